@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/exporter"
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/igferreira/quotes-api/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// runExport writes every quote, or those carrying the given tags, to a file for backups.
+// The file is only left behind when the export completes.
+func runExport(ctx context.Context, svc *service.Service, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: quotesctl export [flags]")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", exporter.FormatNDJSON, "file format, "+strings.Join(exporter.Formats, ", "))
+	withAuthors := flags.Bool("include-authors", false, "include the author of each quote")
+	tags := flags.String("tag", "", "comma-separated tags the exported quotes must all carry")
+	output := flags.String("o", "-", "file to write, - for standard output")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		if flags.NArg() != 0 && err == nil {
+			flags.Usage()
+		}
+		return errUsage
+	}
+
+	filter := repository.QuoteFilter{MatchAllTags: true}
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	var file *os.File
+	if *output != "-" {
+		f, err := os.CreateTemp(filepath.Dir(*output), ".quotes-export-*")
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		out, file = f, f
+	}
+
+	ew, err := exporter.NewWriter(*format, out, *withAuthors)
+	if err != nil {
+		return err
+	}
+
+	rows := 0
+	err = svc.ExportQuotes(ctx, filter, *withAuthors, func(quote *repository.Quote) error {
+		rows++
+		return ew.Write(quote)
+	})
+	if err == nil {
+		err = ew.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export quotes: %w", err)
+	}
+
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		if err := os.Rename(file.Name(), *output); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+	}
+
+	log.Info().Str("file", *output).Str("format", *format).Int("rows", rows).Msg("exported quotes")
+	return nil
+}
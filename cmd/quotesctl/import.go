@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/igferreira/quotes-api/internal/importer"
+	"github.com/igferreira/quotes-api/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// runImport imports a file and prints the report as JSON. It fails when a row was rejected.
+func runImport(ctx context.Context, svc *service.Service, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: quotesctl import [flags] FILE")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "", "file format, csv or ndjson (default: from the file extension)")
+	dryRun := flags.Bool("dry-run", false, "check the file without writing anything")
+	createAuthors := flags.Bool("create-authors", true, "create the authors that do not exist yet")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		if flags.NArg() != 1 && err == nil {
+			flags.Usage()
+		}
+		return errUsage
+	}
+
+	name := flags.Arg(0)
+	var in io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if *format == "" {
+		*format = importer.DetectFormat(name)
+		if *format == "" {
+			return fmt.Errorf("cannot tell the format of %s, use -format", name)
+		}
+	}
+
+	reader, err := importer.NewReader(*format, in)
+	if err != nil {
+		return err
+	}
+
+	report, err := svc.ImportQuotes(ctx, reader, importer.Options{DryRun: *dryRun, CreateAuthors: *createAuthors})
+	if report != nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to import quotes: %w", err)
+	}
+
+	log.Info().Str("file", name).Bool("dry_run", report.DryRun).Int("rows", report.Rows).
+		Int("imported", report.Imported).Int("failed", report.Failed).Msg("imported quotes")
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d rows were not imported", report.Failed, report.Rows)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Command quotesctl runs maintenance tasks against the quotes database.
+//
+// Usage:
+//
+//	quotesctl import [-format csv|ndjson] [-dry-run] [-create-authors=false] FILE
+//	quotesctl export [-format ndjson|csv|json] [-include-authors] [-tag a,b] [-o FILE]
+//
+// The import FILE is read from standard input when it is -, and exports are written to
+// standard output unless -o is given. quotesctl reads the same environment variables as
+// the server and expects the database to be migrated already. Logs are written to
+// standard error and results to standard output.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/igferreira/quotes-api/internal/config"
+	"github.com/igferreira/quotes-api/internal/logger"
+	"github.com/igferreira/quotes-api/internal/repository/postgres"
+	"github.com/igferreira/quotes-api/internal/service"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// errUsage reports a command line that could not be parsed; the usage has been printed already
+var errUsage = errors.New("invalid usage")
+
+// commands maps subcommand names to their implementation
+var commands = map[string]func(ctx context.Context, svc *service.Service, args []string) error{
+	"import": runImport,
+	"export": runExport,
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		usage()
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Setup logger, keeping standard output for results
+	logger.SetupOutput(os.Stderr, cfg.LogLevel, cfg.LogJSON)
+
+	// Stop cleanly on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg, os.Args[1], os.Args[2:]); err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		log.Error().Err(err).Str("command", os.Args[1]).Msg("command failed")
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, command string, args []string) error {
+	poolConfig, err := pgxpool.ParseConfig(cfg.DatabaseURL())
+	if err != nil {
+		return fmt.Errorf("failed to parse database config: %w", err)
+	}
+	poolConfig.MaxConns = cfg.DBMaxConns
+	poolConfig.AfterConnect = postgres.AfterConnect(cfg.AuthorSearchThreshold)
+
+	db, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	repo := postgres.NewRepository(db)
+	svc := service.NewService(repo.AuthorRepo(), repo.QuoteRepo(), repo.TagRepo(), repo.DailyQuoteRepo(), repo)
+
+	return commands[command](ctx, svc, args)
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage: quotesctl <command> [flags] [args]
+
+Commands:
+  import    Import quotes from a CSV or JSON Lines file
+  export    Export quotes as JSON Lines, CSV or JSON
+
+Run quotesctl <command> -h for the flags of a command.
+`)
+}
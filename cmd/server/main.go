@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // time zones of the quote of the day, even without system zoneinfo
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -40,6 +41,10 @@ func main() {
 func run(cfg *config.Config) error {
 	ctx := context.Background()
 
+	if cfg.CursorSecret == "" {
+		log.Warn().Msg("CURSOR_SECRET is not set, pagination cursors will not survive restarts")
+	}
+
 	// Connect to database
 	log.Info().Str("host", cfg.DBHost).Str("database", cfg.DBName).Msg("connecting to database")
 	
@@ -52,6 +57,7 @@ func run(cfg *config.Config) error {
 	poolConfig.MinConns = cfg.DBMinConns
 	poolConfig.MaxConnLifetime = cfg.DBMaxConnLifetime
 	poolConfig.MaxConnIdleTime = cfg.DBMaxConnIdleTime
+	poolConfig.AfterConnect = postgres.AfterConnect(cfg.AuthorSearchThreshold)
 
 	db, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
@@ -74,10 +80,10 @@ func run(cfg *config.Config) error {
 	repo := postgres.NewRepository(db)
 
 	// Create service
-	svc := service.NewService(repo.AuthorRepo(), repo.QuoteRepo())
+	svc := service.NewService(repo.AuthorRepo(), repo.QuoteRepo(), repo.TagRepo(), repo.DailyQuoteRepo(), repo)
 
 	// Create router
-	router := api.NewRouter(svc, db)
+	router := api.NewRouter(cfg, svc, db)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -0,0 +1,417 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Response formats
+const (
+	FormatJSON = "json"
+	FormatXML  = "xml"
+	FormatYAML = "yaml"
+	FormatCSV  = "csv"
+	FormatText = "text"
+)
+
+// problemXMLNamespace is the namespace of RFC 7807 problem details documents in XML
+const problemXMLNamespace = "urn:ietf:rfc:7807"
+
+func init() {
+	RegisterEncoder(&Encoder{
+		Format:           FormatJSON,
+		MediaType:        "application/json",
+		ProblemMediaType: ProblemContentType,
+		Encode:           encodeJSON,
+	})
+	RegisterEncoder(&Encoder{
+		Format:           FormatXML,
+		MediaType:        "application/xml",
+		Aliases:          []string{"text/xml"},
+		ProblemMediaType: "application/problem+xml",
+		Encode:           encodeXML,
+	})
+	RegisterEncoder(&Encoder{
+		Format:    FormatYAML,
+		MediaType: "application/yaml",
+		Aliases:   []string{"application/x-yaml", "text/yaml"},
+		Encode:    encodeYAML,
+	})
+	RegisterEncoder(&Encoder{
+		Format:    FormatCSV,
+		MediaType: "text/csv; charset=utf-8",
+		Encode:    encodeCSV,
+	})
+	RegisterEncoder(&Encoder{
+		Format:    FormatText,
+		MediaType: "text/plain; charset=utf-8",
+		Encode:    encodeText,
+	})
+}
+
+// The XML, YAML, CSV and text encoders render the JSON representation of a value, so that
+// every format uses the same field names and leaves out the same empty fields. The JSON
+// document is parsed into a YAML node tree, which keeps the order of the fields.
+
+// encodeJSON writes a value as JSON, compact unless opts.Pretty is set
+func encodeJSON(w io.Writer, v interface{}, opts EncodeOptions) error {
+	enc := json.NewEncoder(w)
+	if opts.Pretty {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// toNode converts a value to the node tree of its JSON representation
+func toNode(v interface{}) (*yaml.Node, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc.Content[0], nil
+}
+
+// isProblem reports whether a value is a problem details document
+func isProblem(v interface{}) bool {
+	switch v.(type) {
+	case ErrorResponse, *ErrorResponse:
+		return true
+	default:
+		return false
+	}
+}
+
+// encodeYAML writes a value as a YAML document in block style, which is always indented
+func encodeYAML(w io.Writer, v interface{}, _ EncodeOptions) error {
+	node, err := toNode(v)
+	if err != nil {
+		return err
+	}
+	resetStyle(node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// resetStyle drops the JSON flow style and quotes of a node tree, letting the
+// YAML encoder choose them
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// encodeXML writes a value as an XML document. Objects become elements named after
+// their fields and array items i elements, following RFC 7807. Problem details
+// documents have a problem root element, other values a response one. Elements are
+// indented when opts.Pretty is set.
+func encodeXML(w io.Writer, v interface{}, opts EncodeOptions) error {
+	node, err := toNode(v)
+	if err != nil {
+		return err
+	}
+
+	root := xml.StartElement{Name: xml.Name{Local: "response"}}
+	if isProblem(v) {
+		root = xml.StartElement{Name: xml.Name{Space: problemXMLNamespace, Local: "problem"}}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if opts.Pretty {
+		enc.Indent("", "  ")
+	}
+	if err := writeXML(enc, root, node); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// writeXML writes a node as an element
+func writeXML(enc *xml.Encoder, start xml.StartElement, node *yaml.Node) error {
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			child := xml.StartElement{Name: xml.Name{Local: node.Content[i].Value}}
+			if err := writeXML(enc, child, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := writeXML(enc, xml.StartElement{Name: xml.Name{Local: "i"}}, item); err != nil {
+				return err
+			}
+		}
+	default:
+		if node.Tag != "!!null" {
+			if err := enc.EncodeToken(xml.CharData(node.Value)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// encodeCSV writes a value as a CSV table with a header row. Lists, including the data
+// of paginated responses, have a row per item and other values a single row. Nested
+// objects are flattened into columns named after their path, such as author.name; lists
+// of values are joined with pipes and lists of objects written as JSON.
+func encodeCSV(w io.Writer, v interface{}, _ EncodeOptions) error {
+	node, err := toNode(v)
+	if err != nil {
+		return err
+	}
+
+	items := []*yaml.Node{node}
+	if data := field(node, "data"); data != nil && data.Kind == yaml.SequenceNode && !isProblem(v) {
+		items = data.Content
+	} else if node.Kind == yaml.SequenceNode {
+		items = node.Content
+	}
+
+	// items may leave out empty fields, so the columns are collected from all of them
+	var columns []string
+	seen := make(map[string]bool)
+	rows := make([]map[string]string, len(items))
+	for i, item := range items {
+		rows[i] = make(map[string]string)
+		flattenCSV("", item, rows[i], func(column string) {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		})
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			record[i] = row[column]
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// flattenCSV stores the cells of a node in row, calling addColumn for each of them
+func flattenCSV(prefix string, node *yaml.Node, row map[string]string, addColumn func(string)) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name := node.Content[i].Value
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			flattenCSV(name, node.Content[i+1], row, addColumn)
+		}
+		return
+	}
+
+	column := prefix
+	if column == "" {
+		column = "value"
+	}
+	addColumn(column)
+	row[column] = cellValue(node)
+}
+
+// cellValue returns the CSV cell of a scalar or list node
+func cellValue(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		values := make([]string, len(node.Content))
+		for i, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nodeJSON(node)
+			}
+			values[i] = cellValue(item)
+		}
+		return strings.Join(values, "|")
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return ""
+		}
+		return node.Value
+	default:
+		return nodeJSON(node)
+	}
+}
+
+// nodeJSON returns the JSON representation of a node
+func nodeJSON(node *yaml.Node) string {
+	var b strings.Builder
+	writeNodeJSON(&b, node)
+	return b.String()
+}
+
+// writeNodeJSON writes the JSON representation of a node
+func writeNodeJSON(b *strings.Builder, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		b.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(node.Content[i].Value)
+			b.Write(key)
+			b.WriteByte(':')
+			writeNodeJSON(b, node.Content[i+1])
+		}
+		b.WriteByte('}')
+	case yaml.SequenceNode:
+		b.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeNodeJSON(b, item)
+		}
+		b.WriteByte(']')
+	default:
+		if node.Tag == "!!str" {
+			value, _ := json.Marshal(node.Value)
+			b.Write(value)
+		} else {
+			b.WriteString(node.Value)
+		}
+	}
+}
+
+// encodeText writes a value as plain text. Quotes are written one per line as
+// "content" — Author and problem details documents as their status and detail.
+// Other values are written as YAML.
+func encodeText(w io.Writer, v interface{}, opts EncodeOptions) error {
+	if problem, ok := v.(ErrorResponse); ok {
+		return writeProblemText(w, &problem)
+	}
+	if problem, ok := v.(*ErrorResponse); ok {
+		return writeProblemText(w, problem)
+	}
+
+	node, err := toNode(v)
+	if err != nil {
+		return err
+	}
+
+	lines, ok := quoteLines(node)
+	if !ok {
+		return encodeYAML(w, v, opts)
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeProblemText writes a problem details document as plain text
+func writeProblemText(w io.Writer, problem *ErrorResponse) error {
+	if _, err := fmt.Fprintf(w, "%d %s: %s\n", problem.Status, problem.Title, problem.Detail); err != nil {
+		return err
+	}
+	for _, fieldErr := range problem.Errors {
+		if _, err := fmt.Fprintf(w, "  %s: %s\n", fieldErr.Field, fieldErr.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoteLines returns the text lines of the quotes of a node: a quote, a list of quotes or
+// a response holding them in data or quote. ok is false for nodes holding something else.
+func quoteLines(node *yaml.Node) (lines []string, ok bool) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		lines = []string{}
+		for _, item := range node.Content {
+			itemLines, ok := quoteLines(item)
+			if !ok {
+				return nil, false
+			}
+			lines = append(lines, itemLines...)
+		}
+		return lines, true
+
+	case yaml.MappingNode:
+		if content := field(node, "content"); content != nil {
+			return []string{quoteText(content.Value, quoteAuthor(node))}, true
+		}
+		for _, name := range []string{"data", "quote"} {
+			if inner := field(node, name); inner != nil {
+				return quoteLines(inner)
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// quoteAuthor returns the author name of a quote node, empty when it is not known
+func quoteAuthor(node *yaml.Node) string {
+	if name := field(node, "author_name"); name != nil {
+		return name.Value
+	}
+	if author := field(node, "author"); author != nil {
+		if name := field(author, "name"); name != nil {
+			return name.Value
+		}
+	}
+	return ""
+}
+
+// quoteText returns the plain text of a quote
+func quoteText(content, author string) string {
+	if author == "" {
+		return `"` + content + `"`
+	}
+	return `"` + content + `" — ` + author
+}
+
+// field returns the value of a field of an object node, nil when it has no such field
+func field(node *yaml.Node, name string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == name {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
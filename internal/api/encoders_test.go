@@ -0,0 +1,222 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// testQuote has the shape of the quotes of the responses, with the fields the encoders look at
+type testQuote struct {
+	ID         int64    `json:"id"`
+	Content    string   `json:"content"`
+	AuthorName string   `json:"author_name,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+var (
+	quoteValue = testQuote{ID: 1, Content: "Stay hungry", AuthorName: "Steve Jobs", Tags: []string{"life", "work"}}
+
+	pageValue = PaginatedResponse{
+		Data: []testQuote{quoteValue, {ID: 2, Content: "Less is more"}},
+		Meta: PaginationMeta{Limit: 2, NextCursor: "abc"},
+	}
+
+	problemValue = ErrorResponse{
+		Type:   ProblemTypeBaseURI + "validation-error",
+		Title:  "Unprocessable Entity",
+		Status: http.StatusUnprocessableEntity,
+		Detail: "validation failed",
+		Code:   CodeValidation,
+		Errors: []repository.FieldError{{Field: "content", Message: "content is required"}},
+	}
+)
+
+// encoderNamed returns the registered encoder of a format
+func encoderNamed(t *testing.T, format string) *Encoder {
+	t.Helper()
+	for _, enc := range encoders {
+		if enc.Format == format {
+			return enc
+		}
+	}
+	t.Fatalf("no encoder for format %q", format)
+	return nil
+}
+
+func TestEncoders(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		value  interface{}
+		pretty bool
+		want   string
+	}{
+		{
+			name:   "JSON quote",
+			format: FormatJSON,
+			value:  quoteValue,
+			want:   `{"id":1,"content":"Stay hungry","author_name":"Steve Jobs","tags":["life","work"]}` + "\n",
+		},
+		{
+			name:   "JSON pretty",
+			format: FormatJSON,
+			value:  testQuote{ID: 2, Content: "Less is more"},
+			pretty: true,
+			want:   "{\n  \"id\": 2,\n  \"content\": \"Less is more\"\n}\n",
+		},
+		{
+			name:   "JSON page",
+			format: FormatJSON,
+			value:  pageValue,
+			want: `{"data":[{"id":1,"content":"Stay hungry","author_name":"Steve Jobs","tags":["life","work"]},` +
+				`{"id":2,"content":"Less is more"}],"meta":{"limit":2,"offset":0,"next_cursor":"abc"}}` + "\n",
+		},
+		{
+			name:   "JSON problem",
+			format: FormatJSON,
+			value:  problemValue,
+			want: `{"type":"` + ProblemTypeBaseURI + `validation-error","title":"Unprocessable Entity","status":422,` +
+				`"detail":"validation failed","code":"VALIDATION_ERROR","errors":[{"field":"content","message":"content is required"}]}` + "\n",
+		},
+		{
+			name:   "XML quote",
+			format: FormatXML,
+			value:  quoteValue,
+			want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<response><id>1</id><content>Stay hungry</content><author_name>Steve Jobs</author_name>` +
+				`<tags><i>life</i><i>work</i></tags></response>` + "\n",
+		},
+		{
+			name:   "XML pretty escapes text",
+			format: FormatXML,
+			value:  testQuote{ID: 2, Content: "<less> & more"},
+			pretty: true,
+			want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				"<response>\n  <id>2</id>\n  <content>&lt;less&gt; &amp; more</content>\n</response>\n",
+		},
+		{
+			name:   "XML page",
+			format: FormatXML,
+			value:  pageValue,
+			want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<response><data><i><id>1</id><content>Stay hungry</content><author_name>Steve Jobs</author_name>` +
+				`<tags><i>life</i><i>work</i></tags></i><i><id>2</id><content>Less is more</content></i></data>` +
+				`<meta><limit>2</limit><offset>0</offset><next_cursor>abc</next_cursor></meta></response>` + "\n",
+		},
+		{
+			name:   "XML problem",
+			format: FormatXML,
+			value:  &problemValue,
+			want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<problem xmlns="urn:ietf:rfc:7807"><type>` + ProblemTypeBaseURI + `validation-error</type>` +
+				`<title>Unprocessable Entity</title><status>422</status><detail>validation failed</detail>` +
+				`<code>VALIDATION_ERROR</code><errors><i><field>content</field><message>content is required</message></i></errors></problem>` + "\n",
+		},
+		{
+			name:   "YAML quote",
+			format: FormatYAML,
+			value:  quoteValue,
+			want:   "id: 1\ncontent: Stay hungry\nauthor_name: Steve Jobs\ntags:\n  - life\n  - work\n",
+		},
+		{
+			name:   "YAML page",
+			format: FormatYAML,
+			value:  pageValue,
+			want: "data:\n  - id: 1\n    content: Stay hungry\n    author_name: Steve Jobs\n    tags:\n      - life\n      - work\n" +
+				"  - id: 2\n    content: Less is more\nmeta:\n  limit: 2\n  offset: 0\n  next_cursor: abc\n",
+		},
+		{
+			name:   "YAML problem",
+			format: FormatYAML,
+			value:  problemValue,
+			want: "type: " + ProblemTypeBaseURI + "validation-error\ntitle: Unprocessable Entity\nstatus: 422\n" +
+				"detail: validation failed\ncode: VALIDATION_ERROR\nerrors:\n  - field: content\n    message: content is required\n",
+		},
+		{
+			name:   "CSV quote",
+			format: FormatCSV,
+			value:  quoteValue,
+			want:   "id,content,author_name,tags\n1,Stay hungry,Steve Jobs,life|work\n",
+		},
+		{
+			name:   "CSV page has a row per item and the columns of all of them",
+			format: FormatCSV,
+			value:  pageValue,
+			want:   "id,content,author_name,tags\n1,Stay hungry,Steve Jobs,life|work\n2,Less is more,,\n",
+		},
+		{
+			name:   "CSV list",
+			format: FormatCSV,
+			value:  []testQuote{{ID: 2, Content: `"Less", is more`}},
+			want:   "id,content\n2,\"\"\"Less\"\", is more\"\n",
+		},
+		{
+			name:   "CSV problem flattens nested lists to JSON",
+			format: FormatCSV,
+			value:  problemValue,
+			want: "type,title,status,detail,code,errors\n" + ProblemTypeBaseURI + "validation-error,Unprocessable Entity,422," +
+				`validation failed,VALIDATION_ERROR,"[{""field"":""content"",""message"":""content is required""}]"` + "\n",
+		},
+		{
+			name:   "text quote",
+			format: FormatText,
+			value:  quoteValue,
+			want:   "\"Stay hungry\" — Steve Jobs\n",
+		},
+		{
+			name:   "text page has a line per quote",
+			format: FormatText,
+			value:  pageValue,
+			want:   "\"Stay hungry\" — Steve Jobs\n\"Less is more\"\n",
+		},
+		{
+			name:   "text problem",
+			format: FormatText,
+			value:  problemValue,
+			want:   "422 Unprocessable Entity: validation failed\n  content: content is required\n",
+		},
+		{
+			name:   "text falls back to YAML",
+			format: FormatText,
+			value:  PaginationMeta{Limit: 2},
+			want:   "limit: 2\noffset: 0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := encoderNamed(t, tt.format).Encode(&b, tt.value, EncodeOptions{Pretty: tt.pretty}); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Encode() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoderContentType(t *testing.T) {
+	tests := []struct {
+		format  string
+		problem bool
+		want    string
+	}{
+		{format: FormatJSON, want: "application/json"},
+		{format: FormatJSON, problem: true, want: ProblemContentType},
+		{format: FormatXML, want: "application/xml"},
+		{format: FormatXML, problem: true, want: "application/problem+xml"},
+		{format: FormatYAML, problem: true, want: "application/yaml"},
+		{format: FormatCSV, want: "text/csv; charset=utf-8"},
+		{format: FormatText, problem: true, want: "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		if got := encoderNamed(t, tt.format).contentType(tt.problem); got != tt.want {
+			t.Errorf("%s contentType(%v) = %q, want %q", tt.format, tt.problem, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// Stable error codes returned in ErrorResponse.Code
+const (
+	CodeRouteNotFound        = "ROUTE_NOT_FOUND"
+	CodeMethodNotAllowed     = "METHOD_NOT_ALLOWED"
+	CodeInvalidRequestBody   = "INVALID_REQUEST_BODY"
+	CodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+	CodeRequestBodyTooLarge  = "REQUEST_BODY_TOO_LARGE"
+	CodeNotFound             = "NOT_FOUND"
+	CodeConflict             = "CONFLICT"
+	CodeConstraintViolation  = "CONSTRAINT_VIOLATION"
+	CodeValidation           = "VALIDATION_ERROR"
+	CodePreconditionFailed   = "PRECONDITION_FAILED"
+	CodeInvalidCursor        = "INVALID_CURSOR"
+	CodeBatchAborted         = "BATCH_ABORTED"
+	CodeNotAcceptable        = "NOT_ACCEPTABLE"
+	CodeInternal             = "INTERNAL_ERROR"
+)
+
+// ErrorStatus maps an error returned by the service layer to an HTTP status code
+// and a stable error code. Resource specific errors get the resource as a prefix,
+// e.g. AUTHOR_NOT_FOUND.
+func ErrorStatus(err error) (int, string) {
+	var status int
+	var code string
+
+	switch {
+	case errors.Is(kindOf(err), repository.ErrNotFound):
+		status, code = http.StatusNotFound, CodeNotFound
+	case errors.Is(kindOf(err), repository.ErrConflict):
+		status, code = http.StatusConflict, CodeConflict
+	case errors.Is(kindOf(err), repository.ErrConstraint):
+		status, code = http.StatusUnprocessableEntity, CodeConstraintViolation
+	case errors.Is(kindOf(err), repository.ErrPreconditionFailed):
+		status, code = http.StatusPreconditionFailed, CodePreconditionFailed
+	case errors.Is(kindOf(err), repository.ErrValidation):
+		return http.StatusUnprocessableEntity, CodeValidation
+	default:
+		return http.StatusInternalServerError, CodeInternal
+	}
+
+	var domainErr *repository.Error
+	if errors.As(err, &domainErr) && domainErr.Resource != "" {
+		code = strings.ToUpper(domainErr.Resource) + "_" + code
+	}
+
+	return status, code
+}
+
+// kindOf returns the kind of the outermost domain error, or err itself
+// when it does not wrap one
+func kindOf(err error) error {
+	var domainErr *repository.Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Kind
+	}
+	return err
+}
+
+// RespondServiceError sends an error response for an error returned by the service layer.
+// Internal errors are reported with a generic message so storage details do not leak.
+func RespondServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	RespondProblem(w, r, ServiceProblem(r, err))
+}
+
+// ServiceProblem builds the problem details document describing an error returned by the service layer
+func ServiceProblem(r *http.Request, err error) ErrorResponse {
+	status, code := ErrorStatus(err)
+
+	var domainErr *repository.Error
+	switch {
+	case status == http.StatusInternalServerError:
+		err = errors.New("an unexpected error occurred")
+	case errors.As(err, &domainErr):
+		err = domainErr
+	}
+
+	return Problem(r, status, err, code)
+}
+
+// NotFound handles requests for routes that do not exist
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	RespondError(w, r, http.StatusNotFound, fmt.Errorf("no route matches %s", r.URL.Path), CodeRouteNotFound)
+}
+
+// MethodNotAllowed handles requests with a method the route does not support
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	RespondError(w, r, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed on %s", r.Method, r.URL.Path), CodeMethodNotAllowed)
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+	"gopkg.in/yaml.v3"
+)
+
+// FieldsParam is the query parameter selecting the fields of GET responses
+const FieldsParam = "fields"
+
+// dataField is the field of the envelopes holding the items of list responses.
+// Field selection applies to the items, and the rest of the envelope is kept.
+const dataField = "data"
+
+// fieldPathPattern matches a field name or a dotted path to a nested field
+var fieldPathPattern = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_]+)*$`)
+
+// fieldSelection is a tree of selected fields. A nil subtree selects a field whole.
+type fieldSelection map[string]fieldSelection
+
+// parseFields parses the fields query parameter: comma-separated field names, with
+// dots selecting fields of nested objects, such as id,content,author.name
+func parseFields(value string) (fieldSelection, []string, error) {
+	var paths []string
+	for _, path := range strings.Split(value, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !fieldPathPattern.MatchString(path) {
+			return nil, nil, fieldsError(fmt.Sprintf("fields must be comma-separated field names, got %q", path))
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return nil, nil, nil
+	}
+
+	selection := fieldSelection{}
+	for _, path := range paths {
+		selection.add(strings.Split(path, "."))
+	}
+	return selection, paths, nil
+}
+
+// add selects the field at a path. Selecting a field whole takes precedence over
+// selecting some of its fields.
+func (s fieldSelection) add(path []string) {
+	sub, seen := s[path[0]]
+	if len(path) == 1 {
+		s[path[0]] = nil
+		return
+	}
+	if seen && sub == nil {
+		return
+	}
+	if sub == nil {
+		sub = fieldSelection{}
+		s[path[0]] = sub
+	}
+	sub.add(path[1:])
+}
+
+// fieldsError builds the validation error of an invalid fields query parameter
+func fieldsError(message string) error {
+	return &repository.Error{
+		Kind:    repository.ErrValidation,
+		Message: message,
+		Fields:  []repository.FieldError{{Field: FieldsParam, Message: message}},
+	}
+}
+
+// respondFieldsError answers a request with an invalid fields query parameter, whether
+// malformed or naming unknown fields, with 400 Bad Request
+func respondFieldsError(w http.ResponseWriter, r *http.Request, err error) {
+	RespondError(w, r, http.StatusBadRequest, err, CodeValidation)
+}
+
+// checkFields verifies that every selected path names a field of the items of a response
+func checkFields(data interface{}, paths []string) error {
+	known := make(map[string]bool)
+	collectFields(itemType(data), "", known, 0)
+
+	for _, path := range paths {
+		if !known[path] {
+			names := make([]string, 0, len(known))
+			for name := range known {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fieldsError(fmt.Sprintf("fields has unknown field %q, known fields are %s", path, strings.Join(names, ", ")))
+		}
+	}
+	return nil
+}
+
+// itemType returns the type of the values field selection applies to: the items of the
+// data of an envelope, or the response itself
+func itemType(data interface{}) reflect.Type {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name == dataField {
+				if inner := v.Field(i); inner.Kind() != reflect.Interface || !inner.IsNil() {
+					return reflect.ValueOf(inner.Interface()).Type()
+				}
+			}
+		}
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Type()
+}
+
+// textMarshalerType is the type of encoding.TextMarshaler
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// maxFieldDepth bounds the nesting of the paths collected for a type
+const maxFieldDepth = 4
+
+// collectFields adds the JSON paths of the fields of a type to known
+func collectFields(t reflect.Type, prefix string, known map[string]bool, depth int) {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || depth > maxFieldDepth {
+		return
+	}
+	// structs marshaled as strings, such as times and dates, have no fields of their own
+	if reflect.PointerTo(t).Implements(textMarshalerType) {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			// embedded structs are flattened into their parent
+			collectFields(f.Type, prefix, known, depth)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		path := prefix + name
+		known[path] = true
+		collectFields(f.Type, path+".", known, depth+1)
+	}
+}
+
+// selectFields keeps the selected fields of the items of a response node
+func selectFields(node *yaml.Node, selection fieldSelection) {
+	if data := field(node, dataField); data != nil && data.Kind == yaml.SequenceNode {
+		selectNode(data, selection)
+		return
+	}
+	selectNode(node, selection)
+}
+
+// selectNode keeps the selected fields of an object node, or of the items of a list node
+func selectNode(node *yaml.Node, selection fieldSelection) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			selectNode(item, selection)
+		}
+	case yaml.MappingNode:
+		kept := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			sub, ok := selection[node.Content[i].Value]
+			if !ok {
+				continue
+			}
+			if sub != nil {
+				selectNode(node.Content[i+1], sub)
+			}
+			kept = append(kept, node.Content[i], node.Content[i+1])
+		}
+		node.Content = kept
+	}
+}
+
+// document is a response held as the node tree of its JSON representation. It is
+// rendered by every encoder like the value it was built from.
+type document struct {
+	node *yaml.Node
+}
+
+// MarshalJSON returns the JSON representation of the document
+func (d document) MarshalJSON() ([]byte, error) {
+	return []byte(nodeJSON(d.node)), nil
+}
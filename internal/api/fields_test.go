@@ -0,0 +1,270 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// fieldsAuthor and fieldsQuote have the shape of a quote read with its author
+type fieldsAuthor struct {
+	ID   int64   `json:"id"`
+	Name string  `json:"name"`
+	Bio  *string `json:"bio,omitempty"`
+}
+
+type fieldsQuote struct {
+	ID      int64         `json:"id"`
+	Content string        `json:"content"`
+	Author  *fieldsAuthor `json:"author,omitempty"`
+}
+
+var fieldsQuoteValue = &fieldsQuote{ID: 1, Content: "Stay hungry", Author: &fieldsAuthor{ID: 7, Name: "Steve Jobs"}}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		want      fieldSelection
+		wantPaths []string
+		wantErr   bool
+	}{
+		{
+			name:  "empty",
+			value: "",
+		},
+		{
+			name:  "only separators",
+			value: " , ,",
+		},
+		{
+			name:      "fields",
+			value:     "id, content",
+			want:      fieldSelection{"id": nil, "content": nil},
+			wantPaths: []string{"id", "content"},
+		},
+		{
+			name:      "nested field",
+			value:     "id,author.name",
+			want:      fieldSelection{"id": nil, "author": {"name": nil}},
+			wantPaths: []string{"id", "author.name"},
+		},
+		{
+			name:      "nested fields share their parent",
+			value:     "author.id,author.name",
+			want:      fieldSelection{"author": {"id": nil, "name": nil}},
+			wantPaths: []string{"author.id", "author.name"},
+		},
+		{
+			name:      "whole field after its fields",
+			value:     "author.name,author",
+			want:      fieldSelection{"author": nil},
+			wantPaths: []string{"author.name", "author"},
+		},
+		{
+			name:      "whole field before its fields",
+			value:     "author,author.name",
+			want:      fieldSelection{"author": nil},
+			wantPaths: []string{"author", "author.name"},
+		},
+		{
+			name:    "upper case",
+			value:   "id,Content",
+			wantErr: true,
+		},
+		{
+			name:    "empty path segment",
+			value:   "author..name",
+			wantErr: true,
+		},
+		{
+			name:    "trailing dot",
+			value:   "author.",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, paths, err := parseFields(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, repository.ErrValidation) {
+					t.Errorf("parseFields(%q) error = %v, want %v", tt.value, err, repository.ErrValidation)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFields(%q) error = %v", tt.value, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFields(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("parseFields(%q) paths = %v, want %v", tt.value, paths, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestCheckFields(t *testing.T) {
+	page := PaginatedResponse{Data: []*fieldsQuote{fieldsQuoteValue}, Meta: PaginationMeta{Limit: 1}}
+
+	tests := []struct {
+		name    string
+		data    interface{}
+		paths   []string
+		wantErr bool
+	}{
+		{name: "fields", data: fieldsQuoteValue, paths: []string{"id", "content"}},
+		{name: "nested field", data: fieldsQuoteValue, paths: []string{"author.name", "author.bio"}},
+		{name: "whole nested object", data: fieldsQuoteValue, paths: []string{"author"}},
+		{name: "fields of the items of a page", data: page, paths: []string{"id", "author.name"}},
+		{name: "fields of the items of a list", data: []fieldsQuote{*fieldsQuoteValue}, paths: []string{"content"}},
+		{name: "unknown field", data: fieldsQuoteValue, paths: []string{"id", "author_name"}, wantErr: true},
+		{name: "unknown nested field", data: fieldsQuoteValue, paths: []string{"author.born"}, wantErr: true},
+		{name: "fields of a scalar", data: fieldsQuoteValue, paths: []string{"content.length"}, wantErr: true},
+		{name: "envelope fields", data: page, paths: []string{"meta"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkFields(tt.data, tt.paths)
+			if tt.wantErr {
+				var domainErr *repository.Error
+				if !errors.As(err, &domainErr) || domainErr.Kind != repository.ErrValidation {
+					t.Fatalf("checkFields(%v) error = %v, want a validation error", tt.paths, err)
+				}
+				if len(domainErr.Fields) != 1 || domainErr.Fields[0].Field != FieldsParam {
+					t.Errorf("checkFields(%v) field errors = %+v, want one on %s", tt.paths, domainErr.Fields, FieldsParam)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("checkFields(%v) error = %v", tt.paths, err)
+			}
+		})
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	page := PaginatedResponse{
+		Data: []*fieldsQuote{fieldsQuoteValue, {ID: 2, Content: "Less is more"}},
+		Meta: PaginationMeta{Limit: 2, NextCursor: "abc"},
+	}
+
+	tests := []struct {
+		name   string
+		data   interface{}
+		fields string
+		want   string
+	}{
+		{
+			name:   "fields in document order",
+			data:   fieldsQuoteValue,
+			fields: "content,id",
+			want:   `{"id":1,"content":"Stay hungry"}`,
+		},
+		{
+			name:   "nested field",
+			data:   fieldsQuoteValue,
+			fields: "id,author.name",
+			want:   `{"id":1,"author":{"name":"Steve Jobs"}}`,
+		},
+		{
+			name:   "whole field overrides its fields",
+			data:   fieldsQuoteValue,
+			fields: "author.name,author",
+			want:   `{"author":{"id":7,"name":"Steve Jobs"}}`,
+		},
+		{
+			name:   "items of a page keep the meta",
+			data:   page,
+			fields: "content,author.name",
+			want: `{"data":[{"content":"Stay hungry","author":{"name":"Steve Jobs"}},{"content":"Less is more"}],` +
+				`"meta":{"limit":2,"offset":0,"next_cursor":"abc"}}`,
+		},
+		{
+			name:   "items of a list",
+			data:   []*fieldsQuote{fieldsQuoteValue},
+			fields: "id",
+			want:   `[{"id":1}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, _, err := parseFields(tt.fields)
+			if err != nil {
+				t.Fatalf("parseFields(%q) error = %v", tt.fields, err)
+			}
+			node, err := toNode(tt.data)
+			if err != nil {
+				t.Fatalf("toNode() error = %v", err)
+			}
+
+			selectFields(node, selection)
+			if got := nodeJSON(node); got != tt.want {
+				t.Errorf("selectFields(%q) = %s, want %s", tt.fields, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRespondWithFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		fields     string
+		wantStatus int
+		want       string
+	}{
+		{
+			name:       "selected fields",
+			fields:     "id,author.name",
+			wantStatus: http.StatusOK,
+			want:       `{"id":1,"author":{"name":"Steve Jobs"}}` + "\n",
+		},
+		{
+			name:       "unknown field",
+			fields:     "id,author_name",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "malformed fields",
+			fields:     "id,Content",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Negotiate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Respond(w, r, http.StatusOK, fieldsQuoteValue)
+			}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/1?fields="+tt.fields, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if got := w.Body.String(); got != tt.want {
+					t.Errorf("body = %s, want %s", got, tt.want)
+				}
+				return
+			}
+
+			var problem ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("failed to decode problem: %v", err)
+			}
+			if problem.Code != CodeValidation || len(problem.Errors) != 1 || problem.Errors[0].Field != FieldsParam {
+				t.Errorf("problem = %+v, want %s on %s", problem, CodeValidation, FieldsParam)
+			}
+		})
+	}
+}
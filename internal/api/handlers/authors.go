@@ -1,51 +1,59 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/igferreira/quotes-api/internal/api"
+	"github.com/igferreira/quotes-api/internal/pagination"
 	"github.com/igferreira/quotes-api/internal/repository"
 	"github.com/igferreira/quotes-api/internal/service"
+	"github.com/igferreira/quotes-api/internal/validation"
 	"github.com/rs/zerolog/log"
 )
 
 // AuthorHandler handles author-related requests
 type AuthorHandler struct {
-	service *service.Service
+	service      *service.Service
+	cursors      *pagination.Codec
+	maxBodyBytes int64
 }
 
 // NewAuthorHandler creates a new author handler
-func NewAuthorHandler(service *service.Service) *AuthorHandler {
+func NewAuthorHandler(service *service.Service, cursors *pagination.Codec, maxBodyBytes int64) *AuthorHandler {
 	return &AuthorHandler{
-		service: service,
+		service:      service,
+		cursors:      cursors,
+		maxBodyBytes: maxBodyBytes,
 	}
 }
 
 // Create handles POST /authors
 func (h *AuthorHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var params repository.CreateAuthorParams
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		api.RespondError(w, http.StatusBadRequest, err, "INVALID_REQUEST_BODY")
+	if err := decodeJSON(w, r, &params, h.maxBodyBytes); err != nil {
+		respondDecodeError(w, r, err)
 		return
 	}
 
 	// Validate input
-	if params.Name == "" {
-		api.RespondError(w, http.StatusBadRequest, ErrValidation("name is required"), "VALIDATION_ERROR")
+	if err := validation.Validate(&params); err != nil {
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
 	author, err := h.service.CreateAuthor(r.Context(), params)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to create author")
-		api.RespondError(w, http.StatusInternalServerError, err, "CREATE_AUTHOR_ERROR")
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
-	api.RespondJSON(w, http.StatusCreated, author)
+	setETag(w, r, author.Version)
+	api.Respond(w, r, http.StatusCreated, author)
 }
 
 // GetByID handles GET /authors/{id}
@@ -53,32 +61,73 @@ func (h *AuthorHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		api.RespondError(w, http.StatusBadRequest, err, "INVALID_ID")
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_ID")
+		return
+	}
+
+	expansion, err := parseAuthorExpansion(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
 	author, err := h.service.GetAuthor(r.Context(), id)
 	if err != nil {
 		log.Error().Err(err).Int64("id", id).Msg("failed to get author")
-		api.RespondError(w, http.StatusNotFound, err, "AUTHOR_NOT_FOUND")
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
-	api.RespondJSON(w, http.StatusOK, author)
+	// the entity tag does not cover the quotes, so an expanded author is always sent untagged
+	if !expanded(expansion) {
+		if notModified(w, r, etag(r, author.Version)) {
+			return
+		}
+		setETag(w, r, author.Version)
+	}
+
+	if err := h.service.ExpandAuthors(r.Context(), []*repository.Author{author}, expansion); err != nil {
+		log.Error().Err(err).Int64("id", id).Msg("failed to expand author")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+	api.Respond(w, r, http.StatusOK, author)
 }
 
 // List handles GET /authors
 func (h *AuthorHandler) List(w http.ResponseWriter, r *http.Request) {
-	params := parsePaginationParams(r)
+	params, err := parseListParams(r, h.cursors, pagination.KindAuthors)
+	if err != nil {
+		respondListParamsError(w, r, err)
+		return
+	}
+
+	filter, err := parseAuthorFilter(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
 
-	authors, total, err := h.service.ListAuthors(r.Context(), params)
+	expansion, err := parseAuthorExpansion(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	authors, total, err := h.service.ListAuthors(r.Context(), filter, withLookahead(params))
 	if err != nil {
 		log.Error().Err(err).Msg("failed to list authors")
-		api.RespondError(w, http.StatusInternalServerError, err, "LIST_AUTHORS_ERROR")
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
-	api.RespondPaginated(w, authors, total, params.Limit, params.Offset)
+	authors, meta := paginate(r, authors, total, params, h.cursors, pagination.KindAuthors, authorSortValue)
+	if err := h.service.ExpandAuthors(r.Context(), authors, expansion); err != nil {
+		log.Error().Err(err).Msg("failed to expand authors")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+	api.RespondPaginated(w, r, authors, meta)
 }
 
 // Update handles PUT /authors/{id}
@@ -86,30 +135,75 @@ func (h *AuthorHandler) Update(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		api.RespondError(w, http.StatusBadRequest, err, "INVALID_ID")
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_ID")
 		return
 	}
 
 	var params repository.UpdateAuthorParams
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		api.RespondError(w, http.StatusBadRequest, err, "INVALID_REQUEST_BODY")
+	if err := decodeJSON(w, r, &params, h.maxBodyBytes); err != nil {
+		respondDecodeError(w, r, err)
 		return
 	}
 
 	// Validate input
-	if params.Name == "" {
-		api.RespondError(w, http.StatusBadRequest, ErrValidation("name is required"), "VALIDATION_ERROR")
+	if err := validation.Validate(&params); err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	params.ExpectedVersion, err = parseIfMatch(r, h.version(id))
+	if err != nil {
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
 	author, err := h.service.UpdateAuthor(r.Context(), id, params)
 	if err != nil {
 		log.Error().Err(err).Int64("id", id).Msg("failed to update author")
-		api.RespondError(w, http.StatusInternalServerError, err, "UPDATE_AUTHOR_ERROR")
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
-	api.RespondJSON(w, http.StatusOK, author)
+	setETag(w, r, author.Version)
+	api.Respond(w, r, http.StatusOK, author)
+}
+
+// Patch handles PATCH /authors/{id} with a JSON merge patch document
+func (h *AuthorHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_ID")
+		return
+	}
+
+	var params repository.PatchAuthorParams
+	if err := decodeMergePatch(w, r, &params, h.maxBodyBytes); err != nil {
+		respondDecodeError(w, r, err)
+		return
+	}
+
+	// Validate input
+	if err := validation.Validate(&params); err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	params.ExpectedVersion, err = parseIfMatch(r, h.version(id))
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	author, err := h.service.PatchAuthor(r.Context(), id, params)
+	if err != nil {
+		log.Error().Err(err).Int64("id", id).Msg("failed to patch author")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, r, author.Version)
+	api.Respond(w, r, http.StatusOK, author)
 }
 
 // Delete handles DELETE /authors/{id}
@@ -117,36 +211,158 @@ func (h *AuthorHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		api.RespondError(w, http.StatusBadRequest, err, "INVALID_ID")
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_ID")
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r, h.version(id))
+	if err != nil {
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
-	err = h.service.DeleteAuthor(r.Context(), id)
+	err = h.service.DeleteAuthor(r.Context(), id, expectedVersion)
 	if err != nil {
 		log.Error().Err(err).Int64("id", id).Msg("failed to delete author")
-		api.RespondError(w, http.StatusBadRequest, err, "DELETE_AUTHOR_ERROR")
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// AddAlias handles POST /authors/{id}/aliases
+func (h *AuthorHandler) AddAlias(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_ID")
+		return
+	}
+
+	var params repository.AddAliasParams
+	if err := decodeJSON(w, r, &params, h.maxBodyBytes); err != nil {
+		respondDecodeError(w, r, err)
+		return
+	}
+
+	// Validate input
+	if err := validation.Validate(&params); err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	params.ExpectedVersion, err = parseIfMatch(r, h.version(id))
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	author, err := h.service.AddAuthorAlias(r.Context(), id, params)
+	if err != nil {
+		log.Error().Err(err).Int64("id", id).Str("alias", params.Name).Msg("failed to add author alias")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, r, author.Version)
+	api.Respond(w, r, http.StatusOK, author)
+}
+
+// RemoveAlias handles DELETE /authors/{id}/aliases/{alias}
+func (h *AuthorHandler) RemoveAlias(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_ID")
+		return
+	}
+
+	// chi routes on the escaped path when it is not escaped the default way, as when
+	// an alias holds a slash, and the alias then arrives escaped
+	alias := chi.URLParam(r, "alias")
+	if r.URL.RawPath != "" {
+		alias, err = url.PathUnescape(alias)
+		if err != nil {
+			api.RespondServiceError(w, r, invalidParam("alias", "alias must be a valid URL path segment"))
+			return
+		}
+	}
+
+	expectedVersion, err := parseIfMatch(r, h.version(id))
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	err = h.service.RemoveAuthorAlias(r.Context(), id, alias, expectedVersion)
+	if err != nil {
+		log.Error().Err(err).Int64("id", id).Str("alias", alias).Msg("failed to remove author alias")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Batch handles POST /authors:batch
+func (h *AuthorHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	runBatch(w, r, repository.ResourceAuthor, h.maxBodyBytes, h.service.BatchAuthors)
+}
+
 // Search handles GET /authors/search
 func (h *AuthorHandler) Search(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	if query == "" {
-		api.RespondError(w, http.StatusBadRequest, ErrValidation("search query is required"), "VALIDATION_ERROR")
+		api.RespondError(w, r, http.StatusBadRequest, ErrValidation("search query is required"), "VALIDATION_ERROR")
+		return
+	}
+
+	params, err := parseListParams(r, h.cursors, pagination.KindAuthorSearch)
+	if err != nil {
+		respondListParamsError(w, r, err)
 		return
 	}
 
-	params := parsePaginationParams(r)
+	filter, err := parseAuthorFilter(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	expansion, err := parseAuthorExpansion(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
 
-	authors, total, err := h.service.SearchAuthors(r.Context(), query, params)
+	authors, total, err := h.service.SearchAuthors(r.Context(), query, filter, withLookahead(params))
 	if err != nil {
 		log.Error().Err(err).Str("query", query).Msg("failed to search authors")
-		api.RespondError(w, http.StatusInternalServerError, err, "SEARCH_AUTHORS_ERROR")
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
-	api.RespondPaginated(w, authors, total, params.Limit, params.Offset)
+	authors, meta := paginate(r, authors, total, params, h.cursors, pagination.KindAuthorSearch, authorSearchSortValue)
+	found := make([]*repository.Author, len(authors))
+	for i, author := range authors {
+		found[i] = &author.Author
+	}
+	if err := h.service.ExpandAuthors(r.Context(), found, expansion); err != nil {
+		log.Error().Err(err).Str("query", query).Msg("failed to expand authors")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+	api.RespondPaginated(w, r, authors, meta)
+}
+
+// version looks up the stored version of an author for If-Match lists
+func (h *AuthorHandler) version(id int64) versionLookup {
+	return func(ctx context.Context) (int64, error) {
+		author, err := h.service.GetAuthor(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return author.Version, nil
+	}
 }
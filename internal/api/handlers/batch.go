@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/igferreira/quotes-api/internal/api"
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/igferreira/quotes-api/internal/validation"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxBatchOperations is the maximum number of operations of a batch request
+const MaxBatchOperations = 1000
+
+// batchRequest is the body of a batch request. Batches are atomic unless atomic is false.
+type batchRequest struct {
+	Atomic     *bool            `json:"atomic"`
+	Operations []batchOperation `json:"operations"`
+}
+
+// batchOperation is one operation of a batch request. Data holds the body of the
+// equivalent POST or PUT request and version the entity tag sent in If-Match.
+type batchOperation struct {
+	Op      string          `json:"op"`
+	ID      int64           `json:"id"`
+	Version *int64          `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// BatchResponse represents the outcome of a batch request
+type BatchResponse struct {
+	Atomic    bool          `json:"atomic"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// BatchResult represents the outcome of one operation of a batch: its status and either the
+// created or updated resource, or the problem details of its failure
+type BatchResult struct {
+	Index  int                `json:"index"`
+	Status int                `json:"status"`
+	Data   interface{}        `json:"data,omitempty"`
+	Error  *api.ErrorResponse `json:"error,omitempty"`
+}
+
+// runBatch handles a batch request for a resource: it decodes and validates the operations,
+// runs them and responds with the result of each. Invalid operations fail an atomic batch
+// before anything is written and are skipped otherwise.
+func runBatch[C, U, T any](w http.ResponseWriter, r *http.Request, resource string, maxBytes int64,
+	run func(context.Context, []repository.Operation[C, U], bool) ([]repository.OperationResult[T], error)) {
+	var req batchRequest
+	if err := decodeJSON(w, r, &req, maxBytes); err != nil {
+		respondDecodeError(w, r, err)
+		return
+	}
+
+	if len(req.Operations) == 0 || len(req.Operations) > MaxBatchOperations {
+		api.RespondServiceError(w, r, invalidParam("operations",
+			fmt.Sprintf("operations must hold between 1 and %d operations", MaxBatchOperations)))
+		return
+	}
+
+	resp := BatchResponse{
+		Atomic:  req.Atomic == nil || *req.Atomic,
+		Results: make([]BatchResult, len(req.Operations)),
+	}
+
+	// valid operations and their position in the request
+	var ops []repository.Operation[C, U]
+	var indexes []int
+	for i, raw := range req.Operations {
+		resp.Results[i].Index = i
+
+		op, err := parseOperation[C, U](raw)
+		if err != nil {
+			resp.Results[i].fail(operationProblem(r, err))
+			continue
+		}
+		ops = append(ops, op)
+		indexes = append(indexes, i)
+	}
+
+	if resp.Atomic && len(ops) < len(req.Operations) {
+		resp.abort(r, -1)
+		respondBatch(w, r, http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	results, err := run(r.Context(), ops, resp.Atomic)
+	if err != nil {
+		var batchErr *repository.BatchError
+		if !errors.As(err, &batchErr) {
+			log.Error().Err(err).Msgf("failed to run %s batch", resource)
+			api.RespondServiceError(w, r, err)
+			return
+		}
+
+		log.Error().Err(batchErr.Err).Int("index", batchErr.Index).Msgf("failed to run %s batch", resource)
+		problem := api.ServiceProblem(r, batchErr.Err)
+		resp.Results[batchErr.Index].fail(problem)
+		resp.abort(r, batchErr.Index)
+		respondBatch(w, r, problem.Status, resp)
+		return
+	}
+
+	for j, result := range results {
+		i := indexes[j]
+		if result.Err != nil {
+			log.Error().Err(result.Err).Int("index", i).Msgf("failed to apply %s batch operation", resource)
+			resp.Results[i].fail(api.ServiceProblem(r, result.Err))
+			continue
+		}
+
+		switch ops[j].Op {
+		case repository.OpCreate:
+			resp.Results[i].Status = http.StatusCreated
+			resp.Results[i].Data = result.Value
+		case repository.OpUpdate:
+			resp.Results[i].Status = http.StatusOK
+			resp.Results[i].Data = result.Value
+		case repository.OpDelete:
+			resp.Results[i].Status = http.StatusNoContent
+		}
+	}
+
+	respondBatch(w, r, http.StatusOK, resp)
+}
+
+// fail records the failure of an operation
+func (res *BatchResult) fail(problem api.ErrorResponse) {
+	res.Status = problem.Status
+	res.Error = &problem
+}
+
+// abort marks the operations of an atomic batch that did not fail themselves as not applied
+// because of the operation at index failed, or because of invalid operations when index is negative
+func (resp *BatchResponse) abort(r *http.Request, index int) {
+	cause := "the batch contains invalid operations"
+	if index >= 0 {
+		cause = fmt.Sprintf("operation %d failed", index)
+	}
+
+	for i := range resp.Results {
+		if resp.Results[i].Error != nil {
+			continue
+		}
+		resp.Results[i].fail(api.Problem(r, http.StatusFailedDependency,
+			fmt.Errorf("operation was not applied because %s", cause), api.CodeBatchAborted))
+		resp.Results[i].Data = nil
+	}
+}
+
+// respondBatch counts the outcomes of a batch and sends its response
+func respondBatch(w http.ResponseWriter, r *http.Request, status int, resp BatchResponse) {
+	for _, res := range resp.Results {
+		if res.Error != nil {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+	api.Respond(w, r, status, resp)
+}
+
+// parseOperation decodes and validates the data of an operation like the body of the
+// equivalent single request
+func parseOperation[C, U any](raw batchOperation) (repository.Operation[C, U], error) {
+	op := repository.Operation[C, U]{
+		Op:              raw.Op,
+		ID:              raw.ID,
+		ExpectedVersion: raw.Version,
+	}
+
+	switch raw.Op {
+	case repository.OpCreate:
+		if raw.ID != 0 || raw.Version != nil {
+			return op, invalidParam("id", "id and version are not allowed when creating")
+		}
+		return op, decodeOperationData(raw.Data, &op.Create)
+
+	case repository.OpUpdate:
+		if raw.ID < 1 {
+			return op, invalidParam("id", "id is required")
+		}
+		return op, decodeOperationData(raw.Data, &op.Update)
+
+	case repository.OpDelete:
+		if raw.ID < 1 {
+			return op, invalidParam("id", "id is required")
+		}
+		if len(raw.Data) > 0 {
+			return op, invalidParam("data", "data is not allowed when deleting")
+		}
+		return op, nil
+
+	default:
+		return op, invalidParam("op", fmt.Sprintf("op must be %s, %s or %s, got %q",
+			repository.OpCreate, repository.OpUpdate, repository.OpDelete, raw.Op))
+	}
+}
+
+// decodeOperationData strictly decodes the data of an operation into dst and validates it
+func decodeOperationData(data json.RawMessage, dst interface{}) error {
+	if len(data) == 0 || string(data) == "null" {
+		return invalidParam("data", "data is required")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return translateDecodeError(err)
+	}
+
+	return validation.Validate(dst)
+}
+
+// operationProblem builds the problem details of an invalid operation
+func operationProblem(r *http.Request, err error) api.ErrorResponse {
+	var bodyErr *bodyError
+	if errors.As(err, &bodyErr) {
+		return api.Problem(r, bodyErr.status, bodyErr, bodyErr.code)
+	}
+	return api.ServiceProblem(r, err)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/igferreira/quotes-api/internal/api"
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/igferreira/quotes-api/internal/service"
+	"github.com/igferreira/quotes-api/internal/validation"
+	"github.com/rs/zerolog/log"
+)
+
+// DailyQuoteHandler handles quote of the day requests
+type DailyQuoteHandler struct {
+	service      *service.Service
+	repeatDays   int
+	maxBodyBytes int64
+}
+
+// NewDailyQuoteHandler creates a new quote of the day handler avoiding repeats within repeatDays
+func NewDailyQuoteHandler(service *service.Service, repeatDays int, maxBodyBytes int64) *DailyQuoteHandler {
+	return &DailyQuoteHandler{
+		service:      service,
+		repeatDays:   repeatDays,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+// Get handles GET /quotes/daily. The date defaults to the current date in the tz time zone (UTC by default).
+func (h *DailyQuoteHandler) Get(w http.ResponseWriter, r *http.Request) {
+	day, err := parseDay(r.URL.Query().Get("date"), r.URL.Query().Get("tz"))
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	expandAuthor, err := parseQuoteExpansion(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	tag := r.URL.Query().Get("tag")
+	daily, err := h.service.DailyQuote(r.Context(), day, tag, h.repeatDays)
+	if err != nil {
+		log.Error().Err(err).Time("day", day).Str("tag", tag).Msg("failed to get quote of the day")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	expandQuoteAuthors(expandAuthor, daily.Quote)
+	api.Respond(w, r, http.StatusOK, daily)
+}
+
+// Schedule handles PUT /quotes/daily/{date}
+func (h *DailyQuoteHandler) Schedule(w http.ResponseWriter, r *http.Request) {
+	day, err := parseDay(chi.URLParam(r, "date"), "")
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	var params repository.ScheduleDailyQuoteParams
+	if err := decodeJSON(w, r, &params, h.maxBodyBytes); err != nil {
+		respondDecodeError(w, r, err)
+		return
+	}
+
+	// Validate input
+	if err := validation.Validate(&params); err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	daily, err := h.service.ScheduleDailyQuote(r.Context(), day, params)
+	if err != nil {
+		log.Error().Err(err).Time("day", day).Int64("quote_id", params.QuoteID).Msg("failed to schedule quote of the day")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	expandQuoteAuthors(false, daily.Quote)
+	api.Respond(w, r, http.StatusOK, daily)
+}
+
+// Unschedule handles DELETE /quotes/daily/{date}
+func (h *DailyQuoteHandler) Unschedule(w http.ResponseWriter, r *http.Request) {
+	day, err := parseDay(chi.URLParam(r, "date"), "")
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	tag := r.URL.Query().Get("tag")
+	if err := h.service.UnscheduleDailyQuote(r.Context(), day, tag); err != nil {
+		log.Error().Err(err).Time("day", day).Str("tag", tag).Msg("failed to unschedule quote of the day")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseDay parses a YYYY-MM-DD date into midnight UTC. An empty date is the current
+// date in the tz time zone, UTC when tz is empty.
+func parseDay(date, tz string) (time.Time, error) {
+	if date != "" {
+		day, err := time.Parse(repository.DateLayout, date)
+		if err != nil {
+			return time.Time{}, invalidParam("date", "date must be a YYYY-MM-DD date")
+		}
+		return day, nil
+	}
+
+	loc := time.UTC
+	if tz != "" {
+		var err error
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			return time.Time{}, invalidParam("tz", fmt.Sprintf("tz %q is not a known time zone", tz))
+		}
+	}
+
+	year, month, dayOfMonth := time.Now().In(loc).Date()
+	return time.Date(year, month, dayOfMonth, 0, 0, 0, 0, time.UTC), nil
+}
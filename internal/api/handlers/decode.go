@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/api"
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// DefaultMaxBodyBytes is the request body limit used when none is configured
+const DefaultMaxBodyBytes = 1 << 20
+
+// Media types accepted for request bodies
+const (
+	JSONContentType       = "application/json"
+	MergePatchContentType = "application/merge-patch+json"
+)
+
+// bodyError describes why a request body was rejected
+type bodyError struct {
+	status int
+	code   string
+	msg    string
+	fields []repository.FieldError
+}
+
+// Error returns the client-facing message of the error
+func (e *bodyError) Error() string {
+	return e.msg
+}
+
+// FieldErrors returns the fields the error refers to, if any
+func (e *bodyError) FieldErrors() []repository.FieldError {
+	return e.fields
+}
+
+// decodeJSON strictly decodes a JSON request body into dst. The body must be
+// sent as application/json, fit within maxBytes, contain a single JSON value
+// and only use fields known to dst.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}, maxBytes int64) error {
+	return decodeBody(w, r, dst, maxBytes, JSONContentType)
+}
+
+// decodeMergePatch strictly decodes an RFC 7396 JSON merge patch document into dst.
+// Plain application/json bodies are accepted as well.
+func decodeMergePatch(w http.ResponseWriter, r *http.Request, dst interface{}, maxBytes int64) error {
+	return decodeBody(w, r, dst, maxBytes, MergePatchContentType, JSONContentType)
+}
+
+// decodeBody strictly decodes a JSON request body sent with one of the given media types
+func decodeBody(w http.ResponseWriter, r *http.Request, dst interface{}, maxBytes int64, mediaTypes ...string) error {
+	if err := checkContentType(r, mediaTypes); err != nil {
+		return err
+	}
+
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodyBytes
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return translateDecodeError(err)
+	}
+
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return translateDecodeError(err)
+		}
+		return invalidBody("request body must only contain a single JSON value")
+	}
+
+	return nil
+}
+
+// respondDecodeError sends the error response for an error returned by decodeJSON
+func respondDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	api.RespondProblem(w, r, decodeProblem(r, err))
+}
+
+// decodeProblem builds the problem details document describing an error decoding a request body
+func decodeProblem(r *http.Request, err error) api.ErrorResponse {
+	var bodyErr *bodyError
+	if errors.As(err, &bodyErr) {
+		return api.Problem(r, bodyErr.status, bodyErr, bodyErr.code)
+	}
+	return api.Problem(r, http.StatusBadRequest, err, api.CodeInvalidRequestBody)
+}
+
+// checkContentType rejects requests whose body is not declared with one of the given media types
+func checkContentType(r *http.Request, mediaTypes []string) error {
+	allowed := strings.Join(mediaTypes, " or ")
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return &bodyError{
+			status: http.StatusUnsupportedMediaType,
+			code:   api.CodeUnsupportedMediaType,
+			msg:    fmt.Sprintf("Content-Type header must be %s", allowed),
+		}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		for _, t := range mediaTypes {
+			if mediaType == t {
+				return nil
+			}
+		}
+	}
+
+	return &bodyError{
+		status: http.StatusUnsupportedMediaType,
+		code:   api.CodeUnsupportedMediaType,
+		msg:    fmt.Sprintf("Content-Type %q is not supported, use %s", contentType, allowed),
+	}
+}
+
+// translateDecodeError turns encoding/json errors into messages pointing at the offending field or offset
+func translateDecodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return invalidBody(fmt.Sprintf("request body contains badly-formed JSON at offset %d", syntaxErr.Offset))
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return invalidBody("request body contains badly-formed JSON")
+
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" && typeErr.Type.Kind() == reflect.Struct {
+			return invalidBody(fmt.Sprintf("request body must be a JSON object, got %s at offset %d", typeErr.Value, typeErr.Offset))
+		}
+		// errors raised by custom unmarshalers such as repository.Optional carry no field context
+		if typeErr.Field == "" {
+			return invalidBody(fmt.Sprintf("request body contains a JSON %s where %s was expected", typeErr.Value, typeErr.Type))
+		}
+		msg := fmt.Sprintf("%s must be of type %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		return invalidField(typeErr.Field, fmt.Sprintf("request body contains an invalid value for %s at offset %d", typeErr.Field, typeErr.Offset), msg)
+
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return invalidField(field, fmt.Sprintf("request body contains unknown field %q", field), fmt.Sprintf("%s is not a known field", field))
+
+	case errors.Is(err, io.EOF):
+		return invalidBody("request body must not be empty")
+
+	case errors.As(err, &maxBytesErr):
+		return &bodyError{
+			status: http.StatusRequestEntityTooLarge,
+			code:   api.CodeRequestBodyTooLarge,
+			msg:    fmt.Sprintf("request body must not be larger than %d bytes", maxBytesErr.Limit),
+		}
+
+	default:
+		return invalidBody(err.Error())
+	}
+}
+
+// invalidBody creates a 400 error for a malformed request body
+func invalidBody(msg string) *bodyError {
+	return &bodyError{
+		status: http.StatusBadRequest,
+		code:   api.CodeInvalidRequestBody,
+		msg:    msg,
+	}
+}
+
+// invalidField creates a 400 error for a malformed request body pointing at a single field
+func invalidField(field, msg, fieldMsg string) *bodyError {
+	err := invalidBody(msg)
+	err.fields = []repository.FieldError{{Field: field, Message: fieldMsg}}
+	return err
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/api"
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// etag formats the strong entity tag of a resource version in the representation
+// negotiated by r
+func etag(r *http.Request, version int64) string {
+	return api.EntityTag(r, strconv.FormatInt(version, 10))
+}
+
+// quoteETag formats the entity tag of a quote read with its author. The representation
+// embeds the author, so the tag covers both versions and changes when either is updated.
+func quoteETag(r *http.Request, quote *repository.QuoteWithAuthor) string {
+	state := strconv.FormatInt(quote.Version, 10)
+	if quote.Author != nil {
+		state += "-" + strconv.FormatInt(quote.Author.Version, 10)
+	}
+	return api.EntityTag(r, state)
+}
+
+// setETag sets the ETag header for a resource version in the representation negotiated by r
+func setETag(w http.ResponseWriter, r *http.Request, version int64) {
+	w.Header().Set("ETag", etag(r, version))
+}
+
+// versionLookup returns the stored version of the resource a write targets
+type versionLookup func(ctx context.Context) (int64, error)
+
+// parseIfMatch returns the version required by the If-Match header, or nil when
+// the header is absent or "*". Weak or unknown entity tags never match a stored
+// version, so they are mapped to version 0 which makes the write fail with 412.
+// The tag of a quote read with its author also holds the author version, which
+// writes to the quote do not depend on, and every tag may name its representation:
+// both are ignored, since writes depend on the stored version alone.
+//
+// A list of entity tags matches when any of them does: when it holds several
+// versions, current looks up the stored one and the write then requires it, so
+// that it still fails if the resource is modified in between.
+func parseIfMatch(r *http.Request, current versionLookup) (*int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	var versions []int64
+	for _, tag := range strings.Split(header, ",") {
+		if version := parseETagVersion(strings.TrimSpace(tag)); version > 0 && !slices.Contains(versions, version) {
+			versions = append(versions, version)
+		}
+	}
+
+	var version int64
+	switch len(versions) {
+	case 0:
+	case 1:
+		version = versions[0]
+	default:
+		stored, err := current(r.Context())
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(versions, stored) {
+			version = stored
+		}
+	}
+	return &version, nil
+}
+
+// parseETagVersion returns the version a strong entity tag was formatted from,
+// whatever its representation, or 0 for weak and unknown tags
+func parseETagVersion(tag string) int64 {
+	tag, ok := strings.CutPrefix(tag, `"`)
+	if !ok {
+		return 0
+	}
+	tag, ok = strings.CutSuffix(tag, `"`)
+	if !ok {
+		return 0
+	}
+	tag, _, _ = strings.Cut(tag, ";")
+	tag, _, _ = strings.Cut(tag, "-")
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 0 {
+		return 0
+	}
+	return version
+}
+
+// notModified answers 304 Not Modified when the If-None-Match header matches the
+// current entity tag of the resource, reporting whether the response was written
+func notModified(w http.ResponseWriter, r *http.Request, current string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		// If-None-Match uses the weak comparison function
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			w.Header().Set("ETag", current)
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// Number of quotes embedded in each author with expand=quotes: DefaultExpandedQuotes
+// unless quotes_limit asks for another number, up to MaxExpandedQuotes
+const (
+	DefaultExpandedQuotes = 5
+	MaxExpandedQuotes     = 20
+)
+
+// parseExpand parses the expand query parameter, which may be repeated or
+// comma-separated, accepting the expansions in supported
+func parseExpand(r *http.Request, supported ...string) (map[string]bool, error) {
+	expand := make(map[string]bool)
+	for _, value := range queryValues(r, "expand") {
+		if !slices.Contains(supported, value) {
+			return nil, invalidParam("expand",
+				fmt.Sprintf("expand must be %s, got %q", strings.Join(supported, " or "), value))
+		}
+		expand[value] = true
+	}
+	return expand, nil
+}
+
+// parseQuoteExpansion reports whether expand=author asks for the nested author of quotes
+func parseQuoteExpansion(r *http.Request) (bool, error) {
+	expand, err := parseExpand(r, repository.ExpandAuthor)
+	return expand[repository.ExpandAuthor], err
+}
+
+// parseAuthorExpansion parses the expansions of authors: expand=quotes with its
+// quotes_limit and expand=quote_count
+func parseAuthorExpansion(r *http.Request) (repository.AuthorExpansion, error) {
+	expand, err := parseExpand(r, repository.ExpandQuotes, repository.ExpandQuoteCount)
+	if err != nil {
+		return repository.AuthorExpansion{}, err
+	}
+
+	expansion := repository.AuthorExpansion{
+		Quotes:      expand[repository.ExpandQuotes],
+		QuotesLimit: DefaultExpandedQuotes,
+		QuoteCount:  expand[repository.ExpandQuoteCount],
+	}
+
+	if value := r.URL.Query().Get("quotes_limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxExpandedQuotes {
+			return expansion, invalidParam("quotes_limit",
+				fmt.Sprintf("quotes_limit must be an integer between 1 and %d", MaxExpandedQuotes))
+		}
+		expansion.QuotesLimit = int32(limit)
+	}
+
+	return expansion, nil
+}
+
+// expanded reports whether an author expansion embeds anything
+func expanded(expansion repository.AuthorExpansion) bool {
+	return expansion.Quotes || expansion.QuoteCount
+}
+
+// expandQuoteAuthors keeps the nested author the repository fills in quotes only when
+// it was asked for, the flat author_name and author_bio being sent otherwise
+func expandQuoteAuthors(expand bool, quotes ...*repository.QuoteWithAuthor) {
+	if expand {
+		return
+	}
+	for _, quote := range quotes {
+		if quote != nil {
+			quote.Author = nil
+		}
+	}
+}
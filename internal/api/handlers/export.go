@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/igferreira/quotes-api/internal/api"
+	"github.com/igferreira/quotes-api/internal/exporter"
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/igferreira/quotes-api/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// exportFlushRows is the number of rows written between two flushes of an export
+const exportFlushRows = 500
+
+// IncludeAuthors is the include query parameter value embedding authors in an export
+const IncludeAuthors = "authors"
+
+// ExportHandler handles quote exports
+type ExportHandler struct {
+	service      *service.Service
+	writeTimeout time.Duration
+}
+
+// NewExportHandler creates a new export handler. Exports may take longer than the server
+// write timeout, so each flush gives the response another writeTimeout to be written.
+func NewExportHandler(service *service.Service, writeTimeout time.Duration) *ExportHandler {
+	return &ExportHandler{
+		service:      service,
+		writeTimeout: writeTimeout,
+	}
+}
+
+// Export handles GET /export. The quotes matching the list filters are streamed in ID order
+// as JSON Lines (the default), CSV or a JSON array, with their authors when include=authors.
+// Errors are answered with problem details until the first row is sent; after that the
+// connection is aborted so that a truncated file cannot be mistaken for a complete one.
+func (h *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format, withAuthors, err := parseExportParams(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	filter, err := parseQuoteFilter(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	filter.AuthorIDs, err = parseAuthorIDs(r)
+	if err != nil {
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_AUTHOR_ID")
+		return
+	}
+
+	resp := &exportResponse{
+		w:           w,
+		rc:          http.NewResponseController(w),
+		contentType: exporter.ContentType(format),
+		filename:    exporter.Filename(format, time.Now()),
+		timeout:     h.writeTimeout,
+	}
+	ew, err := exporter.NewWriter(format, resp, withAuthors)
+	if err != nil {
+		api.RespondServiceError(w, r, invalidParam("format", err.Error()))
+		return
+	}
+
+	// the export outlives the request timeout; a client that goes away fails the next flush
+	ctx := context.WithoutCancel(r.Context())
+	rows := 0
+	err = h.service.ExportQuotes(ctx, filter, withAuthors, func(quote *repository.Quote) error {
+		if err := ew.Write(quote); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushRows == 0 {
+			return resp.flush(ew)
+		}
+		return nil
+	})
+	if err == nil {
+		err = ew.Close()
+	}
+	if err == nil && !resp.started {
+		// an empty JSON Lines export has no bytes, but still has headers
+		_, err = resp.Write(nil)
+	}
+
+	if err != nil {
+		log.Error().Err(err).Str("format", format).Int("rows", rows).Msg("failed to export quotes")
+		if !resp.started {
+			api.RespondServiceError(w, r, err)
+			return
+		}
+		panic(http.ErrAbortHandler)
+	}
+
+	log.Info().Str("format", format).Bool("authors", withAuthors).Int("rows", rows).Msg("exported quotes")
+}
+
+// parseExportParams parses the format and include query parameters
+func parseExportParams(r *http.Request) (string, bool, error) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exporter.FormatNDJSON
+	}
+	valid := false
+	for _, f := range exporter.Formats {
+		valid = valid || f == format
+	}
+	if !valid {
+		return "", false, invalidParam("format",
+			fmt.Sprintf("format must be one of %s, got %q", strings.Join(exporter.Formats, ", "), format))
+	}
+
+	withAuthors := false
+	for _, include := range queryValues(r, "include") {
+		if include != IncludeAuthors {
+			return "", false, invalidParam("include",
+				fmt.Sprintf("include must be %s, got %q", IncludeAuthors, include))
+		}
+		withAuthors = true
+	}
+
+	return format, withAuthors, nil
+}
+
+// exportResponse writes an export to the response. The headers are sent with the first
+// bytes, so that errors happening before can still be answered with problem details.
+type exportResponse struct {
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	contentType string
+	filename    string
+	timeout     time.Duration
+	started     bool
+}
+
+// Write writes a part of the export, after the headers for the first one
+func (resp *exportResponse) Write(p []byte) (int, error) {
+	if !resp.started {
+		resp.started = true
+		resp.w.Header().Set("Content-Type", resp.contentType)
+		resp.w.Header().Set("Content-Disposition",
+			mime.FormatMediaType("attachment", map[string]string{"filename": resp.filename}))
+		resp.w.WriteHeader(http.StatusOK)
+		resp.extendDeadline()
+	}
+	return resp.w.Write(p)
+}
+
+// flush sends the rows buffered by ew to the client and extends the write deadline
+func (resp *exportResponse) flush(ew exporter.Writer) error {
+	if err := ew.Flush(); err != nil {
+		return err
+	}
+	if err := resp.rc.Flush(); err != nil {
+		return err
+	}
+	resp.extendDeadline()
+	return nil
+}
+
+// extendDeadline gives the response another write timeout to be written
+func (resp *exportResponse) extendDeadline() {
+	if resp.timeout <= 0 {
+		return
+	}
+	if err := resp.rc.SetWriteDeadline(time.Now().Add(resp.timeout)); err != nil {
+		log.Debug().Err(err).Msg("failed to extend export write deadline")
+	}
+}
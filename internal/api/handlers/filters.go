@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// Values of the tags_mode query parameter
+const (
+	TagsModeAll = "all"
+	TagsModeAny = "any"
+)
+
+// dateLayout is accepted besides RFC 3339 timestamps and stands for midnight UTC
+const dateLayout = "2006-01-02"
+
+// parseQuoteFilter parses the quote filter query parameters:
+//
+//	tag=a&tag=b     quotes tagged with a and b (tags_mode=all, the default) or either (tags_mode=any)
+//	exclude_tag=c   quotes not tagged with c
+//	source=s        quotes whose source contains s
+//	max_length=n    quotes of at most n characters
+//	created_after, created_before, updated_since   RFC 3339 timestamps or dates
+//	q               full-text search query
+//
+// Invalid values are reported together in a validation error. Author IDs are parsed by parseAuthorIDs.
+func parseQuoteFilter(r *http.Request) (repository.QuoteFilter, error) {
+	query := r.URL.Query()
+	var violations []repository.FieldError
+
+	filter := repository.QuoteFilter{
+		Tags:         queryValues(r, "tag"),
+		MatchAllTags: true,
+		ExcludeTags:  queryValues(r, "exclude_tag"),
+		Source:       strings.TrimSpace(query.Get("source")),
+		Query:        strings.TrimSpace(query.Get("q")),
+	}
+
+	switch mode := query.Get("tags_mode"); mode {
+	case "", TagsModeAll:
+	case TagsModeAny:
+		filter.MatchAllTags = false
+	default:
+		violations = append(violations, repository.FieldError{
+			Field:   "tags_mode",
+			Message: fmt.Sprintf("tags_mode must be %s or %s, got %q", TagsModeAll, TagsModeAny, mode),
+		})
+	}
+
+	if value := query.Get("max_length"); value != "" {
+		maxLength, err := strconv.Atoi(value)
+		if err != nil || maxLength < 1 {
+			violations = append(violations, repository.FieldError{
+				Field:   "max_length",
+				Message: "max_length must be a positive integer",
+			})
+		}
+		filter.MaxLength = maxLength
+	}
+
+	dates := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_since", &filter.UpdatedSince},
+	}
+	for _, d := range dates {
+		value := query.Get(d.name)
+		if value == "" {
+			continue
+		}
+		t, err := parseTime(value)
+		if err != nil {
+			violations = append(violations, repository.FieldError{
+				Field:   d.name,
+				Message: fmt.Sprintf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", d.name),
+			})
+			continue
+		}
+		*d.dst = &t
+	}
+
+	if len(violations) > 0 {
+		return filter, &repository.Error{
+			Kind:    repository.ErrValidation,
+			Message: violations[0].Message,
+			Fields:  violations,
+		}
+	}
+
+	return filter, nil
+}
+
+// parseAuthorFilter parses the author filter query parameters:
+//
+//	era_from, era_to   years bounding the lifetime of the authors, such as 1800 or 500 BC
+//	nationality=n      authors of nationality n, ignoring case and accents
+//	occupation=a       authors who were a, or any of several occupations
+//
+// Invalid values are reported together in a validation error.
+func parseAuthorFilter(r *http.Request) (repository.AuthorFilter, error) {
+	query := r.URL.Query()
+	var violations []repository.FieldError
+
+	filter := repository.AuthorFilter{
+		Nationality: strings.TrimSpace(query.Get("nationality")),
+		Occupations: queryValues(r, "occupation"),
+	}
+
+	eras := []struct {
+		name string
+		dst  **int32
+	}{
+		{"era_from", &filter.EraFrom},
+		{"era_to", &filter.EraTo},
+	}
+	for _, e := range eras {
+		value := query.Get(e.name)
+		if value == "" {
+			continue
+		}
+		d, err := repository.ParsePartialDate(value)
+		if err != nil || d.Month != 0 || d.Circa {
+			violations = append(violations, repository.FieldError{
+				Field:   e.name,
+				Message: fmt.Sprintf("%s must be a year such as 1800 or 500 BC", e.name),
+			})
+			continue
+		}
+		*e.dst = &d.Year
+	}
+
+	if len(violations) > 0 {
+		return filter, &repository.Error{
+			Kind:    repository.ErrValidation,
+			Message: violations[0].Message,
+			Fields:  violations,
+		}
+	}
+
+	return filter, nil
+}
+
+// parseAuthorIDs parses the author_id query parameter, which may be repeated or comma-separated
+func parseAuthorIDs(r *http.Request) ([]int64, error) {
+	var ids []int64
+	for _, value := range queryValues(r, "author_id") {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("author_id %q is not a valid ID", value)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// queryValues returns the non-empty values of a repeatable query parameter,
+// splitting comma-separated values
+func queryValues(r *http.Request, name string) []string {
+	var values []string
+	for _, value := range r.URL.Query()[name] {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// parseTime parses an RFC 3339 timestamp or a date
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(dateLayout, value)
+}
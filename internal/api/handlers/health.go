@@ -30,7 +30,7 @@ type HealthResponse struct {
 
 // Liveness handles GET /healthz
 func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
-	api.RespondJSON(w, http.StatusOK, HealthResponse{
+	api.Respond(w, r, http.StatusOK, HealthResponse{
 		Status:    "ok",
 		Timestamp: time.Now(),
 		Services:  map[string]string{},
@@ -55,7 +55,7 @@ func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
 		services["database"] = "healthy"
 	}
 
-	api.RespondJSON(w, status, HealthResponse{
+	api.Respond(w, r, status, HealthResponse{
 		Status:    overallStatus,
 		Timestamp: time.Now(),
 		Services:  services,
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/igferreira/quotes-api/internal/api"
+	"github.com/igferreira/quotes-api/internal/importer"
+	"github.com/igferreira/quotes-api/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// MultipartContentType is the media type of file uploads
+const MultipartContentType = "multipart/form-data"
+
+// ImportFileField is the multipart field holding the file to import
+const ImportFileField = "file"
+
+// ImportHandler handles quote imports
+type ImportHandler struct {
+	service  *service.Service
+	maxBytes int64
+}
+
+// NewImportHandler creates a new import handler accepting files of up to maxBytes
+func NewImportHandler(service *service.Service, maxBytes int64) *ImportHandler {
+	return &ImportHandler{
+		service:  service,
+		maxBytes: maxBytes,
+	}
+}
+
+// Import handles POST /import with a multipart/form-data upload. The file is read as
+// it is uploaded, in the format given by the format query parameter or its extension.
+// dry_run=true checks the file without writing and create_authors=false rejects the
+// quotes of unknown authors instead of creating them.
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	opts, format, err := parseImportParams(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	if err := checkContentType(r, []string{MultipartContentType}); err != nil {
+		respondDecodeError(w, r, err)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBytes)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		api.RespondError(w, r, http.StatusBadRequest, err, api.CodeInvalidRequestBody)
+		return
+	}
+
+	// the file is consumed as the parts are read, so it must come before any other field
+	var part io.Reader
+	var filename string
+	for part == nil {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			api.RespondError(w, r, http.StatusBadRequest,
+				fmt.Errorf("request body must contain a %q file field", ImportFileField), api.CodeInvalidRequestBody)
+			return
+		}
+		if err != nil {
+			respondImportError(w, r, err)
+			return
+		}
+		if p.FormName() == ImportFileField {
+			part, filename = p, p.FileName()
+		}
+	}
+
+	if format == "" {
+		format = importer.DetectFormat(filename)
+	}
+	if format == "" {
+		api.RespondServiceError(w, r, invalidParam("format",
+			fmt.Sprintf("format must be %s or %s when it cannot be told from the file name", importer.FormatCSV, importer.FormatNDJSON)))
+		return
+	}
+
+	reader, err := importer.NewReader(format, part)
+	if err != nil {
+		respondImportError(w, r, err)
+		return
+	}
+
+	report, err := h.service.ImportQuotes(r.Context(), reader, opts)
+	if err != nil {
+		log.Error().Err(err).Str("file", filename).Int("rows", report.Rows).Msg("failed to import quotes")
+		// the quotes imported before the error stay imported, the report tells which
+		problem := importProblem(r, err)
+		problem.Report = report
+		api.RespondProblem(w, r, problem)
+		return
+	}
+
+	log.Info().Str("file", filename).Bool("dry_run", report.DryRun).Int("rows", report.Rows).
+		Int("imported", report.Imported).Int("failed", report.Failed).Msg("imported quotes")
+	api.Respond(w, r, http.StatusOK, report)
+}
+
+// parseImportParams parses the format, dry_run and create_authors query parameters
+func parseImportParams(r *http.Request) (importer.Options, string, error) {
+	query := r.URL.Query()
+	opts := importer.Options{CreateAuthors: true}
+
+	format := query.Get("format")
+	if format != "" && format != importer.FormatCSV && format != importer.FormatNDJSON {
+		return opts, "", invalidParam("format",
+			fmt.Sprintf("format must be %s or %s, got %q", importer.FormatCSV, importer.FormatNDJSON, format))
+	}
+
+	flags := []struct {
+		name string
+		dst  *bool
+	}{
+		{"dry_run", &opts.DryRun},
+		{"create_authors", &opts.CreateAuthors},
+	}
+	for _, f := range flags {
+		value := query.Get(f.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, "", invalidParam(f.name, fmt.Sprintf("%s must be true or false", f.name))
+		}
+		*f.dst = parsed
+	}
+
+	return opts, format, nil
+}
+
+// respondImportError sends the error response for an import that could not be completed
+func respondImportError(w http.ResponseWriter, r *http.Request, err error) {
+	api.RespondProblem(w, r, importProblem(r, err))
+}
+
+// importProblem builds the problem details document describing an error reading or importing an upload
+func importProblem(r *http.Request, err error) api.ErrorResponse {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return decodeProblem(r, translateDecodeError(err))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return api.Problem(r, http.StatusBadRequest, errors.New("request body is not a valid multipart upload"), api.CodeInvalidRequestBody)
+	default:
+		return api.ServiceProblem(r, err)
+	}
+}
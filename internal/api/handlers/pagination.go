@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/igferreira/quotes-api/internal/api"
+	"github.com/igferreira/quotes-api/internal/pagination"
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// listSort describes how a list can be sorted
+type listSort struct {
+	fields   []string
+	defaults repository.Sort
+}
+
+// listSorts holds the sort fields and the default sort of each list
+var listSorts = map[string]listSort{
+	pagination.KindAuthors:      {repository.AuthorSortFields, repository.DefaultAuthorSort},
+	pagination.KindAuthorSearch: {repository.AuthorSearchSortFields, repository.DefaultAuthorSearchSort},
+	pagination.KindQuotes:       {repository.QuoteSortFields, repository.DefaultQuoteSort},
+	pagination.KindQuoteSearch:  {repository.QuoteSearchSortFields, repository.DefaultQuoteSearchSort},
+}
+
+// parseListParams parses pagination parameters from request, including the sort
+// and cursor of the given list and the include_total opt-out.
+// A cursor cannot be combined with an offset, nor replayed with another scope.
+func parseListParams(r *http.Request, cursors *pagination.Codec, kind string) (repository.ListParams, error) {
+	params := parsePaginationParams(r)
+
+	if includeTotal, err := strconv.ParseBool(r.URL.Query().Get("include_total")); err == nil {
+		params.SkipTotal = !includeTotal
+	}
+
+	sort, err := parseSort(r.URL.Query().Get("sort"), listSorts[kind])
+	if err != nil {
+		return params, err
+	}
+	params.Sort = sort
+
+	token := r.URL.Query().Get("cursor")
+	if token == "" {
+		return params, nil
+	}
+	if r.URL.Query().Has("offset") {
+		return params, ErrValidation("cursor and offset cannot be combined")
+	}
+
+	cursor, err := cursors.Decode(kind, params.Sort, listScope(r), token)
+	if err != nil {
+		return params, err
+	}
+	params.Cursor = cursor
+	params.Offset = 0
+
+	return params, nil
+}
+
+// presentationParams are the query parameters of lists that shape the response without
+// changing the items listed, or that the cursor binds on its own
+var presentationParams = []string{
+	"cursor", "offset", "limit", "include_total", "sort", "expand", "quotes_limit",
+	api.FieldsParam, api.FormatParam, api.PrettyParam,
+}
+
+// listScope identifies the items a list request walks through: its path and the query
+// parameters filtering it, the search query included. Cursors are bound to it so that a
+// cursor issued for one query or filter is rejected with another one.
+func listScope(r *http.Request) string {
+	query := r.URL.Query()
+	for _, name := range presentationParams {
+		query.Del(name)
+	}
+	for _, values := range query {
+		slices.Sort(values)
+	}
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// parseSort parses a comma-separated list of sort fields, each prefixed with - for descending
+// order, and appends the ID tie-breaker. An empty value selects the default sort of the list.
+func parseSort(value string, list listSort) (repository.Sort, error) {
+	if value == "" {
+		return list.defaults.WithTieBreaker(), nil
+	}
+
+	var sort repository.Sort
+	for _, field := range strings.Split(value, ",") {
+		f := repository.SortField{Field: strings.TrimSpace(field)}
+		if name, ok := strings.CutPrefix(f.Field, "-"); ok {
+			f.Field, f.Desc = name, true
+		}
+
+		var message string
+		switch {
+		case !slices.Contains(list.fields, f.Field):
+			message = fmt.Sprintf("unknown sort field %q, expected one of %s", f.Field, strings.Join(list.fields, ", "))
+		case slices.ContainsFunc(sort, func(s repository.SortField) bool { return s.Field == f.Field }):
+			message = fmt.Sprintf("sort field %q is repeated", f.Field)
+		}
+		if message != "" {
+			return nil, &repository.Error{
+				Kind:    repository.ErrValidation,
+				Message: message,
+				Fields:  []repository.FieldError{{Field: "sort", Message: message}},
+			}
+		}
+
+		sort = append(sort, f)
+	}
+
+	return sort.WithTieBreaker(), nil
+}
+
+// respondListParamsError sends the error of parseListParams: a validation error for an
+// invalid sort, an invalid cursor error otherwise
+func respondListParamsError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, repository.ErrValidation) {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+	api.RespondError(w, r, http.StatusBadRequest, err, api.CodeInvalidCursor)
+}
+
+// withLookahead returns params fetching one extra item, revealing whether another page exists
+func withLookahead(params repository.ListParams) repository.ListParams {
+	params.Limit++
+	return params
+}
+
+// paginate drops the lookahead item from a page fetched with withLookahead and builds
+// its pagination metadata, deriving the cursors from the sort values of the first and
+// last items and the scope of r.
+func paginate[T any](r *http.Request, items []T, total int64, params repository.ListParams, cursors *pagination.Codec, kind string, sortValue func(T, string) string) ([]T, api.PaginationMeta) {
+	meta := api.PaginationMeta{
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	}
+	if !params.SkipTotal {
+		meta.Total = &total
+	}
+
+	backward := params.Cursor != nil && params.Cursor.Backward
+	hasMore := len(items) > int(params.Limit)
+	if hasMore {
+		// a backward page is fetched from the cursor outward, so the extra item comes first
+		if backward {
+			items = items[1:]
+		} else {
+			items = items[:params.Limit]
+		}
+	}
+
+	if len(items) == 0 {
+		return items, meta
+	}
+
+	hasNext := hasMore
+	hasPrev := params.Offset > 0 || params.Cursor != nil
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	position := func(item T) repository.Cursor {
+		values := make([]string, len(params.Sort))
+		for i, f := range params.Sort {
+			values[i] = sortValue(item, f.Field)
+		}
+		return repository.Cursor{Values: values}
+	}
+
+	scope := listScope(r)
+	if hasNext {
+		meta.NextCursor = cursors.Encode(kind, params.Sort, scope, position(items[len(items)-1]))
+	}
+	if hasPrev {
+		prev := position(items[0])
+		prev.Backward = true
+		meta.PrevCursor = cursors.Encode(kind, params.Sort, scope, prev)
+	}
+
+	return items, meta
+}
+
+// authorSortValue returns the value of a sort field of an author
+func authorSortValue(a *repository.Author, field string) string {
+	switch field {
+	case repository.SortName:
+		return a.Name
+	case repository.SortCreatedAt:
+		return formatSortTime(a.CreatedAt)
+	case repository.SortUpdatedAt:
+		return formatSortTime(a.UpdatedAt)
+	default:
+		return strconv.FormatInt(a.ID, 10)
+	}
+}
+
+// authorSearchSortValue returns the value of a sort field of an author search result
+func authorSearchSortValue(a *repository.AuthorSearchResult, field string) string {
+	if field == repository.SortScore {
+		return formatSortFloat(a.Score)
+	}
+	return authorSortValue(&a.Author, field)
+}
+
+// quoteSortValue returns the value of a sort field of a quote
+func quoteSortValue(q *repository.QuoteWithAuthor, field string) string {
+	switch field {
+	case repository.SortCreatedAt:
+		return formatSortTime(q.CreatedAt)
+	case repository.SortUpdatedAt:
+		return formatSortTime(q.UpdatedAt)
+	case repository.SortAuthorName:
+		return q.AuthorName
+	case repository.SortLength:
+		return strconv.Itoa(utf8.RuneCountInString(q.Content))
+	default:
+		return strconv.FormatInt(q.ID, 10)
+	}
+}
+
+// quoteSearchSortValue returns the value of a sort field of a quote search result
+func quoteSearchSortValue(q *repository.QuoteSearchResult, field string) string {
+	if field == repository.SortRank {
+		return formatSortFloat(q.Rank)
+	}
+	return quoteSortValue(&q.QuoteWithAuthor, field)
+}
+
+// formatSortTime formats a time sort value without losing precision
+func formatSortTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// formatSortFloat formats a score sort value in the shortest form that parses back to it
+func formatSortFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
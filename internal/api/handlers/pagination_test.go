@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/igferreira/quotes-api/internal/pagination"
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		kind  string
+		want  repository.Sort
+	}{
+		{
+			name: "default quote sort",
+			kind: pagination.KindQuotes,
+			want: repository.Sort{{Field: "created_at", Desc: true}, {Field: "id", Desc: true}},
+		},
+		{
+			name: "default author sort",
+			kind: pagination.KindAuthors,
+			want: repository.Sort{{Field: "name"}, {Field: "id"}},
+		},
+		{
+			name:  "descending",
+			value: "-length",
+			kind:  pagination.KindQuotes,
+			want:  repository.Sort{{Field: "length", Desc: true}, {Field: "id", Desc: true}},
+		},
+		{
+			name:  "mixed directions",
+			value: "author_name,-created_at",
+			kind:  pagination.KindQuotes,
+			want:  repository.Sort{{Field: "author_name"}, {Field: "created_at", Desc: true}, {Field: "id", Desc: true}},
+		},
+		{
+			name:  "spaces around fields",
+			value: " name , -updated_at ",
+			kind:  pagination.KindAuthors,
+			want:  repository.Sort{{Field: "name"}, {Field: "updated_at", Desc: true}, {Field: "id", Desc: true}},
+		},
+		{
+			name:  "explicit tie-breaker",
+			value: "-created_at,id",
+			kind:  pagination.KindQuotes,
+			want:  repository.Sort{{Field: "created_at", Desc: true}, {Field: "id"}},
+		},
+		{
+			name:  "fields after the ID are dropped",
+			value: "-id,length",
+			kind:  pagination.KindQuotes,
+			want:  repository.Sort{{Field: "id", Desc: true}},
+		},
+		{
+			name:  "search only field",
+			value: "-rank",
+			kind:  pagination.KindQuoteSearch,
+			want:  repository.Sort{{Field: "rank", Desc: true}, {Field: "id", Desc: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSort(tt.value, listSorts[tt.kind])
+			if err != nil {
+				t.Fatalf("parseSort() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSort() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSortErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		kind  string
+	}{
+		{"unknown field", "popularity", pagination.KindQuotes},
+		{"field of another list", "name", pagination.KindQuotes},
+		{"search field outside search", "-rank", pagination.KindQuotes},
+		{"repeated field", "length,-length", pagination.KindQuotes},
+		{"empty field", "name,", pagination.KindAuthors},
+		{"bare minus", "-", pagination.KindAuthors},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSort(tt.value, listSorts[tt.kind])
+			if !errors.Is(err, repository.ErrValidation) {
+				t.Fatalf("parseSort() = %v, %v, want a validation error", got, err)
+			}
+
+			var domainErr *repository.Error
+			if !errors.As(err, &domainErr) || len(domainErr.Fields) != 1 || domainErr.Fields[0].Field != "sort" {
+				t.Errorf("parseSort() error = %#v, want an error on the sort field", err)
+			}
+		})
+	}
+}
+
+func TestParseSortMatchesCursor(t *testing.T) {
+	// a cursor is bound to the sort it was issued for, whatever way the sort was spelled
+	codec := pagination.NewCodec([]byte("test secret"))
+	issued, err := parseSort("author_name", listSorts[pagination.KindQuotes])
+	if err != nil {
+		t.Fatalf("parseSort() error = %v", err)
+	}
+	token := codec.Encode(pagination.KindQuotes, issued, "", repository.Cursor{Values: []string{"Mark Twain", "3"}})
+
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"author_name", true},
+		{"author_name,id", true},
+		{" author_name ", true},
+		{"-author_name", false},
+		{"author_name,-id", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			sort, err := parseSort(tt.value, listSorts[pagination.KindQuotes])
+			if err != nil {
+				t.Fatalf("parseSort() error = %v", err)
+			}
+			_, err = codec.Decode(pagination.KindQuotes, sort, "", token)
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("Decode() with sort %q error = %v, want valid %v", tt.value, err, tt.valid)
+			}
+		})
+	}
+}
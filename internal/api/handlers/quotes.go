@@ -1,55 +1,59 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/igferreira/quotes-api/internal/api"
+	"github.com/igferreira/quotes-api/internal/pagination"
 	"github.com/igferreira/quotes-api/internal/repository"
 	"github.com/igferreira/quotes-api/internal/service"
+	"github.com/igferreira/quotes-api/internal/validation"
 	"github.com/rs/zerolog/log"
 )
 
 // QuoteHandler handles quote-related requests
 type QuoteHandler struct {
-	service *service.Service
+	service      *service.Service
+	cursors      *pagination.Codec
+	maxBodyBytes int64
 }
 
 // NewQuoteHandler creates a new quote handler
-func NewQuoteHandler(service *service.Service) *QuoteHandler {
+func NewQuoteHandler(service *service.Service, cursors *pagination.Codec, maxBodyBytes int64) *QuoteHandler {
 	return &QuoteHandler{
-		service: service,
+		service:      service,
+		cursors:      cursors,
+		maxBodyBytes: maxBodyBytes,
 	}
 }
 
 // Create handles POST /quotes
 func (h *QuoteHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var params repository.CreateQuoteParams
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		api.RespondError(w, http.StatusBadRequest, err, "INVALID_REQUEST_BODY")
+	if err := decodeJSON(w, r, &params, h.maxBodyBytes); err != nil {
+		respondDecodeError(w, r, err)
 		return
 	}
 
 	// Validate input
-	if params.Content == "" {
-		api.RespondError(w, http.StatusBadRequest, ErrValidation("content is required"), "VALIDATION_ERROR")
-		return
-	}
-	if params.AuthorID <= 0 {
-		api.RespondError(w, http.StatusBadRequest, ErrValidation("valid author_id is required"), "VALIDATION_ERROR")
+	if err := validation.Validate(&params); err != nil {
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
 	quote, err := h.service.CreateQuote(r.Context(), params)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to create quote")
-		api.RespondError(w, http.StatusInternalServerError, err, "CREATE_QUOTE_ERROR")
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
-	api.RespondJSON(w, http.StatusCreated, quote)
+	setETag(w, r, quote.Version)
+	api.Respond(w, r, http.StatusCreated, quote)
 }
 
 // GetByID handles GET /quotes/{id}
@@ -57,52 +61,151 @@ func (h *QuoteHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		api.RespondError(w, http.StatusBadRequest, err, "INVALID_ID")
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_ID")
+		return
+	}
+
+	expandAuthor, err := parseQuoteExpansion(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
 	quote, err := h.service.GetQuote(r.Context(), id)
 	if err != nil {
 		log.Error().Err(err).Int64("id", id).Msg("failed to get quote")
-		api.RespondError(w, http.StatusNotFound, err, "QUOTE_NOT_FOUND")
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
-	api.RespondJSON(w, http.StatusOK, quote)
+	// the entity tag covers the author flattened into author_name and author_bio, but an
+	// expanded author is another representation, which is always sent and left untagged
+	if !expandAuthor {
+		tag := quoteETag(r, quote)
+		if notModified(w, r, tag) {
+			return
+		}
+		w.Header().Set("ETag", tag)
+	}
+	expandQuoteAuthors(expandAuthor, quote)
+	api.Respond(w, r, http.StatusOK, quote)
 }
 
 // List handles GET /quotes
 func (h *QuoteHandler) List(w http.ResponseWriter, r *http.Request) {
-	params := parsePaginationParams(r)
+	params, err := parseListParams(r, h.cursors, pagination.KindQuotes)
+	if err != nil {
+		respondListParamsError(w, r, err)
+		return
+	}
 
-	// Check if filtering by author
-	authorIDStr := r.URL.Query().Get("author_id")
-	if authorIDStr != "" {
-		authorID, err := strconv.ParseInt(authorIDStr, 10, 64)
-		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, err, "INVALID_AUTHOR_ID")
-			return
-		}
+	filter, err := parseQuoteFilter(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
 
-		quotes, err := h.service.ListQuotesByAuthor(r.Context(), authorID, params)
-		if err != nil {
-			log.Error().Err(err).Int64("author_id", authorID).Msg("failed to list quotes by author")
-			api.RespondError(w, http.StatusInternalServerError, err, "LIST_QUOTES_ERROR")
-			return
-		}
+	filter.AuthorIDs, err = parseAuthorIDs(r)
+	if err != nil {
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_AUTHOR_ID")
+		return
+	}
 
-		api.RespondJSON(w, http.StatusOK, quotes)
+	expandAuthor, err := parseQuoteExpansion(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
-	quotes, total, err := h.service.ListQuotes(r.Context(), params)
+	quotes, total, err := h.service.ListQuotes(r.Context(), filter, withLookahead(params))
 	if err != nil {
 		log.Error().Err(err).Msg("failed to list quotes")
-		api.RespondError(w, http.StatusInternalServerError, err, "LIST_QUOTES_ERROR")
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
-	api.RespondPaginated(w, quotes, total, params.Limit, params.Offset)
+	quotes, meta := paginate(r, quotes, total, params, h.cursors, pagination.KindQuotes, quoteSortValue)
+	expandQuoteAuthors(expandAuthor, quotes...)
+	api.RespondPaginated(w, r, quotes, meta)
+}
+
+// ListByAuthor handles GET /authors/{id}/quotes
+func (h *QuoteHandler) ListByAuthor(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	authorID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_ID")
+		return
+	}
+
+	params, err := parseListParams(r, h.cursors, pagination.KindQuotes)
+	if err != nil {
+		respondListParamsError(w, r, err)
+		return
+	}
+
+	filter, err := parseQuoteFilter(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	expandAuthor, err := parseQuoteExpansion(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	quotes, total, err := h.service.ListQuotesByAuthor(r.Context(), authorID, filter, withLookahead(params))
+	if err != nil {
+		log.Error().Err(err).Int64("author_id", authorID).Msg("failed to list quotes by author")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	quotes, meta := paginate(r, quotes, total, params, h.cursors, pagination.KindQuotes, quoteSortValue)
+	expandQuoteAuthors(expandAuthor, quotes...)
+	api.RespondPaginated(w, r, quotes, meta)
+}
+
+// ListByTag handles GET /tags/{slug}/quotes
+func (h *QuoteHandler) ListByTag(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	params, err := parseListParams(r, h.cursors, pagination.KindQuotes)
+	if err != nil {
+		respondListParamsError(w, r, err)
+		return
+	}
+
+	filter, err := parseQuoteFilter(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	filter.AuthorIDs, err = parseAuthorIDs(r)
+	if err != nil {
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_AUTHOR_ID")
+		return
+	}
+
+	expandAuthor, err := parseQuoteExpansion(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	quotes, total, err := h.service.ListQuotesByTag(r.Context(), slug, filter, withLookahead(params))
+	if err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("failed to list quotes by tag")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	quotes, meta := paginate(r, quotes, total, params, h.cursors, pagination.KindQuotes, quoteSortValue)
+	expandQuoteAuthors(expandAuthor, quotes...)
+	api.RespondPaginated(w, r, quotes, meta)
 }
 
 // Update handles PUT /quotes/{id}
@@ -110,34 +213,75 @@ func (h *QuoteHandler) Update(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		api.RespondError(w, http.StatusBadRequest, err, "INVALID_ID")
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_ID")
 		return
 	}
 
 	var params repository.UpdateQuoteParams
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		api.RespondError(w, http.StatusBadRequest, err, "INVALID_REQUEST_BODY")
+	if err := decodeJSON(w, r, &params, h.maxBodyBytes); err != nil {
+		respondDecodeError(w, r, err)
 		return
 	}
 
 	// Validate input
-	if params.Content == "" {
-		api.RespondError(w, http.StatusBadRequest, ErrValidation("content is required"), "VALIDATION_ERROR")
+	if err := validation.Validate(&params); err != nil {
+		api.RespondServiceError(w, r, err)
 		return
 	}
-	if params.AuthorID <= 0 {
-		api.RespondError(w, http.StatusBadRequest, ErrValidation("valid author_id is required"), "VALIDATION_ERROR")
+
+	params.ExpectedVersion, err = parseIfMatch(r, h.version(id))
+	if err != nil {
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
 	quote, err := h.service.UpdateQuote(r.Context(), id, params)
 	if err != nil {
 		log.Error().Err(err).Int64("id", id).Msg("failed to update quote")
-		api.RespondError(w, http.StatusInternalServerError, err, "UPDATE_QUOTE_ERROR")
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
-	api.RespondJSON(w, http.StatusOK, quote)
+	setETag(w, r, quote.Version)
+	api.Respond(w, r, http.StatusOK, quote)
+}
+
+// Patch handles PATCH /quotes/{id} with a JSON merge patch document
+func (h *QuoteHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_ID")
+		return
+	}
+
+	var params repository.PatchQuoteParams
+	if err := decodeMergePatch(w, r, &params, h.maxBodyBytes); err != nil {
+		respondDecodeError(w, r, err)
+		return
+	}
+
+	// Validate input
+	if err := validation.Validate(&params); err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	params.ExpectedVersion, err = parseIfMatch(r, h.version(id))
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	quote, err := h.service.PatchQuote(r.Context(), id, params)
+	if err != nil {
+		log.Error().Err(err).Int64("id", id).Msg("failed to patch quote")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, r, quote.Version)
+	api.Respond(w, r, http.StatusOK, quote)
 }
 
 // Delete handles DELETE /quotes/{id}
@@ -145,48 +289,162 @@ func (h *QuoteHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		api.RespondError(w, http.StatusBadRequest, err, "INVALID_ID")
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_ID")
 		return
 	}
 
-	err = h.service.DeleteQuote(r.Context(), id)
+	expectedVersion, err := parseIfMatch(r, h.version(id))
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	err = h.service.DeleteQuote(r.Context(), id, expectedVersion)
 	if err != nil {
 		log.Error().Err(err).Int64("id", id).Msg("failed to delete quote")
-		api.RespondError(w, http.StatusInternalServerError, err, "DELETE_QUOTE_ERROR")
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// Batch handles POST /quotes:batch
+func (h *QuoteHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	runBatch(w, r, repository.ResourceQuote, h.maxBodyBytes, h.service.BatchQuotes)
+}
+
 // Search handles GET /quotes/search
 func (h *QuoteHandler) Search(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		api.RespondError(w, http.StatusBadRequest, ErrValidation("search query is required"), "VALIDATION_ERROR")
+	if strings.TrimSpace(r.URL.Query().Get("q")) == "" {
+		api.RespondError(w, r, http.StatusBadRequest, ErrValidation("search query is required"), "VALIDATION_ERROR")
+		return
+	}
+
+	params, err := parseListParams(r, h.cursors, pagination.KindQuoteSearch)
+	if err != nil {
+		respondListParamsError(w, r, err)
 		return
 	}
 
-	params := parsePaginationParams(r)
+	filter, err := parseQuoteFilter(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
 
-	quotes, total, err := h.service.SearchQuotes(r.Context(), query, params)
+	filter.AuthorIDs, err = parseAuthorIDs(r)
 	if err != nil {
-		log.Error().Err(err).Str("query", query).Msg("failed to search quotes")
-		api.RespondError(w, http.StatusInternalServerError, err, "SEARCH_QUOTES_ERROR")
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_AUTHOR_ID")
 		return
 	}
 
-	api.RespondPaginated(w, quotes, total, params.Limit, params.Offset)
+	expandAuthor, err := parseQuoteExpansion(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	quotes, total, err := h.service.SearchQuotes(r.Context(), filter, withLookahead(params))
+	if err != nil {
+		log.Error().Err(err).Str("query", filter.Query).Msg("failed to search quotes")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	quotes, meta := paginate(r, quotes, total, params, h.cursors, pagination.KindQuoteSearch, quoteSearchSortValue)
+	for _, quote := range quotes {
+		expandQuoteAuthors(expandAuthor, &quote.QuoteWithAuthor)
+	}
+	api.RespondPaginated(w, r, quotes, meta)
 }
 
-// GetRandom handles GET /quotes/random
+// GetRandom handles GET /quotes/random. Without count it responds with a single quote,
+// with count with a list of up to count distinct quotes.
 func (h *QuoteHandler) GetRandom(w http.ResponseWriter, r *http.Request) {
-	quote, err := h.service.GetRandomQuote(r.Context())
+	filter, err := parseQuoteFilter(r)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to get random quote")
-		api.RespondError(w, http.StatusInternalServerError, err, "GET_RANDOM_QUOTE_ERROR")
+		api.RespondServiceError(w, r, err)
 		return
 	}
 
-	api.RespondJSON(w, http.StatusOK, quote)
+	filter.AuthorIDs, err = parseAuthorIDs(r)
+	if err != nil {
+		api.RespondError(w, r, http.StatusBadRequest, err, "INVALID_AUTHOR_ID")
+		return
+	}
+
+	params, err := parseRandomParams(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	expandAuthor, err := parseQuoteExpansion(r)
+	if err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	quotes, err := h.service.RandomQuotes(r.Context(), filter, params)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get random quotes")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	expandQuoteAuthors(expandAuthor, quotes...)
+	if !r.URL.Query().Has("count") {
+		api.Respond(w, r, http.StatusOK, quotes[0])
+		return
+	}
+	api.Respond(w, r, http.StatusOK, RandomQuotesResponse{Data: quotes})
+}
+
+// RandomQuotesResponse represents a list of random quotes
+type RandomQuotesResponse struct {
+	Data []*repository.QuoteWithAuthor `json:"data"`
+}
+
+// parseRandomParams parses the count and seed of a random pick
+func parseRandomParams(r *http.Request) (repository.RandomParams, error) {
+	params := repository.RandomParams{Count: 1}
+	var violations []repository.FieldError
+
+	if value := r.URL.Query().Get("count"); value != "" {
+		count, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			violations = append(violations, repository.FieldError{Field: "count", Message: "count must be an integer"})
+		}
+		params.Count = int32(count)
+	}
+
+	if value := r.URL.Query().Get("seed"); value != "" {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			violations = append(violations, repository.FieldError{Field: "seed", Message: "seed must be an integer"})
+		}
+		params.Seed = &seed
+	}
+
+	if len(violations) > 0 {
+		return params, &repository.Error{
+			Kind:    repository.ErrValidation,
+			Message: violations[0].Message,
+			Fields:  violations,
+		}
+	}
+
+	return params, validation.Validate(&params)
+}
+
+// version looks up the stored version of a quote for If-Match lists
+func (h *QuoteHandler) version(id int64) versionLookup {
+	return func(ctx context.Context) (int64, error) {
+		quote, err := h.service.GetQuote(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return quote.Version, nil
+	}
 }
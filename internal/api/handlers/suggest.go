@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/igferreira/quotes-api/internal/api"
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/igferreira/quotes-api/internal/service"
+	"github.com/igferreira/quotes-api/internal/validation"
+	"github.com/rs/zerolog/log"
+)
+
+// Suggestion limits
+const (
+	DefaultSuggestLimit = 10
+	MaxSuggestLimit     = 20
+)
+
+// SuggestHandler handles type-ahead suggestion requests
+type SuggestHandler struct {
+	service *service.Service
+	timeout time.Duration
+}
+
+// NewSuggestHandler creates a new suggest handler answering within timeout
+func NewSuggestHandler(service *service.Service, timeout time.Duration) *SuggestHandler {
+	return &SuggestHandler{
+		service: service,
+		timeout: timeout,
+	}
+}
+
+// SuggestResponse represents a list of suggestions.
+// Partial is set when some suggestion types were left out to answer in time.
+type SuggestResponse struct {
+	Data    []*repository.Suggestion `json:"data"`
+	Partial bool                     `json:"partial,omitempty"`
+}
+
+// Suggest handles GET /suggest
+func (h *SuggestHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	params := repository.SuggestParams{
+		Query: r.URL.Query().Get("q"),
+		Types: service.SuggestionTypes,
+		Limit: DefaultSuggestLimit,
+	}
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			params.Limit = int32(min(parsed, MaxSuggestLimit))
+		}
+	}
+
+	if t := r.URL.Query().Get("types"); t != "" {
+		types, err := parseSuggestionTypes(t)
+		if err != nil {
+			api.RespondServiceError(w, r, err)
+			return
+		}
+		params.Types = types
+	}
+
+	if err := validation.Validate(&params); err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	suggestions, partial, err := h.service.Suggest(ctx, params)
+	if err != nil {
+		log.Error().Err(err).Str("query", params.Query).Msg("failed to suggest")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+	if partial {
+		log.Warn().Str("query", params.Query).Dur("timeout", h.timeout).Msg("suggestions left out to answer in time")
+	}
+
+	if suggestions == nil {
+		suggestions = []*repository.Suggestion{}
+	}
+	api.Respond(w, r, http.StatusOK, SuggestResponse{
+		Data:    suggestions,
+		Partial: partial,
+	})
+}
+
+// parseSuggestionTypes parses a comma-separated list of suggestion types
+func parseSuggestionTypes(value string) ([]string, error) {
+	var types []string
+	for _, t := range strings.Split(value, ",") {
+		t = strings.TrimSpace(t)
+		if !slices.Contains(service.SuggestionTypes, t) {
+			return nil, &repository.Error{
+				Kind:    repository.ErrValidation,
+				Message: fmt.Sprintf("types must only contain %s", strings.Join(service.SuggestionTypes, ", ")),
+				Fields: []repository.FieldError{{
+					Field:   "types",
+					Message: fmt.Sprintf("%q is not a suggestion type", t),
+				}},
+			}
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/igferreira/quotes-api/internal/api"
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/igferreira/quotes-api/internal/service"
+	"github.com/igferreira/quotes-api/internal/validation"
+	"github.com/rs/zerolog/log"
+)
+
+// TagHandler handles tag-related requests
+type TagHandler struct {
+	service      *service.Service
+	maxBodyBytes int64
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(service *service.Service, maxBodyBytes int64) *TagHandler {
+	return &TagHandler{
+		service:      service,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+// List handles GET /tags. Tags are paginated by offset only.
+func (h *TagHandler) List(w http.ResponseWriter, r *http.Request) {
+	params := parsePaginationParams(r)
+
+	tags, total, err := h.service.ListTags(r.Context(), params)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list tags")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	if tags == nil {
+		tags = []*repository.Tag{}
+	}
+	api.RespondPaginated(w, r, tags, api.PaginationMeta{
+		Total:  &total,
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	})
+}
+
+// GetBySlug handles GET /tags/{slug}
+func (h *TagHandler) GetBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	tag, err := h.service.GetTag(r.Context(), slug)
+	if err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("failed to get tag")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	api.Respond(w, r, http.StatusOK, tag)
+}
+
+// Rename handles PATCH /tags/{slug}
+func (h *TagHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	var params repository.RenameTagParams
+	if err := decodeJSON(w, r, &params, h.maxBodyBytes); err != nil {
+		respondDecodeError(w, r, err)
+		return
+	}
+
+	// Validate input
+	if err := validation.Validate(&params); err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	tag, err := h.service.RenameTag(r.Context(), slug, params)
+	if err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("failed to rename tag")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	api.Respond(w, r, http.StatusOK, tag)
+}
+
+// Merge handles POST /tags/{slug}/merge
+func (h *TagHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	var params repository.MergeTagParams
+	if err := decodeJSON(w, r, &params, h.maxBodyBytes); err != nil {
+		respondDecodeError(w, r, err)
+		return
+	}
+
+	// Validate input
+	if err := validation.Validate(&params); err != nil {
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	tag, err := h.service.MergeTag(r.Context(), slug, params)
+	if err != nil {
+		log.Error().Err(err).Str("slug", slug).Str("into", params.Into).Msg("failed to merge tag")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	api.Respond(w, r, http.StatusOK, tag)
+}
+
+// Delete handles DELETE /tags/{slug}
+func (h *TagHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	if err := h.service.DeleteTag(r.Context(), slug); err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("failed to delete tag")
+		api.RespondServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -20,6 +20,15 @@ func ErrValidation(msg string) error {
 	return errors.New(msg)
 }
 
+// invalidParam creates a validation error rejecting a single request field
+func invalidParam(field, message string) error {
+	return &repository.Error{
+		Kind:    repository.ErrValidation,
+		Message: message,
+		Fields:  []repository.FieldError{{Field: field, Message: message}},
+	}
+}
+
 // parsePaginationParams parses pagination parameters from request
 func parsePaginationParams(r *http.Request) repository.ListParams {
 	limit := DefaultLimit
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// FormatParam is the query parameter choosing a response format by name, overriding Accept
+const FormatParam = "format"
+
+// PrettyParam is the query parameter asking for indented responses
+const PrettyParam = "pretty"
+
+// EncodeOptions holds the options of the encoding of a response
+type EncodeOptions struct {
+	// Pretty asks for an indented document, in the formats that are not always indented
+	Pretty bool
+}
+
+// Encoder renders response bodies in a media type. Encoders are tried in the order they
+// are registered when Accept leaves a choice, so the first one is the default.
+type Encoder struct {
+	// Format is the name of the encoder in the format query parameter
+	Format string
+
+	// MediaType is the Content-Type of the responses, Aliases other media types it serves
+	MediaType string
+	Aliases   []string
+
+	// ProblemMediaType is the Content-Type of problem details documents, MediaType when empty
+	ProblemMediaType string
+
+	// Encode writes a response body
+	Encode func(w io.Writer, v interface{}, opts EncodeOptions) error
+}
+
+// encoders holds the registered encoders in order of preference
+var encoders []*Encoder
+
+// RegisterEncoder makes an encoder available to content negotiation. It is meant to be
+// called during initialization, before requests are served.
+func RegisterEncoder(enc *Encoder) {
+	encoders = append(encoders, enc)
+}
+
+// rendering holds how the responses to a request are rendered
+type rendering struct {
+	encoder *Encoder
+	opts    EncodeOptions
+
+	// fields selected with the fields query parameter, as a tree and as paths
+	fields     fieldSelection
+	fieldPaths []string
+}
+
+// renderingKey is the context key of the rendering of a request
+type renderingKey struct{}
+
+// Negotiate is a middleware choosing how the responses to a request are rendered: the
+// encoder from its format query parameter or Accept header, indentation from pretty and,
+// for GET requests, the fields to send from fields. Requests accepting none of the
+// registered media types are answered with 406 Not Acceptable.
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		enc, err := negotiate(r)
+		if err != nil {
+			RespondError(w, r, http.StatusNotAcceptable, err, CodeNotAcceptable)
+			return
+		}
+
+		rnd := &rendering{encoder: enc}
+		r = r.WithContext(context.WithValue(r.Context(), renderingKey{}, rnd))
+
+		query := r.URL.Query()
+		if value := query.Get(PrettyParam); value != "" {
+			pretty, err := strconv.ParseBool(value)
+			if err != nil {
+				RespondServiceError(w, r, &repository.Error{
+					Kind:    repository.ErrValidation,
+					Message: "pretty must be true or false",
+					Fields:  []repository.FieldError{{Field: PrettyParam, Message: "pretty must be true or false"}},
+				})
+				return
+			}
+			rnd.opts.Pretty = pretty
+		}
+
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			rnd.fields, rnd.fieldPaths, err = parseFields(query.Get(FieldsParam))
+			if err != nil {
+				respondFieldsError(w, r, err)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// renderingFor returns the rendering negotiated for a request, or the default one when
+// the request did not go through Negotiate
+func renderingFor(r *http.Request) *rendering {
+	if rnd, ok := r.Context().Value(renderingKey{}).(*rendering); ok {
+		return rnd
+	}
+	return &rendering{encoder: encoders[0]}
+}
+
+// EncoderFor returns the encoder negotiated for a request, or the default one when the
+// request did not go through Negotiate
+func EncoderFor(r *http.Request) *Encoder {
+	return renderingFor(r).encoder
+}
+
+// EntityTag formats the strong entity tag of the representation of a resource state sent
+// in response to a request. Other formats, indentation and field selections render other
+// bytes from the same state, so they are folded into the tag after a semicolon, as in
+// "3;xml;pretty;fields=content+id". The default representation is tagged with the state.
+func EntityTag(r *http.Request, state string) string {
+	rnd := renderingFor(r)
+	tag := state
+	if rnd.encoder != encoders[0] {
+		tag += ";" + rnd.encoder.Format
+	}
+	if rnd.opts.Pretty {
+		tag += ";pretty"
+	}
+	if len(rnd.fieldPaths) > 0 {
+		paths := slices.Clone(rnd.fieldPaths)
+		slices.Sort(paths)
+		tag += ";fields=" + strings.Join(slices.Compact(paths), "+")
+	}
+	return `"` + tag + `"`
+}
+
+// negotiate picks the encoder named by the format query parameter or, without it,
+// the one best matching the Accept header
+func negotiate(r *http.Request) (*Encoder, error) {
+	if format := r.URL.Query().Get(FormatParam); format != "" {
+		for _, enc := range encoders {
+			if enc.Format == format {
+				return enc, nil
+			}
+		}
+		return nil, fmt.Errorf("format must be one of %s, got %q", strings.Join(formatNames(), ", "), format)
+	}
+
+	ranges := parseAccept(r.Header.Get("Accept"))
+	if len(ranges) == 0 {
+		return encoders[0], nil
+	}
+
+	var best *Encoder
+	var bestQ float64
+	for _, enc := range encoders {
+		if q := enc.quality(ranges); q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("none of the accepted media types can be produced, use one of %s",
+			strings.Join(mediaTypes(), ", "))
+	}
+	return best, nil
+}
+
+// mediaRange is a media range of an Accept header with its quality
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept parses an Accept header, skipping malformed media ranges
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+
+	// the most specific range matching a media type gives its quality
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+// specificity ranks exact media types before type/* before */*
+func (mr mediaRange) specificity() int {
+	switch {
+	case mr.typ == "*":
+		return 0
+	case mr.subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matches reports whether a media type belongs to the range
+func (mr mediaRange) matches(mediaType string) bool {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	return (mr.typ == "*" || mr.typ == typ) && (mr.subtype == "*" || mr.subtype == subtype)
+}
+
+// quality returns how much the encoder is wanted by the ranges of an Accept header, sorted
+// by specificity, 0 meaning not at all. Aliases only match exact media types, so that text/*
+// does not select XML through text/xml.
+func (enc *Encoder) quality(ranges []mediaRange) float64 {
+	primary, _, _ := mime.ParseMediaType(enc.MediaType)
+	for _, mr := range ranges {
+		if mr.specificity() < 2 {
+			if mr.matches(primary) {
+				return mr.q
+			}
+			continue
+		}
+		for _, mediaType := range enc.mediaTypes() {
+			if mr.matches(mediaType) {
+				return mr.q
+			}
+		}
+	}
+	return 0
+}
+
+// mediaTypes returns the media types served by the encoder, without parameters
+func (enc *Encoder) mediaTypes() []string {
+	types := []string{enc.MediaType, enc.ProblemMediaType}
+	types = append(types, enc.Aliases...)
+
+	var result []string
+	for _, t := range types {
+		if t == "" {
+			continue
+		}
+		if mediaType, _, err := mime.ParseMediaType(t); err == nil {
+			result = append(result, mediaType)
+		}
+	}
+	return result
+}
+
+// contentType returns the Content-Type of a response, problem details documents included
+func (enc *Encoder) contentType(problem bool) string {
+	if problem && enc.ProblemMediaType != "" {
+		return enc.ProblemMediaType
+	}
+	return enc.MediaType
+}
+
+// formatNames returns the names of the registered encoders
+func formatNames() []string {
+	names := make([]string, len(encoders))
+	for i, enc := range encoders {
+		names[i] = enc.Format
+	}
+	return names
+}
+
+// mediaTypes returns the media types of the registered encoders
+func mediaTypes() []string {
+	types := make([]string, len(encoders))
+	for i, enc := range encoders {
+		types[i], _, _ = strings.Cut(enc.MediaType, ";")
+	}
+	return types
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []mediaRange
+	}{
+		{
+			name:   "empty",
+			header: "",
+			want:   nil,
+		},
+		{
+			name:   "single type",
+			header: "application/xml",
+			want:   []mediaRange{{typ: "application", subtype: "xml", q: 1}},
+		},
+		{
+			name:   "qualities and parameters",
+			header: "application/json;q=0.5, text/csv; charset=utf-8",
+			want: []mediaRange{
+				{typ: "application", subtype: "json", q: 0.5},
+				{typ: "text", subtype: "csv", q: 1},
+			},
+		},
+		{
+			name:   "most specific first, in order otherwise",
+			header: "*/*;q=0.1, text/*;q=0.2, application/yaml, text/plain;q=0.3",
+			want: []mediaRange{
+				{typ: "application", subtype: "yaml", q: 1},
+				{typ: "text", subtype: "plain", q: 0.3},
+				{typ: "text", subtype: "*", q: 0.2},
+				{typ: "*", subtype: "*", q: 0.1},
+			},
+		},
+		{
+			name:   "malformed ranges are skipped",
+			header: "application, /xml, text/plain;q=2, text/csv;q=x, , application/yaml;q=0",
+			want:   []mediaRange{{typ: "application", subtype: "yaml", q: 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAccept(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAccept(%q) = %+v, want %+v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name    string
+		accept  string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{name: "no Accept header", want: FormatJSON},
+		{name: "anything", accept: "*/*", want: FormatJSON},
+		{name: "exact type", accept: "application/xml", want: FormatXML},
+		{name: "type with parameters", accept: "text/plain; charset=utf-8", want: FormatText},
+		{name: "alias", accept: "text/xml", want: FormatXML},
+		{name: "other alias", accept: "application/x-yaml", want: FormatYAML},
+		{name: "problem media type", accept: "application/problem+xml", want: FormatXML},
+		{name: "highest quality", accept: "application/json;q=0.5, application/yaml;q=0.8", want: FormatYAML},
+		{name: "most specific range gives the quality", accept: "application/*;q=0.9, application/json;q=0.1, text/csv;q=0.5", want: FormatXML},
+		{name: "ties go to the first registered", accept: "application/yaml, application/xml", want: FormatXML},
+		{name: "subtype wildcard skips aliases", accept: "text/*", want: FormatCSV},
+		{name: "zero quality excludes", accept: "application/json;q=0, */*", want: FormatXML},
+		{name: "format overrides Accept", accept: "application/xml", format: "csv", want: FormatCSV},
+		{name: "nothing acceptable", accept: "image/png", wantErr: true},
+		{name: "only excluded types", accept: "application/json;q=0", wantErr: true},
+		{name: "unknown format", format: "pdf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/quotes"
+			if tt.format != "" {
+				target += "?format=" + tt.format
+			}
+			r := httptest.NewRequest(http.MethodGet, target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			enc, err := negotiate(r)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("negotiate() = %s, want an error", enc.Format)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("negotiate() error = %v", err)
+			}
+			if enc.Format != tt.want {
+				t.Errorf("negotiate() = %s, want %s", enc.Format, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiateNotAcceptable(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		format string
+	}{
+		{name: "unsupported Accept", accept: "image/png"},
+		{name: "unknown format", format: "pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Negotiate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Error("handler was called")
+			}))
+
+			target := "/quotes"
+			if tt.format != "" {
+				target += "?format=" + tt.format
+			}
+			r := httptest.NewRequest(http.MethodGet, target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != http.StatusNotAcceptable {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusNotAcceptable)
+			}
+			if got := w.Header().Get("Content-Type"); got != ProblemContentType {
+				t.Errorf("Content-Type = %q, want %q", got, ProblemContentType)
+			}
+			var problem ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("failed to decode problem: %v", err)
+			}
+			if problem.Code != CodeNotAcceptable || problem.Status != http.StatusNotAcceptable {
+				t.Errorf("problem = %+v, want code %s and status %d", problem, CodeNotAcceptable, http.StatusNotAcceptable)
+			}
+		})
+	}
+}
+
+func TestEntityTag(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   string
+	}{
+		{name: "default representation", target: "/quotes/1", want: `"3-7"`},
+		{name: "explicit JSON", target: "/quotes/1", accept: "application/json", want: `"3-7"`},
+		{name: "other format", target: "/quotes/1", accept: "application/xml", want: `"3-7;xml"`},
+		{name: "format parameter", target: "/quotes/1?format=text", want: `"3-7;text"`},
+		{name: "pretty", target: "/quotes/1?pretty=true", want: `"3-7;pretty"`},
+		{name: "fields in any order", target: "/quotes/1?fields=id,content,id", want: `"3-7;fields=content+id"`},
+		{
+			name:   "everything",
+			target: "/quotes/1?format=yaml&pretty=true&fields=author.name,content",
+			want:   `"3-7;yaml;pretty;fields=author.name+content"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := Negotiate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = EntityTag(r, "3-7")
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.want {
+				t.Errorf("EntityTag() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
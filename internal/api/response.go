@@ -1,24 +1,54 @@
 package api
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/igferreira/quotes-api/internal/repository"
 	"github.com/rs/zerolog/log"
 )
 
-// ErrorResponse represents an error response
+// ProblemContentType is the media type of RFC 7807 problem details documents
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBaseURI is the base of the type URIs identifying each error code
+const ProblemTypeBaseURI = "https://github.com/igferreira/quotes-api/blob/main/docs/problems.md#"
+
+// ErrorResponse represents an error response as an RFC 7807 problem details document
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-	Code    string `json:"code,omitempty"`
+	Type      string                  `json:"type"`
+	Title     string                  `json:"title"`
+	Status    int                     `json:"status"`
+	Detail    string                  `json:"detail,omitempty"`
+	Instance  string                  `json:"instance,omitempty"`
+	Code      string                  `json:"code,omitempty"`
+	RequestID string                  `json:"request_id,omitempty"`
+	Errors    []repository.FieldError `json:"errors,omitempty"`
+
+	// Report is the report of an import stopped by the error, covering the rows handled before it
+	Report interface{} `json:"report,omitempty"`
+}
+
+// fieldErrors is implemented by errors that refer to specific request fields
+type fieldErrors interface {
+	FieldErrors() []repository.FieldError
 }
 
-// PaginationMeta represents pagination metadata
+// PaginationMeta represents pagination metadata.
+// Total is omitted when the client opted out of counting with include_total=false.
+// NextCursor and PrevCursor are set when there is a page after or before the current one.
 type PaginationMeta struct {
-	Total  int64 `json:"total"`
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Total      *int64 `json:"total,omitempty"`
+	Limit      int32  `json:"limit"`
+	Offset     int32  `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 // PaginatedResponse represents a paginated response
@@ -27,35 +57,121 @@ type PaginatedResponse struct {
 	Meta PaginationMeta `json:"meta"`
 }
 
-// RespondJSON sends a JSON response
-func RespondJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
+// Respond sends a response in the format negotiated for the request
+func Respond(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	respond(w, r, status, false, data)
+}
 
-	if data != nil {
-		if err := json.NewEncoder(w).Encode(data); err != nil {
-			log.Error().Err(err).Msg("failed to encode response")
-		}
-	}
+// RespondError sends an error response as a problem details document
+func RespondError(w http.ResponseWriter, r *http.Request, status int, err error, code string) {
+	RespondProblem(w, r, Problem(r, status, err, code))
 }
 
-// RespondError sends an error response
-func RespondError(w http.ResponseWriter, status int, err error, code string) {
-	RespondJSON(w, status, ErrorResponse{
-		Error:   http.StatusText(status),
-		Message: err.Error(),
-		Code:    code,
-	})
+// RespondProblem sends a problem details document with its status
+func RespondProblem(w http.ResponseWriter, r *http.Request, problem ErrorResponse) {
+	respond(w, r, problem.Status, true, problem)
+}
+
+// Problem builds the problem details document describing an error of a request
+func Problem(r *http.Request, status int, err error, code string) ErrorResponse {
+	problem := ErrorResponse{
+		Type:      problemType(code),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    err.Error(),
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+
+	var withFields fieldErrors
+	if errors.As(err, &withFields) {
+		problem.Errors = withFields.FieldErrors()
+	}
+
+	return problem
 }
 
-// RespondPaginated sends a paginated response
-func RespondPaginated(w http.ResponseWriter, data interface{}, total int64, limit, offset int32) {
-	RespondJSON(w, http.StatusOK, PaginatedResponse{
+// RespondPaginated sends a paginated response. The total and the links to the next and
+// previous pages are also sent as X-Total-Count and Link headers, for the formats that
+// only carry the data.
+func RespondPaginated(w http.ResponseWriter, r *http.Request, data interface{}, meta PaginationMeta) {
+	if meta.Total != nil {
+		w.Header().Set("X-Total-Count", strconv.FormatInt(*meta.Total, 10))
+	}
+	for _, link := range []struct{ rel, cursor string }{
+		{"next", meta.NextCursor},
+		{"prev", meta.PrevCursor},
+	} {
+		if link.cursor != "" {
+			w.Header().Add("Link", fmt.Sprintf("<%s>; rel=%q", cursorURL(r, link.cursor), link.rel))
+		}
+	}
+
+	Respond(w, r, http.StatusOK, PaginatedResponse{
 		Data: data,
-		Meta: PaginationMeta{
-			Total:  total,
-			Limit:  limit,
-			Offset: offset,
-		},
+		Meta: meta,
 	})
 }
+
+// cursorURL returns the URL of the request with its position replaced by a cursor
+func cursorURL(r *http.Request, cursor string) string {
+	u := *r.URL
+	query := u.Query()
+	query.Del("offset")
+	query.Set("cursor", cursor)
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
+
+// respond encodes data as negotiated for the request, keeping the selected fields of
+// successful responses. The body is encoded before anything is sent, so that a value the
+// format cannot represent fails the request with a 500 instead of a truncated body.
+func respond(w http.ResponseWriter, r *http.Request, status int, problem bool, data interface{}) {
+	rnd := renderingFor(r)
+
+	if data != nil && !problem && rnd.fields != nil {
+		if err := checkFields(data, rnd.fieldPaths); err != nil {
+			respondFieldsError(w, r, err)
+			return
+		}
+		node, err := toNode(data)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to select response fields")
+			RespondError(w, r, http.StatusInternalServerError, errors.New("an unexpected error occurred"), CodeInternal)
+			return
+		}
+		selectFields(node, rnd.fields)
+		data = document{node: node}
+	}
+
+	var body bytes.Buffer
+	if data != nil {
+		if err := rnd.encoder.Encode(&body, data, rnd.opts); err != nil {
+			log.Error().Err(err).Str("format", rnd.encoder.Format).Msg("failed to encode response")
+			if problem || rnd.encoder == encoders[0] {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			// report the failure in the default format, which represents every value
+			r = r.WithContext(context.WithValue(r.Context(), renderingKey{}, &rendering{encoder: encoders[0], opts: rnd.opts}))
+			RespondError(w, r, http.StatusInternalServerError,
+				fmt.Errorf("response cannot be rendered as %s", rnd.encoder.Format), CodeInternal)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", rnd.encoder.contentType(problem))
+	w.WriteHeader(status)
+	if _, err := body.WriteTo(w); err != nil {
+		log.Debug().Err(err).Msg("failed to write response")
+	}
+}
+
+// problemType returns the type URI documenting an error code
+func problemType(code string) string {
+	if code == "" {
+		return "about:blank"
+	}
+	return ProblemTypeBaseURI + strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
@@ -1,16 +1,20 @@
 package api
 
 import (
+	"net/http"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/igferreira/quotes-api/internal/api/handlers"
 	mw "github.com/igferreira/quotes-api/internal/api/middleware"
+	"github.com/igferreira/quotes-api/internal/config"
+	"github.com/igferreira/quotes-api/internal/pagination"
 	"github.com/igferreira/quotes-api/internal/service"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // NewRouter creates a new router with all routes configured
-func NewRouter(service *service.Service, db *pgxpool.Pool) *chi.Mux {
+func NewRouter(cfg *config.Config, service *service.Service, db *pgxpool.Pool) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Global middleware
@@ -21,38 +25,84 @@ func NewRouter(service *service.Service, db *pgxpool.Pool) *chi.Mux {
 	r.Use(mw.CORS)
 	r.Use(middleware.Timeout(60)) // 60 second timeout
 
+	// Unknown routes and methods answer with problem details as well
+	r.NotFound(Negotiate(http.HandlerFunc(NotFound)).ServeHTTP)
+	r.MethodNotAllowed(Negotiate(http.HandlerFunc(MethodNotAllowed)).ServeHTTP)
+
 	// Health checks
 	healthHandler := handlers.NewHealthHandler(db)
-	r.Get("/healthz", healthHandler.Liveness)
-	r.Get("/readyz", healthHandler.Readiness)
+	r.With(Negotiate).Get("/healthz", healthHandler.Liveness)
+	r.With(Negotiate).Get("/readyz", healthHandler.Readiness)
 
 	// API routes
+	cursors := pagination.NewCodec([]byte(cfg.CursorSecret))
 	r.Route("/api/v1", func(r chi.Router) {
-		// Authors
-		authorHandler := handlers.NewAuthorHandler(service)
-		r.Route("/authors", func(r chi.Router) {
-			r.Get("/", authorHandler.List)
-			r.Post("/", authorHandler.Create)
-			r.Get("/search", authorHandler.Search)
-			r.Route("/{id}", func(r chi.Router) {
-				r.Get("/", authorHandler.GetByID)
-				r.Put("/", authorHandler.Update)
-				r.Delete("/", authorHandler.Delete)
+		authorHandler := handlers.NewAuthorHandler(service, cursors, cfg.MaxBodyBytes)
+		quoteHandler := handlers.NewQuoteHandler(service, cursors, cfg.MaxBodyBytes)
+		dailyQuoteHandler := handlers.NewDailyQuoteHandler(service, cfg.DailyQuoteRepeatDays, cfg.MaxBodyBytes)
+		tagHandler := handlers.NewTagHandler(service, cfg.MaxBodyBytes)
+		suggestHandler := handlers.NewSuggestHandler(service, cfg.SuggestTimeout)
+		importHandler := handlers.NewImportHandler(service, cfg.ImportMaxBytes)
+		exportHandler := handlers.NewExportHandler(service, cfg.WriteTimeout)
+
+		// Imports and exports take the format of their file in ?format=, so their
+		// responses are not negotiated
+		r.Post("/import", importHandler.Import)
+		r.Get("/export", exportHandler.Export)
+
+		// Every other response is rendered in the format negotiated from Accept or ?format=
+		r.Group(func(r chi.Router) {
+			r.Use(Negotiate)
+
+			// Authors
+			r.Post("/authors:batch", authorHandler.Batch)
+			r.Route("/authors", func(r chi.Router) {
+				r.Get("/", authorHandler.List)
+				r.Post("/", authorHandler.Create)
+				r.Get("/search", authorHandler.Search)
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", authorHandler.GetByID)
+					r.Put("/", authorHandler.Update)
+					r.Patch("/", authorHandler.Patch)
+					r.Delete("/", authorHandler.Delete)
+					r.Get("/quotes", quoteHandler.ListByAuthor)
+					r.Post("/aliases", authorHandler.AddAlias)
+					r.Delete("/aliases/{alias}", authorHandler.RemoveAlias)
+				})
+			})
+
+			// Quotes
+			r.Post("/quotes:batch", quoteHandler.Batch)
+			r.Route("/quotes", func(r chi.Router) {
+				r.Get("/", quoteHandler.List)
+				r.Post("/", quoteHandler.Create)
+				r.Get("/search", quoteHandler.Search)
+				r.Get("/random", quoteHandler.GetRandom)
+				r.Get("/daily", dailyQuoteHandler.Get)
+				r.Put("/daily/{date}", dailyQuoteHandler.Schedule)
+				r.Delete("/daily/{date}", dailyQuoteHandler.Unschedule)
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", quoteHandler.GetByID)
+					r.Put("/", quoteHandler.Update)
+					r.Patch("/", quoteHandler.Patch)
+					r.Delete("/", quoteHandler.Delete)
+				})
 			})
-		})
 
-		// Quotes
-		quoteHandler := handlers.NewQuoteHandler(service)
-		r.Route("/quotes", func(r chi.Router) {
-			r.Get("/", quoteHandler.List)
-			r.Post("/", quoteHandler.Create)
-			r.Get("/search", quoteHandler.Search)
-			r.Get("/random", quoteHandler.GetRandom)
-			r.Route("/{id}", func(r chi.Router) {
-				r.Get("/", quoteHandler.GetByID)
-				r.Put("/", quoteHandler.Update)
-				r.Delete("/", quoteHandler.Delete)
+			// Tags
+			r.Route("/tags", func(r chi.Router) {
+				r.Get("/", tagHandler.List)
+				r.Route("/{slug}", func(r chi.Router) {
+					r.Get("/", tagHandler.GetBySlug)
+					r.Patch("/", tagHandler.Rename)
+					r.Delete("/", tagHandler.Delete)
+					r.Post("/merge", tagHandler.Merge)
+					r.Get("/quotes", quoteHandler.ListByTag)
+				})
 			})
+
+			// Suggestions
+			r.Get("/suggest", suggestHandler.Suggest)
 		})
 	})
 
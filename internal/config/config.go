@@ -13,6 +13,13 @@ type Config struct {
 	ReadTimeout  time.Duration `envconfig:"READ_TIMEOUT" default:"10s"`
 	WriteTimeout time.Duration `envconfig:"WRITE_TIMEOUT" default:"10s"`
 	IdleTimeout  time.Duration `envconfig:"IDLE_TIMEOUT" default:"120s"`
+	MaxBodyBytes int64         `envconfig:"MAX_BODY_BYTES" default:"1048576"`
+
+	// Maximum size of an uploaded import file
+	ImportMaxBytes int64 `envconfig:"IMPORT_MAX_BYTES" default:"33554432"`
+
+	// Secret signing pagination cursors; a random one is generated when empty
+	CursorSecret string `envconfig:"CURSOR_SECRET"`
 
 	// Database configuration
 	DBHost     string `envconfig:"DB_HOST" default:"localhost"`
@@ -28,6 +35,13 @@ type Config struct {
 	DBMaxConnLifetime time.Duration `envconfig:"DB_MAX_CONN_LIFETIME" default:"1h"`
 	DBMaxConnIdleTime time.Duration `envconfig:"DB_MAX_CONN_IDLE_TIME" default:"30m"`
 
+	// Search
+	AuthorSearchThreshold float64       `envconfig:"AUTHOR_SEARCH_THRESHOLD" default:"0.3"`
+	SuggestTimeout        time.Duration `envconfig:"SUGGEST_TIMEOUT" default:"200ms"`
+
+	// Quote of the day: days within which a quote is not drawn again
+	DailyQuoteRepeatDays int `envconfig:"DAILY_QUOTE_REPEAT_DAYS" default:"30"`
+
 	// Logging
 	LogLevel string `envconfig:"LOG_LEVEL" default:"info"`
 	LogJSON  bool   `envconfig:"LOG_JSON" default:"false"`
@@ -42,6 +56,12 @@ func Load() (*Config, error) {
 	if err := envconfig.Process("", &cfg); err != nil {
 		return nil, fmt.Errorf("failed to process env config: %w", err)
 	}
+	if cfg.AuthorSearchThreshold < 0 || cfg.AuthorSearchThreshold > 1 {
+		return nil, fmt.Errorf("AUTHOR_SEARCH_THRESHOLD must be between 0 and 1, got %g", cfg.AuthorSearchThreshold)
+	}
+	if cfg.DailyQuoteRepeatDays < 0 {
+		return nil, fmt.Errorf("DAILY_QUOTE_REPEAT_DAYS must not be negative, got %d", cfg.DailyQuoteRepeatDays)
+	}
 	return &cfg, nil
 }
 
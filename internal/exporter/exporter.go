@@ -0,0 +1,203 @@
+// Package exporter writes quotes as JSON Lines, CSV or JSON documents one quote at a time,
+// so exports of any size are written in constant memory.
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// Supported formats
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+)
+
+// Formats lists the supported formats
+var Formats = []string{FormatNDJSON, FormatCSV, FormatJSON}
+
+// Writer writes quotes to a document. Written quotes are buffered until Flush or Close;
+// Close ends the document and must be called once every quote is written.
+type Writer interface {
+	Write(quote *repository.Quote) error
+	Flush() error
+	Close() error
+}
+
+// NewWriter creates a writer for a document in the given format. With withAuthors, the
+// quotes carry their author: embedded in JSON formats, as extra columns in CSV.
+func NewWriter(format string, w io.Writer, withAuthors bool) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		buf := bufio.NewWriter(w)
+		return &ndjsonWriter{buf: buf, enc: json.NewEncoder(buf)}, nil
+	case FormatCSV:
+		return &csvWriter{csv: csv.NewWriter(w), withAuthors: withAuthors}, nil
+	case FormatJSON:
+		return &jsonWriter{buf: bufio.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("format must be one of %s, got %q", strings.Join(Formats, ", "), format)
+	}
+}
+
+// ContentType returns the media type of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// Filename returns the name of an export file made at the given time
+func Filename(format string, at time.Time) string {
+	return fmt.Sprintf("quotes-%s.%s", at.UTC().Format("20060102T150405Z"), format)
+}
+
+// ndjsonWriter writes one JSON object per line
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+// Write writes a quote as a line
+func (w *ndjsonWriter) Write(quote *repository.Quote) error {
+	return w.enc.Encode(quote)
+}
+
+// Flush writes the buffered lines
+func (w *ndjsonWriter) Flush() error {
+	return w.buf.Flush()
+}
+
+// Close flushes the document, which needs no ending
+func (w *ndjsonWriter) Close() error {
+	return w.buf.Flush()
+}
+
+// jsonWriter writes a JSON array
+type jsonWriter struct {
+	buf   *bufio.Writer
+	count int
+}
+
+// Write writes a quote as the next element of the array
+func (w *jsonWriter) Write(quote *repository.Quote) error {
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n"
+	if w.count == 0 {
+		sep = "[\n"
+	}
+	w.count++
+
+	if _, err := w.buf.WriteString(sep); err != nil {
+		return err
+	}
+	_, err = w.buf.Write(data)
+	return err
+}
+
+// Flush writes the buffered elements
+func (w *jsonWriter) Flush() error {
+	return w.buf.Flush()
+}
+
+// Close ends the array and flushes the document
+func (w *jsonWriter) Close() error {
+	end := "\n]\n"
+	if w.count == 0 {
+		end = "[]\n"
+	}
+	if _, err := w.buf.WriteString(end); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
+
+// CSV columns of an export; the author columns are only written with the authors.
+// Tags are separated by pipes and times are RFC 3339 timestamps.
+var (
+	csvColumns       = []string{"id", "content", "author_id", "source", "tags", "created_at", "updated_at", "version"}
+	csvAuthorColumns = []string{"author_name", "author_bio"}
+)
+
+// csvWriter writes a CSV file with a header row
+type csvWriter struct {
+	csv         *csv.Writer
+	withAuthors bool
+	started     bool
+}
+
+// Write writes a quote as a row, after the header for the first one
+func (w *csvWriter) Write(quote *repository.Quote) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	row := []string{
+		strconv.FormatInt(quote.ID, 10),
+		quote.Content,
+		strconv.FormatInt(quote.AuthorID, 10),
+		stringValue(quote.Source),
+		strings.Join(quote.Tags, "|"),
+		quote.CreatedAt.Format(time.RFC3339Nano),
+		quote.UpdatedAt.Format(time.RFC3339Nano),
+		strconv.FormatInt(quote.Version, 10),
+	}
+	if w.withAuthors && quote.Author != nil {
+		row = append(row, quote.Author.Name, stringValue(quote.Author.Bio))
+	}
+	return w.csv.Write(row)
+}
+
+// writeHeader writes the header row unless it was written already
+func (w *csvWriter) writeHeader() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	header := csvColumns
+	if w.withAuthors {
+		header = append(header[:len(header):len(header)], csvAuthorColumns...)
+	}
+	return w.csv.Write(header)
+}
+
+// Flush writes the buffered rows
+func (w *csvWriter) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// Close writes the header of an empty export and flushes the document
+func (w *csvWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// stringValue returns the value of an optional string, empty when it is not set
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,138 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// CSV columns, matched ignoring case. The first row of a CSV file names its columns;
+// content and author are required. Tags are read from a tags column listing them
+// separated by commas, semicolons or pipes, and from any number of tag columns
+// (tag, tag1, tag_2, ...) holding one tag each. The read-only columns of an export are
+// ignored, so that a CSV export with its authors can be imported again.
+const (
+	ColumnContent   = "content"
+	ColumnAuthor    = "author"
+	ColumnAuthorBio = "author_bio"
+	ColumnSource    = "source"
+	ColumnTags      = "tags"
+)
+
+// columnAliases maps other common column names to the columns above
+var columnAliases = map[string]string{
+	"quote":       ColumnContent,
+	"text":        ColumnContent,
+	"author_name": ColumnAuthor,
+	"bio":         ColumnAuthorBio,
+}
+
+// readOnlyColumns are the columns of an export set by the server, which are ignored
+var readOnlyColumns = map[string]bool{
+	"id":         true,
+	"author_id":  true,
+	"created_at": true,
+	"updated_at": true,
+	"version":    true,
+}
+
+// tagColumn matches the columns holding a single tag
+var tagColumn = regexp.MustCompile(`^tag_?[0-9]*$`)
+
+// CSVReader reads records from a CSV file with a header row
+type CSVReader struct {
+	csv     *csv.Reader
+	columns map[string]int
+	tagCols []int
+}
+
+// NewCSVReader reads the header of a CSV file and returns a reader for its rows
+func NewCSVReader(r io.Reader) (*CSVReader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, formatError("file", "file is empty")
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, formatError("file", fmt.Sprintf("header row is not valid CSV: %v", parseErr.Err))
+		}
+		return nil, err
+	}
+
+	reader := &CSVReader{csv: cr, columns: make(map[string]int)}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if alias, ok := columnAliases[name]; ok {
+			name = alias
+		}
+
+		switch {
+		case readOnlyColumns[name]:
+			continue
+		case tagColumn.MatchString(name):
+			reader.tagCols = append(reader.tagCols, i)
+		case name == ColumnContent, name == ColumnAuthor, name == ColumnAuthorBio, name == ColumnSource, name == ColumnTags:
+			if _, ok := reader.columns[name]; ok {
+				return nil, formatError("header", fmt.Sprintf("column %q appears more than once", name))
+			}
+			reader.columns[name] = i
+		default:
+			return nil, formatError("header", fmt.Sprintf("column %q is not a known column", header[i]))
+		}
+	}
+
+	for _, required := range []string{ColumnContent, ColumnAuthor} {
+		if _, ok := reader.columns[required]; !ok {
+			return nil, formatError("header", fmt.Sprintf("column %q is required", required))
+		}
+	}
+
+	return reader, nil
+}
+
+// Read reads the next record
+func (r *CSVReader) Read() (*Record, error) {
+	row, err := r.csv.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &RowError{Row: parseErr.StartLine, Message: parseErr.Err.Error()}
+		}
+		return nil, err
+	}
+
+	line, _ := r.csv.FieldPos(0)
+	record := &Record{
+		Row:     line,
+		Content: r.cell(row, ColumnContent),
+		Author:  r.cell(row, ColumnAuthor),
+	}
+	if _, ok := r.columns[ColumnAuthorBio]; ok {
+		record.AuthorBio = optionalString(r.cell(row, ColumnAuthorBio))
+	}
+	if _, ok := r.columns[ColumnSource]; ok {
+		record.Source = optionalString(r.cell(row, ColumnSource))
+	}
+	if _, ok := r.columns[ColumnTags]; ok {
+		record.Tags = splitTags(r.cell(row, ColumnTags))
+	}
+	for _, i := range r.tagCols {
+		if tag := strings.TrimSpace(row[i]); tag != "" {
+			record.Tags = append(record.Tags, tag)
+		}
+	}
+
+	return record, nil
+}
+
+// cell returns the value of a column in a row
+func (r *CSVReader) cell(row []string, column string) string {
+	return row[r.columns[column]]
+}
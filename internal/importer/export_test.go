@@ -0,0 +1,89 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igferreira/quotes-api/internal/exporter"
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+func TestImportExport(t *testing.T) {
+	source := "Letter to a friend, 1931"
+	bio := "Theoretical physicist"
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	quotes := []*repository.Quote{
+		{
+			ID: 1, Content: "Imagination is more important than knowledge.", AuthorID: 7,
+			Author: &repository.Author{ID: 7, Name: "Albert Einstein", Bio: &bio, CreatedAt: at, UpdatedAt: at, Version: 2},
+			Source: &source, Tags: []string{"imagination", "knowledge"},
+			CreatedAt: at, UpdatedAt: at, Version: 3,
+		},
+		{
+			ID: 2, Content: "Be yourself; everyone else is already taken, \"they\" say.", AuthorID: 8,
+			Author:    &repository.Author{ID: 8, Name: "Oscar Wilde", CreatedAt: at, UpdatedAt: at, Version: 1},
+			CreatedAt: at, UpdatedAt: at, Version: 1,
+		},
+	}
+	want := []Record{
+		{Content: quotes[0].Content, Author: "Albert Einstein", AuthorBio: &bio, Source: &source, Tags: []string{"imagination", "knowledge"}},
+		{Content: quotes[1].Content, Author: "Oscar Wilde"},
+	}
+
+	for _, format := range []string{exporter.FormatCSV, exporter.FormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			var file bytes.Buffer
+			w, err := exporter.NewWriter(format, &file, true)
+			if err != nil {
+				t.Fatalf("NewWriter() error = %v", err)
+			}
+			for _, q := range quotes {
+				if err := w.Write(q); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			r, err := NewReader(format, &file)
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			var got []Record
+			for {
+				record, err := r.Read()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Read() error = %v", err)
+				}
+				record.Row = 0
+				got = append(got, *record)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("imported %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestImportRejectsUnknownColumns(t *testing.T) {
+	if _, err := NewCSVReader(strings.NewReader("content,author,rating\n")); err == nil {
+		t.Error("NewCSVReader() with an unknown column error = nil, want an error")
+	}
+
+	r := NewNDJSONReader(strings.NewReader(`{"content": "...", "author": "Anonymous", "rating": 5}` + "\n"))
+	var rowErr *RowError
+	if _, err := r.Read(); !errors.As(err, &rowErr) {
+		t.Errorf("Read() with an unknown field error = %v, want a row error", err)
+	}
+}
@@ -0,0 +1,134 @@
+// Package importer reads quotes to import from CSV and JSON Lines streams.
+// Records are read one at a time, so a file of any size is imported in constant memory.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// Supported formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Record is a quote to import, with its author referred to by name
+type Record struct {
+	// Row is the line of the record in the file, starting at 1
+	Row int `json:"-"`
+
+	Content   string   `json:"content" validate:"required,min=1,max=5000"`
+	Author    string   `json:"author" validate:"required,min=1,max=255"`
+	AuthorBio *string  `json:"author_bio,omitempty" validate:"omitempty,max=5000"`
+	Source    *string  `json:"source,omitempty" validate:"omitempty,max=500"`
+	Tags      []string `json:"tags,omitempty" validate:"omitempty,max=20,dive,required,max=50"`
+}
+
+// Reader reads the records of a file. Read returns io.EOF after the last record and a
+// *RowError for a row that cannot be read, after which reading continues with the next
+// row. Any other error is fatal.
+type Reader interface {
+	Read() (*Record, error)
+}
+
+// RowError describes why a row was not imported
+type RowError struct {
+	Row     int                     `json:"row"`
+	Message string                  `json:"message"`
+	Fields  []repository.FieldError `json:"errors,omitempty"`
+}
+
+// Error returns the message of the error, prefixed with the row
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+}
+
+// Options control an import
+type Options struct {
+	// DryRun checks every row without writing anything
+	DryRun bool
+
+	// CreateAuthors creates the authors that do not exist yet; otherwise their quotes are rejected
+	CreateAuthors bool
+}
+
+// MaxReportedErrors is the maximum number of row errors listed in a report
+const MaxReportedErrors = 1000
+
+// Report summarizes an import. With DryRun, Imported and AuthorsCreated count what would
+// have been written.
+type Report struct {
+	DryRun          bool       `json:"dry_run"`
+	Rows            int        `json:"rows"`
+	Imported        int        `json:"imported"`
+	Failed          int        `json:"failed"`
+	AuthorsCreated  int        `json:"authors_created"`
+	Errors          []RowError `json:"errors"`
+	ErrorsTruncated bool       `json:"errors_truncated,omitempty"`
+}
+
+// Fail records a row that was not imported
+func (r *Report) Fail(err RowError) {
+	r.Failed++
+	if len(r.Errors) < MaxReportedErrors {
+		r.Errors = append(r.Errors, err)
+	} else {
+		r.ErrorsTruncated = true
+	}
+}
+
+// NewReader creates a reader for a file in the given format
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVReader(r)
+	case FormatNDJSON:
+		return NewNDJSONReader(r), nil
+	default:
+		return nil, formatError("format", fmt.Sprintf("format must be %s or %s, got %q", FormatCSV, FormatNDJSON, format))
+	}
+}
+
+// DetectFormat guesses the format of a file from its name, returning "" when unknown
+func DetectFormat(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	}
+	return ""
+}
+
+// formatError creates a validation error for a file that cannot be read at all
+func formatError(field, message string) error {
+	return &repository.Error{
+		Kind:    repository.ErrValidation,
+		Message: message,
+		Fields:  []repository.FieldError{{Field: field, Message: message}},
+	}
+}
+
+// splitTags splits a cell listing several tags separated by commas, semicolons or pipes
+func splitTags(cell string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(cell, func(r rune) bool { return r == ',' || r == ';' || r == '|' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// optionalString returns nil for a blank value
+func optionalString(value string) *string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return &value
+}
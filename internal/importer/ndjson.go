@@ -0,0 +1,150 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MaxLineBytes is the maximum size of a line of a JSON Lines file
+const MaxLineBytes = 1 << 20
+
+// NDJSONReader reads records from a JSON Lines file holding one JSON object per line
+// with the fields of Record. Blank lines are skipped and tags may also be given as a
+// single string, like the tags column of a CSV file. The author may also be an object
+// with its name and bio, and the read-only fields of an export are ignored, so that an
+// NDJSON export with its authors can be imported again.
+type NDJSONReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// ndjsonRecord is the JSON form of a record
+type ndjsonRecord struct {
+	Content   string       `json:"content"`
+	Author    ndjsonAuthor `json:"author"`
+	AuthorBio *string      `json:"author_bio"`
+	Source    *string      `json:"source"`
+	Tags      ndjsonTags   `json:"tags"`
+
+	// read-only fields of an export, ignored
+	ID        json.RawMessage `json:"id"`
+	AuthorID  json.RawMessage `json:"author_id"`
+	CreatedAt json.RawMessage `json:"created_at"`
+	UpdatedAt json.RawMessage `json:"updated_at"`
+	Version   json.RawMessage `json:"version"`
+}
+
+// ndjsonAuthor accepts the name of an author or an exported author object, of which
+// only the name and bio are read
+type ndjsonAuthor struct {
+	Name string  `json:"name"`
+	Bio  *string `json:"bio"`
+}
+
+// UnmarshalJSON decodes an author from a name or an object
+func (a *ndjsonAuthor) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Name); err == nil {
+		return nil
+	}
+
+	type author ndjsonAuthor
+	if err := json.Unmarshal(data, (*author)(a)); err != nil {
+		return errors.New("author must be a string or an object with a name")
+	}
+	return nil
+}
+
+// ndjsonTags accepts a list of tags or a string of tags separated by commas, semicolons or pipes
+type ndjsonTags []string
+
+// UnmarshalJSON decodes tags from a list or a string
+func (t *ndjsonTags) UnmarshalJSON(data []byte) error {
+	var cell string
+	if err := json.Unmarshal(data, &cell); err == nil {
+		*t = splitTags(cell)
+		return nil
+	}
+
+	var tags []string
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return errors.New("tags must be a list of strings or a string")
+	}
+	*t = tags
+	return nil
+}
+
+// NewNDJSONReader creates a reader for a JSON Lines file
+func NewNDJSONReader(r io.Reader) *NDJSONReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLineBytes)
+	return &NDJSONReader{scanner: scanner}
+}
+
+// Read reads the next record
+func (r *NDJSONReader) Read() (*Record, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.DisallowUnknownFields()
+
+		var rec ndjsonRecord
+		if err := dec.Decode(&rec); err != nil {
+			return nil, &RowError{Row: r.line, Message: decodeMessage(err)}
+		}
+		if dec.More() {
+			return nil, &RowError{Row: r.line, Message: "line must hold a single JSON object"}
+		}
+
+		if rec.AuthorBio == nil {
+			rec.AuthorBio = rec.Author.Bio
+		}
+		return &Record{
+			Row:       r.line,
+			Content:   rec.Content,
+			Author:    rec.Author.Name,
+			AuthorBio: rec.AuthorBio,
+			Source:    rec.Source,
+			Tags:      rec.Tags,
+		}, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, formatError("file", fmt.Sprintf("line %d is longer than %d bytes", r.line+1, MaxLineBytes))
+		}
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// decodeMessage describes why a line could not be decoded
+func decodeMessage(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("line contains badly-formed JSON at offset %d", syntaxErr.Offset)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "line contains badly-formed JSON"
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Sprintf("line must be a JSON object, got %s", typeErr.Value)
+		}
+		return fmt.Sprintf("%s must be of type %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return fmt.Sprintf("%s is not a known field", strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`))
+	default:
+		return err.Error()
+	}
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"os"
 	"time"
 
@@ -8,8 +9,13 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-// Setup configures the global logger
+// Setup configures the global logger to write to standard output
 func Setup(level string, jsonOutput bool) {
+	SetupOutput(os.Stdout, level, jsonOutput)
+}
+
+// SetupOutput configures the global logger to write to out
+func SetupOutput(out io.Writer, level string, jsonOutput bool) {
 	// Set the global log level
 	logLevel, err := zerolog.ParseLevel(level)
 	if err != nil {
@@ -19,10 +25,10 @@ func Setup(level string, jsonOutput bool) {
 
 	// Configure output format
 	if jsonOutput {
-		log.Logger = log.Output(os.Stdout)
+		log.Logger = log.Output(out)
 	} else {
 		log.Logger = log.Output(zerolog.ConsoleWriter{
-			Out:        os.Stdout,
+			Out:        out,
 			TimeFormat: time.RFC3339,
 		})
 	}
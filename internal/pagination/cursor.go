@@ -0,0 +1,116 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// Lists a cursor can be used with; a cursor issued for one list is rejected by the others
+const (
+	KindAuthors      = "authors"
+	KindAuthorSearch = "author_search"
+	KindQuotes       = "quotes"
+	KindQuoteSearch  = "quote_search"
+)
+
+// ErrInvalidCursor is returned when a cursor is malformed, was tampered with or belongs to another list, sort or scope
+var ErrInvalidCursor = errors.New("cursor is invalid")
+
+// signatureSize is the number of HMAC-SHA256 bytes kept in a cursor
+const signatureSize = 16
+
+// scopeHashSize is the number of SHA-256 bytes of the scope kept in a cursor
+const scopeHashSize = 12
+
+// payload is the signed content of a cursor
+type payload struct {
+	Kind     string   `json:"k"`
+	Sort     string   `json:"s"`
+	Scope    string   `json:"q"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// Codec encodes keyset positions into opaque cursors signed with HMAC-SHA256
+type Codec struct {
+	key []byte
+}
+
+// NewCodec creates a codec signing cursors with the given secret. An empty secret is
+// replaced by a random key, making cursors valid only for the lifetime of the process.
+func NewCodec(secret []byte) *Codec {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic("pagination: failed to generate cursor key: " + err.Error())
+		}
+	}
+	return &Codec{key: secret}
+}
+
+// Encode returns the cursor of a position in the given list sorted by sort.
+// scope identifies the items the list walks through, such as its search query
+// and filters: the cursor is only valid for lists with the same scope.
+func (c *Codec) Encode(kind string, sort repository.Sort, scope string, cursor repository.Cursor) string {
+	data, err := json.Marshal(payload{
+		Kind:     kind,
+		Sort:     sort.String(),
+		Scope:    hashScope(scope),
+		Values:   cursor.Values,
+		Backward: cursor.Backward,
+	})
+	if err != nil {
+		panic("pagination: failed to encode cursor: " + err.Error())
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(c.sign(data))
+}
+
+// Decode verifies a cursor issued for the given list sorted by sort with the given scope
+// and returns its position
+func (c *Codec) Decode(kind string, sort repository.Sort, scope string, token string) (*repository.Cursor, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, c.sign(data)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var p payload
+	if err := json.Unmarshal(data, &p); err != nil || p.Kind != kind || p.Sort != sort.String() || p.Scope != hashScope(scope) {
+		return nil, ErrInvalidCursor
+	}
+
+	return &repository.Cursor{
+		Values:   p.Values,
+		Backward: p.Backward,
+	}, nil
+}
+
+// sign computes the truncated signature of a cursor payload
+func (c *Codec) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(data)
+	return mac.Sum(nil)[:signatureSize]
+}
+
+// hashScope digests a scope, which may be long, into the short value stored in cursors.
+// The payload is signed, so the digest need not be keyed.
+func hashScope(scope string) string {
+	sum := sha256.Sum256([]byte(scope))
+	return base64.RawURLEncoding.EncodeToString(sum[:scopeHashSize])
+}
@@ -0,0 +1,118 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+var (
+	byName    = repository.Sort{{Field: repository.SortName}, {Field: repository.SortID}}
+	byNewest  = repository.Sort{{Field: repository.SortCreatedAt, Desc: true}, {Field: repository.SortID, Desc: true}}
+	testCodec = NewCodec([]byte("test secret"))
+)
+
+const testScope = "/api/v1/quotes/search?q=love&tag=life"
+
+func TestCodecRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		kind   string
+		sort   repository.Sort
+		cursor repository.Cursor
+	}{
+		{"forward", KindAuthors, byName, repository.Cursor{Values: []string{"Mark Twain", "3"}}},
+		{"backward", KindQuotes, byNewest, repository.Cursor{Values: []string{"2024-05-01T10:00:00.123456Z", "42"}, Backward: true}},
+		{"non-ascii values", KindAuthorSearch, byName, repository.Cursor{Values: []string{"Jorge Luis Borges · «Ficciones»", "7"}}},
+		{"no values", KindQuoteSearch, byName, repository.Cursor{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := testCodec.Encode(tt.kind, tt.sort, testScope, tt.cursor)
+			got, err := testCodec.Decode(tt.kind, tt.sort, testScope, token)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Values, tt.cursor.Values) || got.Backward != tt.cursor.Backward {
+				t.Errorf("Decode() = %+v, want %+v", *got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestCodecRejectsInvalidCursors(t *testing.T) {
+	cursor := repository.Cursor{Values: []string{"Mark Twain", "3"}}
+	token := testCodec.Encode(KindAuthors, byName, testScope, cursor)
+	encoded, signature, _ := strings.Cut(token, ".")
+
+	// a payload re-encoded with other values but signed by another key
+	forged := NewCodec([]byte("other secret")).Encode(KindAuthors, byName, testScope, repository.Cursor{Values: []string{"Albert Einstein", "1"}})
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	tests := []struct {
+		name  string
+		kind  string
+		sort  repository.Sort
+		scope string
+		token string
+	}{
+		{"empty", KindAuthors, byName, testScope, ""},
+		{"no signature", KindAuthors, byName, testScope, encoded},
+		{"empty signature", KindAuthors, byName, testScope, encoded + "."},
+		{"truncated signature", KindAuthors, byName, testScope, token[:len(token)-4]},
+		{"truncated payload", KindAuthors, byName, testScope, encoded[:len(encoded)-4] + "." + signature},
+		{"tampered payload", KindAuthors, byName, testScope, forgedPayload + "." + signature},
+		{"tampered signature", KindAuthors, byName, testScope, encoded + "." + flipFirst(signature)},
+		{"signed by another key", KindAuthors, byName, testScope, forged},
+		{"not base64", KindAuthors, byName, testScope, "!!!." + signature},
+		{"wrong kind", KindQuotes, byName, testScope, token},
+		{"wrong sort", KindAuthors, repository.Sort{{Field: repository.SortName, Desc: true}, {Field: repository.SortID, Desc: true}}, testScope, token},
+		{"other search query", KindAuthors, byName, "/api/v1/quotes/search?q=hate&tag=life", token},
+		{"other filter", KindAuthors, byName, "/api/v1/quotes/search?q=love", token},
+		{"sort without tie-breaker", KindAuthors, repository.Sort{{Field: repository.SortName}}, testScope, token},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testCodec.Decode(tt.kind, tt.sort, tt.scope, tt.token)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Decode() = %+v, %v, want ErrInvalidCursor", got, err)
+			}
+		})
+	}
+}
+
+func TestCodecRejectsSignedNonJSONPayload(t *testing.T) {
+	// a payload that is not JSON but carries a valid signature is still rejected
+	data := []byte("not json")
+	token := base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(testCodec.sign(data))
+	if _, err := testCodec.Decode(KindAuthors, byName, testScope, token); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Decode() error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestNewCodecWithoutSecret(t *testing.T) {
+	cursor := repository.Cursor{Values: []string{"Mark Twain", "3"}}
+	a, b := NewCodec(nil), NewCodec(nil)
+
+	token := a.Encode(KindAuthors, byName, testScope, cursor)
+	if _, err := a.Decode(KindAuthors, byName, testScope, token); err != nil {
+		t.Fatalf("Decode() with the issuing codec error = %v", err)
+	}
+	if _, err := b.Decode(KindAuthors, byName, testScope, token); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Decode() with another random key error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+// flipFirst changes the first character of a base64 string to another valid one
+func flipFirst(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
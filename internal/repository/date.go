@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PartialDate is a historical date known to the year, the month or the day, possibly
+// only approximately. Years before the common era are negative and there is no year 0.
+// It is written as 1835-11-30, 1835-11 or 1835, followed by BC before the common era
+// and preceded by "c." when approximate, such as "c. 500 BC".
+type PartialDate struct {
+	Year int32
+
+	// Month and Day are 0 when they are not known
+	Month int32
+	Day   int32
+
+	Circa bool
+}
+
+// maxYear is the largest year of a partial date, in either era
+const maxYear = 9999
+
+// circaPrefixes are the accepted spellings of an approximate date, longest first
+var circaPrefixes = []string{"circa ", "ca. ", "ca ", "c. ", "c.", "c "}
+
+// ParsePartialDate parses a partial date in the format of PartialDate.String. Eras may
+// also be written BCE, AD or CE and approximations circa or ca.
+func ParsePartialDate(s string) (PartialDate, error) {
+	invalid := fmt.Errorf(`invalid date %q: use YYYY-MM-DD, YYYY-MM or YYYY, followed by BC before the common era and preceded by "c." when approximate`, s)
+
+	var d PartialDate
+	rest := strings.TrimSpace(s)
+	lower := strings.ToLower(rest)
+	for _, prefix := range circaPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			d.Circa = true
+			rest = strings.TrimSpace(rest[len(prefix):])
+			lower = strings.ToLower(rest)
+			break
+		}
+	}
+
+	bc := false
+	for _, era := range []string{"bce", "bc", "ad", "ce"} {
+		if strings.HasSuffix(lower, " "+era) {
+			bc = strings.HasPrefix(era, "b")
+			rest = strings.TrimSpace(rest[:len(rest)-len(era)])
+			break
+		}
+	}
+
+	parts := strings.Split(rest, "-")
+	if len(parts) > 3 {
+		return PartialDate{}, invalid
+	}
+	values := make([]int32, len(parts))
+	for i, part := range parts {
+		if part == "" || len(part) > 4 || (i > 0 && len(part) != 2) {
+			return PartialDate{}, invalid
+		}
+		value, err := strconv.ParseInt(part, 10, 32)
+		if err != nil {
+			return PartialDate{}, invalid
+		}
+		// 0 stands for an unknown month or day, which is left out instead
+		if i > 0 && value == 0 {
+			return PartialDate{}, invalid
+		}
+		values[i] = int32(value)
+	}
+
+	d.Year = values[0]
+	if len(values) > 1 {
+		d.Month = values[1]
+	}
+	if len(values) > 2 {
+		d.Day = values[2]
+	}
+	if bc {
+		d.Year = -d.Year
+	}
+
+	if err := d.Validate(); err != nil {
+		return PartialDate{}, fmt.Errorf("invalid date %q: %w", s, err)
+	}
+	return d, nil
+}
+
+// Validate checks that the date exists
+func (d PartialDate) Validate() error {
+	switch {
+	case d.Year == 0:
+		return fmt.Errorf("there is no year 0")
+	case d.Year < -maxYear || d.Year > maxYear:
+		return fmt.Errorf("year must be between %d BC and %d", maxYear, maxYear)
+	case d.Month < 0 || d.Month > 12:
+		return fmt.Errorf("month must be between 1 and 12")
+	case d.Day != 0 && d.Month == 0:
+		return fmt.Errorf("a day needs a month")
+	case d.Day < 0 || d.Day > d.daysInMonth():
+		return fmt.Errorf("day must be between 1 and %d", d.daysInMonth())
+	}
+	return nil
+}
+
+// daysInMonth returns the number of days of the month of the date, in the proleptic
+// Gregorian calendar
+func (d PartialDate) daysInMonth() int32 {
+	if d.Month == 0 {
+		return 0
+	}
+	// time counts years astronomically, 1 BC being year 0
+	year := int(d.Year)
+	if year < 0 {
+		year++
+	}
+	return int32(time.Date(year, time.Month(d.Month)+1, 0, 0, 0, 0, 0, time.UTC).Day())
+}
+
+// String formats the date, such as 1835-11-30, 1835-11, 1835 or c. 500 BC
+func (d PartialDate) String() string {
+	var b strings.Builder
+	if d.Circa {
+		b.WriteString("c. ")
+	}
+
+	year := d.Year
+	if year < 0 {
+		year = -year
+	}
+	b.WriteString(strconv.Itoa(int(year)))
+	if d.Month != 0 {
+		fmt.Fprintf(&b, "-%02d", d.Month)
+	}
+	if d.Day != 0 {
+		fmt.Fprintf(&b, "-%02d", d.Day)
+	}
+
+	if d.Year < 0 {
+		b.WriteString(" BC")
+	}
+	return b.String()
+}
+
+// MarshalText formats the date with String
+func (d PartialDate) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses the date with ParsePartialDate
+func (d *PartialDate) UnmarshalText(text []byte) error {
+	parsed, err := ParsePartialDate(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import "testing"
+
+func TestParsePartialDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want PartialDate
+	}{
+		{"1835-11-30", PartialDate{Year: 1835, Month: 11, Day: 30}},
+		{"1835-11", PartialDate{Year: 1835, Month: 11}},
+		{"1835", PartialDate{Year: 1835}},
+		{" 1835 ", PartialDate{Year: 1835}},
+		{"44 BC", PartialDate{Year: -44}},
+		{"44 bce", PartialDate{Year: -44}},
+		{"0044-03-15 BC", PartialDate{Year: -44, Month: 3, Day: 15}},
+		{"1 BC", PartialDate{Year: -1}},
+		{"1066 AD", PartialDate{Year: 1066}},
+		{"1066 CE", PartialDate{Year: 1066}},
+		{"c. 500 BC", PartialDate{Year: -500, Circa: true}},
+		{"c.500 BC", PartialDate{Year: -500, Circa: true}},
+		{"circa 1450", PartialDate{Year: 1450, Circa: true}},
+		{"ca. 1450-06", PartialDate{Year: 1450, Month: 6, Circa: true}},
+		{"Circa 1450", PartialDate{Year: 1450, Circa: true}},
+		{"9999", PartialDate{Year: 9999}},
+		{"9999 BC", PartialDate{Year: -9999}},
+		// leap days follow the proleptic Gregorian calendar, in which 1 BC and 5 BC are leap years
+		{"2000-02-29", PartialDate{Year: 2000, Month: 2, Day: 29}},
+		{"1-02-29 BC", PartialDate{Year: -1, Month: 2, Day: 29}},
+		{"5-02-29 BC", PartialDate{Year: -5, Month: 2, Day: 29}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePartialDate(tt.in)
+			if err != nil {
+				t.Fatalf("ParsePartialDate(%q) error = %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePartialDate(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePartialDateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"empty", ""},
+		{"year 0", "0"},
+		{"year 0 BC", "0 BC"},
+		{"negative year", "-44"},
+		{"five digit year", "10000"},
+		{"month 0", "1835-00"},
+		{"month 13", "1835-13"},
+		{"single digit month", "1835-1"},
+		{"day 0", "1835-11-00"},
+		{"day past the month", "1835-11-31"},
+		{"leap day of a common year", "1900-02-29"},
+		{"leap day of a common BC year", "4-02-29 BC"},
+		{"day past the month in BC", "0044-04-31 BC"},
+		{"too many parts", "1835-11-30-01"},
+		{"not a number", "eighteen"},
+		{"era without a space", "44BC"},
+		{"unknown era", "44 AH"},
+		{"circa alone", "c."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ParsePartialDate(tt.in); err == nil {
+				t.Errorf("ParsePartialDate(%q) = %+v, want an error", tt.in, got)
+			}
+		})
+	}
+}
+
+func TestPartialDateString(t *testing.T) {
+	tests := []struct {
+		date PartialDate
+		want string
+	}{
+		{PartialDate{Year: 1835, Month: 11, Day: 30}, "1835-11-30"},
+		{PartialDate{Year: 1835, Month: 11}, "1835-11"},
+		{PartialDate{Year: 1835}, "1835"},
+		{PartialDate{Year: 800, Month: 12, Day: 25}, "800-12-25"},
+		{PartialDate{Year: -44, Month: 3, Day: 15}, "44-03-15 BC"},
+		{PartialDate{Year: -500, Circa: true}, "c. 500 BC"},
+		{PartialDate{Year: 1450, Month: 6, Circa: true}, "c. 1450-06"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.date.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+
+			// every formatted date parses back to itself
+			parsed, err := ParsePartialDate(tt.want)
+			if err != nil {
+				t.Fatalf("ParsePartialDate(%q) error = %v", tt.want, err)
+			}
+			if parsed != tt.date {
+				t.Errorf("ParsePartialDate(%q) = %+v, want %+v", tt.want, parsed, tt.date)
+			}
+		})
+	}
+}
+
+func TestPartialDateText(t *testing.T) {
+	var d PartialDate
+	if err := d.UnmarshalText([]byte("c. 4 BC")); err != nil {
+		t.Fatalf("UnmarshalText() error = %v", err)
+	}
+	text, err := d.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() error = %v", err)
+	}
+	if string(text) != "c. 4 BC" {
+		t.Errorf("MarshalText() = %q, want %q", text, "c. 4 BC")
+	}
+
+	if err := d.UnmarshalText([]byte("0 BC")); err == nil {
+		t.Error("UnmarshalText() with year 0 error = nil, want an error")
+	}
+}
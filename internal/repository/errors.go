@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Resource names used when reporting domain errors
+const (
+	ResourceAuthor = "author"
+	ResourceQuote  = "quote"
+	ResourceTag    = "tag"
+)
+
+// Sentinel errors describing the kind of a failure independently of the storage backend
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrConstraint = errors.New("constraint violation")
+	ErrValidation = errors.New("validation failed")
+
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// FieldError describes why a single field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error tied to a resource. Kind is one of the sentinel
+// errors above, so callers can match it with errors.Is
+type Error struct {
+	Kind     error
+	Resource string
+	Message  string
+	Fields   []FieldError
+	Err      error
+}
+
+// Error returns the client-facing message of the error
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Resource != "" {
+		return fmt.Sprintf("%s %s", e.Resource, e.Kind)
+	}
+	return e.Kind.Error()
+}
+
+// Unwrap exposes both the kind and the underlying cause to errors.Is and errors.As
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// FieldErrors returns the fields the error refers to, if any
+func (e *Error) FieldErrors() []FieldError {
+	return e.Fields
+}
+
+// NewError creates a domain error of the given kind for a resource
+func NewError(kind error, resource, message string, cause error) *Error {
+	return &Error{
+		Kind:     kind,
+		Resource: resource,
+		Message:  message,
+		Err:      cause,
+	}
+}
+
+// NotFound creates an ErrNotFound error for the resource with the given ID
+func NotFound(resource string, id int64) *Error {
+	return NewError(ErrNotFound, resource, fmt.Sprintf("%s %d not found", resource, id), nil)
+}
+
+// PreconditionFailed creates an ErrPreconditionFailed error for a resource modified concurrently
+func PreconditionFailed(resource string, id int64, currentVersion int64) *Error {
+	return NewError(ErrPreconditionFailed, resource,
+		fmt.Sprintf("%s %d has been modified, current version is %d", resource, id, currentVersion), nil)
+}
+
+// BatchError reports the operation of a batch that failed, by its position in the batch
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+// Error returns the message of the failed operation, prefixed with its position
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+// Unwrap exposes the error of the failed operation
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Optional is a field of a partial update that distinguishes a value absent
+// from the request (Set is false) from an explicit null (Set is true, Value is nil)
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// IsSet reports whether the field was present in the request
+func (o Optional[T]) IsSet() bool {
+	return o.Set
+}
+
+// IsNull reports whether the field was explicitly set to null
+func (o Optional[T]) IsNull() bool {
+	return o.Set && o.Value == nil
+}
+
+// UnmarshalJSON marks the field as present and decodes its value.
+// It is only called by encoding/json when the field appears in the document.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Value = nil
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	o.Value = &value
+	return nil
+}
+
+// MarshalJSON encodes the value, or null when it is absent or null
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.Value)
+}
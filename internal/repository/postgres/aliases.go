@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// Aliases are kept in authors.aliases and indexed in author_aliases by the
+// refresh_authors_aliases trigger, whose unique key rejects a name another author
+// already goes by. Aliases are matched ignoring case, accents and spacing.
+
+// AddAlias adds an alias to an author, optionally only if its version matches
+// params.ExpectedVersion
+func (r *authorRepository) AddAlias(ctx context.Context, id int64, params repository.AddAliasParams) (*repository.Author, error) {
+	author, err := r.queries.AddAuthorAlias(ctx, AddAuthorAliasParams{
+		Alias:           params.Name,
+		ID:              id,
+		ExpectedVersion: params.ExpectedVersion,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.aliasMismatch(ctx, id, params.ExpectedVersion,
+				repository.NewError(repository.ErrConflict, repository.ResourceAuthor,
+					fmt.Sprintf("author %d is already known as %q", id, params.Name), nil))
+		}
+		return nil, translateError(err, repository.ResourceAuthor, id, "add author alias")
+	}
+
+	return toAuthor(author)
+}
+
+// RemoveAlias removes an alias from an author, optionally only if its version
+// matches expectedVersion
+func (r *authorRepository) RemoveAlias(ctx context.Context, id int64, alias string, expectedVersion *int64) error {
+	rows, err := r.queries.RemoveAuthorAlias(ctx, RemoveAuthorAliasParams{
+		Alias:           alias,
+		ID:              id,
+		ExpectedVersion: expectedVersion,
+	})
+	if err != nil {
+		return translateError(err, repository.ResourceAuthor, id, "remove author alias")
+	}
+	if rows == 0 {
+		return r.aliasMismatch(ctx, id, expectedVersion,
+			repository.NewError(repository.ErrNotFound, repository.ResourceAuthor,
+				fmt.Sprintf("author %d has no alias %q", id, alias), nil))
+	}
+	return nil
+}
+
+// aliasMismatch explains why an alias write matched no row: the author does not exist,
+// was modified since the expected version, or the alias is the reason given by err
+func (r *authorRepository) aliasMismatch(ctx context.Context, id int64, expectedVersion *int64, err error) error {
+	author, getErr := r.queries.GetAuthor(ctx, id)
+	if getErr != nil {
+		return translateError(getErr, repository.ResourceAuthor, id, "get author")
+	}
+	if expectedVersion != nil && author.Version != *expectedVersion {
+		return repository.PreconditionFailed(repository.ResourceAuthor, id, author.Version)
+	}
+	return err
+}
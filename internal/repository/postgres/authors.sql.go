@@ -8,35 +8,110 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
 )
 
-const countAuthors = `-- name: CountAuthors :one
-SELECT COUNT(*) FROM authors
+const addAuthorAlias = `-- name: AddAuthorAlias :one
+UPDATE authors
+SET aliases = array_append(aliases, $1::text)
+WHERE id = $2
+  AND NOT EXISTS (
+    SELECT 1 FROM author_aliases
+    WHERE author_id = $2 AND normalized_name = author_name_key($1::text)
+  )
+  AND ($3::bigint IS NULL OR version = $3)
+RETURNING id, name, bio, created_at, updated_at, version, birth_year, birth_month, birth_day, birth_circa, death_year, death_month, death_day, death_circa, nationality, occupations, wikidata_id, external_ids, aliases, portrait_url
 `
 
-func (q *Queries) CountAuthors(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countAuthors)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+type AddAuthorAliasParams struct {
+	Alias           string        `json:"alias"`
+	ID              int64         `json:"id"`
+	ExpectedVersion sql.NullInt64 `json:"expected_version"`
+}
+
+func (q *Queries) AddAuthorAlias(ctx context.Context, arg AddAuthorAliasParams) (Author, error) {
+	row := q.db.QueryRowContext(ctx, addAuthorAlias, arg.Alias, arg.ID, arg.ExpectedVersion)
+	var i Author
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Bio,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.BirthYear,
+		&i.BirthMonth,
+		&i.BirthDay,
+		&i.BirthCirca,
+		&i.DeathYear,
+		&i.DeathMonth,
+		&i.DeathDay,
+		&i.DeathCirca,
+		&i.Nationality,
+		pq.Array(&i.Occupations),
+		&i.WikidataID,
+		&i.ExternalIds,
+		pq.Array(&i.Aliases),
+		&i.PortraitUrl,
+	)
+	return i, err
 }
 
 const createAuthor = `-- name: CreateAuthor :one
 INSERT INTO authors (
-    name, bio
+    name, bio,
+    birth_year, birth_month, birth_day, birth_circa,
+    death_year, death_month, death_day, death_circa,
+    nationality, occupations, wikidata_id, external_ids, aliases, portrait_url
 ) VALUES (
-    $1, $2
+    $1, $2,
+    $3, $4, $5, $6,
+    $7, $8, $9, $10,
+    $11, $12, $13, $14, $15, $16
 )
-RETURNING id, name, bio, created_at, updated_at
+RETURNING id, name, bio, created_at, updated_at, version, birth_year, birth_month, birth_day, birth_circa, death_year, death_month, death_day, death_circa, nationality, occupations, wikidata_id, external_ids, aliases, portrait_url
 `
 
 type CreateAuthorParams struct {
-	Name string         `json:"name"`
-	Bio  sql.NullString `json:"bio"`
+	Name        string          `json:"name"`
+	Bio         sql.NullString  `json:"bio"`
+	BirthYear   sql.NullInt32   `json:"birth_year"`
+	BirthMonth  sql.NullInt32   `json:"birth_month"`
+	BirthDay    sql.NullInt32   `json:"birth_day"`
+	BirthCirca  bool            `json:"birth_circa"`
+	DeathYear   sql.NullInt32   `json:"death_year"`
+	DeathMonth  sql.NullInt32   `json:"death_month"`
+	DeathDay    sql.NullInt32   `json:"death_day"`
+	DeathCirca  bool            `json:"death_circa"`
+	Nationality sql.NullString  `json:"nationality"`
+	Occupations []string        `json:"occupations"`
+	WikidataID  sql.NullString  `json:"wikidata_id"`
+	ExternalIds json.RawMessage `json:"external_ids"`
+	Aliases     []string        `json:"aliases"`
+	PortraitUrl sql.NullString  `json:"portrait_url"`
 }
 
 func (q *Queries) CreateAuthor(ctx context.Context, arg CreateAuthorParams) (Author, error) {
-	row := q.db.QueryRowContext(ctx, createAuthor, arg.Name, arg.Bio)
+	row := q.db.QueryRowContext(ctx, createAuthor,
+		arg.Name,
+		arg.Bio,
+		arg.BirthYear,
+		arg.BirthMonth,
+		arg.BirthDay,
+		arg.BirthCirca,
+		arg.DeathYear,
+		arg.DeathMonth,
+		arg.DeathDay,
+		arg.DeathCirca,
+		arg.Nationality,
+		pq.Array(arg.Occupations),
+		arg.WikidataID,
+		arg.ExternalIds,
+		pq.Array(arg.Aliases),
+		arg.PortraitUrl,
+	)
 	var i Author
 	err := row.Scan(
 		&i.ID,
@@ -44,22 +119,46 @@ func (q *Queries) CreateAuthor(ctx context.Context, arg CreateAuthorParams) (Aut
 		&i.Bio,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.BirthYear,
+		&i.BirthMonth,
+		&i.BirthDay,
+		&i.BirthCirca,
+		&i.DeathYear,
+		&i.DeathMonth,
+		&i.DeathDay,
+		&i.DeathCirca,
+		&i.Nationality,
+		pq.Array(&i.Occupations),
+		&i.WikidataID,
+		&i.ExternalIds,
+		pq.Array(&i.Aliases),
+		&i.PortraitUrl,
 	)
 	return i, err
 }
 
-const deleteAuthor = `-- name: DeleteAuthor :exec
+const deleteAuthor = `-- name: DeleteAuthor :execrows
 DELETE FROM authors
 WHERE id = $1
+  AND ($2::bigint IS NULL OR version = $2)
 `
 
-func (q *Queries) DeleteAuthor(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteAuthor, id)
-	return err
+type DeleteAuthorParams struct {
+	ID              int64         `json:"id"`
+	ExpectedVersion sql.NullInt64 `json:"expected_version"`
+}
+
+func (q *Queries) DeleteAuthor(ctx context.Context, arg DeleteAuthorParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAuthor, arg.ID, arg.ExpectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getAuthor = `-- name: GetAuthor :one
-SELECT id, name, bio, created_at, updated_at FROM authors
+SELECT id, name, bio, created_at, updated_at, version, birth_year, birth_month, birth_day, birth_circa, death_year, death_month, death_day, death_circa, nationality, occupations, wikidata_id, external_ids, aliases, portrait_url FROM authors
 WHERE id = $1 LIMIT 1
 `
 
@@ -72,78 +171,238 @@ func (q *Queries) GetAuthor(ctx context.Context, id int64) (Author, error) {
 		&i.Bio,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.BirthYear,
+		&i.BirthMonth,
+		&i.BirthDay,
+		&i.BirthCirca,
+		&i.DeathYear,
+		&i.DeathMonth,
+		&i.DeathDay,
+		&i.DeathCirca,
+		&i.Nationality,
+		pq.Array(&i.Occupations),
+		&i.WikidataID,
+		&i.ExternalIds,
+		pq.Array(&i.Aliases),
+		&i.PortraitUrl,
 	)
 	return i, err
 }
 
-const listAuthors = `-- name: ListAuthors :many
-SELECT id, name, bio, created_at, updated_at FROM authors
-ORDER BY name
-LIMIT $1 OFFSET $2
+const getAuthorByName = `-- name: GetAuthorByName :one
+SELECT id, name, bio, created_at, updated_at, version, birth_year, birth_month, birth_day, birth_circa, death_year, death_month, death_day, death_circa, nationality, occupations, wikidata_id, external_ids, aliases, portrait_url FROM authors
+WHERE id = (
+    SELECT author_id FROM author_aliases
+    WHERE normalized_name = author_name_key($1::text)
+)
 `
 
-type ListAuthorsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+func (q *Queries) GetAuthorByName(ctx context.Context, name string) (Author, error) {
+	row := q.db.QueryRowContext(ctx, getAuthorByName, name)
+	var i Author
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Bio,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.BirthYear,
+		&i.BirthMonth,
+		&i.BirthDay,
+		&i.BirthCirca,
+		&i.DeathYear,
+		&i.DeathMonth,
+		&i.DeathDay,
+		&i.DeathCirca,
+		&i.Nationality,
+		pq.Array(&i.Occupations),
+		&i.WikidataID,
+		&i.ExternalIds,
+		pq.Array(&i.Aliases),
+		&i.PortraitUrl,
+	)
+	return i, err
 }
 
-func (q *Queries) ListAuthors(ctx context.Context, arg ListAuthorsParams) ([]Author, error) {
-	rows, err := q.db.QueryContext(ctx, listAuthors, arg.Limit, arg.Offset)
+const patchAuthor = `-- name: PatchAuthor :one
+UPDATE authors
+SET
+    name = COALESCE($1, name),
+    bio = CASE WHEN $2::boolean THEN $3 ELSE bio END,
+    birth_year = CASE WHEN $4::boolean THEN $5 ELSE birth_year END,
+    birth_month = CASE WHEN $4::boolean THEN $6 ELSE birth_month END,
+    birth_day = CASE WHEN $4::boolean THEN $7 ELSE birth_day END,
+    birth_circa = CASE WHEN $4::boolean THEN $8 ELSE birth_circa END,
+    death_year = CASE WHEN $9::boolean THEN $10 ELSE death_year END,
+    death_month = CASE WHEN $9::boolean THEN $11 ELSE death_month END,
+    death_day = CASE WHEN $9::boolean THEN $12 ELSE death_day END,
+    death_circa = CASE WHEN $9::boolean THEN $13 ELSE death_circa END,
+    nationality = CASE WHEN $14::boolean THEN $15 ELSE nationality END,
+    occupations = CASE WHEN $16::boolean THEN $17::text[] ELSE occupations END,
+    wikidata_id = CASE WHEN $18::boolean THEN $19 ELSE wikidata_id END,
+    external_ids = CASE WHEN $20::boolean THEN $21::jsonb ELSE external_ids END,
+    aliases = CASE WHEN $22::boolean THEN $23::text[] ELSE aliases END,
+    portrait_url = CASE WHEN $24::boolean THEN $25 ELSE portrait_url END
+WHERE id = $26
+  AND ($27::bigint IS NULL OR version = $27)
+RETURNING id, name, bio, created_at, updated_at, version, birth_year, birth_month, birth_day, birth_circa, death_year, death_month, death_day, death_circa, nationality, occupations, wikidata_id, external_ids, aliases, portrait_url
+`
+
+type PatchAuthorParams struct {
+	Name            sql.NullString  `json:"name"`
+	SetBio          bool            `json:"set_bio"`
+	Bio             sql.NullString  `json:"bio"`
+	SetBirthDate    bool            `json:"set_birth_date"`
+	BirthYear       sql.NullInt32   `json:"birth_year"`
+	BirthMonth      sql.NullInt32   `json:"birth_month"`
+	BirthDay        sql.NullInt32   `json:"birth_day"`
+	BirthCirca      bool            `json:"birth_circa"`
+	SetDeathDate    bool            `json:"set_death_date"`
+	DeathYear       sql.NullInt32   `json:"death_year"`
+	DeathMonth      sql.NullInt32   `json:"death_month"`
+	DeathDay        sql.NullInt32   `json:"death_day"`
+	DeathCirca      bool            `json:"death_circa"`
+	SetNationality  bool            `json:"set_nationality"`
+	Nationality     sql.NullString  `json:"nationality"`
+	SetOccupations  bool            `json:"set_occupations"`
+	Occupations     []string        `json:"occupations"`
+	SetWikidataID   bool            `json:"set_wikidata_id"`
+	WikidataID      sql.NullString  `json:"wikidata_id"`
+	SetExternalIds  bool            `json:"set_external_ids"`
+	ExternalIds     json.RawMessage `json:"external_ids"`
+	SetAliases      bool            `json:"set_aliases"`
+	Aliases         []string        `json:"aliases"`
+	SetPortraitUrl  bool            `json:"set_portrait_url"`
+	PortraitUrl     sql.NullString  `json:"portrait_url"`
+	ID              int64           `json:"id"`
+	ExpectedVersion sql.NullInt64   `json:"expected_version"`
+}
+
+func (q *Queries) PatchAuthor(ctx context.Context, arg PatchAuthorParams) (Author, error) {
+	row := q.db.QueryRowContext(ctx, patchAuthor,
+		arg.Name,
+		arg.SetBio,
+		arg.Bio,
+		arg.SetBirthDate,
+		arg.BirthYear,
+		arg.BirthMonth,
+		arg.BirthDay,
+		arg.BirthCirca,
+		arg.SetDeathDate,
+		arg.DeathYear,
+		arg.DeathMonth,
+		arg.DeathDay,
+		arg.DeathCirca,
+		arg.SetNationality,
+		arg.Nationality,
+		arg.SetOccupations,
+		pq.Array(arg.Occupations),
+		arg.SetWikidataID,
+		arg.WikidataID,
+		arg.SetExternalIds,
+		arg.ExternalIds,
+		arg.SetAliases,
+		pq.Array(arg.Aliases),
+		arg.SetPortraitUrl,
+		arg.PortraitUrl,
+		arg.ID,
+		arg.ExpectedVersion,
+	)
+	var i Author
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Bio,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.BirthYear,
+		&i.BirthMonth,
+		&i.BirthDay,
+		&i.BirthCirca,
+		&i.DeathYear,
+		&i.DeathMonth,
+		&i.DeathDay,
+		&i.DeathCirca,
+		&i.Nationality,
+		pq.Array(&i.Occupations),
+		&i.WikidataID,
+		&i.ExternalIds,
+		pq.Array(&i.Aliases),
+		&i.PortraitUrl,
+	)
+	return i, err
+}
+
+const removeAuthorAlias = `-- name: RemoveAuthorAlias :execrows
+UPDATE authors
+SET aliases = ARRAY(
+    SELECT a.alias
+    FROM unnest(aliases) WITH ORDINALITY AS a(alias, position)
+    WHERE author_name_key(a.alias) <> author_name_key($1::text)
+    ORDER BY a.position
+)
+WHERE id = $2
+  AND EXISTS (
+    SELECT 1 FROM unnest(aliases) AS alias
+    WHERE author_name_key(alias) = author_name_key($1::text)
+  )
+  AND ($3::bigint IS NULL OR version = $3)
+`
+
+type RemoveAuthorAliasParams struct {
+	Alias           string        `json:"alias"`
+	ID              int64         `json:"id"`
+	ExpectedVersion sql.NullInt64 `json:"expected_version"`
+}
+
+func (q *Queries) RemoveAuthorAlias(ctx context.Context, arg RemoveAuthorAliasParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeAuthorAlias, arg.Alias, arg.ID, arg.ExpectedVersion)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Author{}
-	for rows.Next() {
-		var i Author
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Bio,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const searchAuthorsByName = `-- name: SearchAuthorsByName :many
-SELECT id, name, bio, created_at, updated_at FROM authors
-WHERE name ILIKE '%' || $1 || '%'
-ORDER BY name
-LIMIT $2 OFFSET $3
+const suggestAuthors = `-- name: SuggestAuthors :many
+SELECT
+    id,
+    name,
+    word_similarity(normalize_name($1::text), author_search_text(name, aliases)) as score
+FROM authors
+WHERE author_search_text(name, aliases) LIKE normalize_name($2::text) || '%'
+   OR author_search_text(name, aliases) LIKE '% ' || normalize_name($2::text) || '%'
+ORDER BY score DESC, length(name), name
+LIMIT $3
 `
 
-type SearchAuthorsByNameParams struct {
-	Column1 sql.NullString `json:"column_1"`
-	Limit   int32          `json:"limit"`
-	Offset  int32          `json:"offset"`
+type SuggestAuthorsParams struct {
+	Query   string `json:"query"`
+	Pattern string `json:"pattern"`
+	Limit   int32  `json:"limit"`
 }
 
-func (q *Queries) SearchAuthorsByName(ctx context.Context, arg SearchAuthorsByNameParams) ([]Author, error) {
-	rows, err := q.db.QueryContext(ctx, searchAuthorsByName, arg.Column1, arg.Limit, arg.Offset)
+type SuggestAuthorsRow struct {
+	ID    int64   `json:"id"`
+	Name  string  `json:"name"`
+	Score float32 `json:"score"`
+}
+
+func (q *Queries) SuggestAuthors(ctx context.Context, arg SuggestAuthorsParams) ([]SuggestAuthorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, suggestAuthors, arg.Query, arg.Pattern, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Author{}
+	items := []SuggestAuthorsRow{}
 	for rows.Next() {
-		var i Author
+		var i SuggestAuthorsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Name,
-			&i.Bio,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.Score,
 		); err != nil {
 			return nil, err
 		}
@@ -161,20 +420,69 @@ func (q *Queries) SearchAuthorsByName(ctx context.Context, arg SearchAuthorsByNa
 const updateAuthor = `-- name: UpdateAuthor :one
 UPDATE authors
 SET 
-    name = $2,
-    bio = $3
-WHERE id = $1
-RETURNING id, name, bio, created_at, updated_at
+    name = $1,
+    bio = $2,
+    birth_year = $3,
+    birth_month = $4,
+    birth_day = $5,
+    birth_circa = $6,
+    death_year = $7,
+    death_month = $8,
+    death_day = $9,
+    death_circa = $10,
+    nationality = $11,
+    occupations = $12,
+    wikidata_id = $13,
+    external_ids = $14,
+    aliases = $15,
+    portrait_url = $16
+WHERE id = $17
+  AND ($18::bigint IS NULL OR version = $18)
+RETURNING id, name, bio, created_at, updated_at, version, birth_year, birth_month, birth_day, birth_circa, death_year, death_month, death_day, death_circa, nationality, occupations, wikidata_id, external_ids, aliases, portrait_url
 `
 
 type UpdateAuthorParams struct {
-	ID   int64          `json:"id"`
-	Name string         `json:"name"`
-	Bio  sql.NullString `json:"bio"`
+	Name            string          `json:"name"`
+	Bio             sql.NullString  `json:"bio"`
+	BirthYear       sql.NullInt32   `json:"birth_year"`
+	BirthMonth      sql.NullInt32   `json:"birth_month"`
+	BirthDay        sql.NullInt32   `json:"birth_day"`
+	BirthCirca      bool            `json:"birth_circa"`
+	DeathYear       sql.NullInt32   `json:"death_year"`
+	DeathMonth      sql.NullInt32   `json:"death_month"`
+	DeathDay        sql.NullInt32   `json:"death_day"`
+	DeathCirca      bool            `json:"death_circa"`
+	Nationality     sql.NullString  `json:"nationality"`
+	Occupations     []string        `json:"occupations"`
+	WikidataID      sql.NullString  `json:"wikidata_id"`
+	ExternalIds     json.RawMessage `json:"external_ids"`
+	Aliases         []string        `json:"aliases"`
+	PortraitUrl     sql.NullString  `json:"portrait_url"`
+	ID              int64           `json:"id"`
+	ExpectedVersion sql.NullInt64   `json:"expected_version"`
 }
 
 func (q *Queries) UpdateAuthor(ctx context.Context, arg UpdateAuthorParams) (Author, error) {
-	row := q.db.QueryRowContext(ctx, updateAuthor, arg.ID, arg.Name, arg.Bio)
+	row := q.db.QueryRowContext(ctx, updateAuthor,
+		arg.Name,
+		arg.Bio,
+		arg.BirthYear,
+		arg.BirthMonth,
+		arg.BirthDay,
+		arg.BirthCirca,
+		arg.DeathYear,
+		arg.DeathMonth,
+		arg.DeathDay,
+		arg.DeathCirca,
+		arg.Nationality,
+		pq.Array(arg.Occupations),
+		arg.WikidataID,
+		arg.ExternalIds,
+		pq.Array(arg.Aliases),
+		arg.PortraitUrl,
+		arg.ID,
+		arg.ExpectedVersion,
+	)
 	var i Author
 	err := row.Scan(
 		&i.ID,
@@ -182,6 +490,21 @@ func (q *Queries) UpdateAuthor(ctx context.Context, arg UpdateAuthorParams) (Aut
 		&i.Bio,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.BirthYear,
+		&i.BirthMonth,
+		&i.BirthDay,
+		&i.BirthCirca,
+		&i.DeathYear,
+		&i.DeathMonth,
+		&i.DeathDay,
+		&i.DeathCirca,
+		&i.Nationality,
+		pq.Array(&i.Occupations),
+		&i.WikidataID,
+		&i.ExternalIds,
+		pq.Array(&i.Aliases),
+		&i.PortraitUrl,
 	)
 	return i, err
 }
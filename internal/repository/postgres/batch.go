@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// Bulk inserts queue the generated INSERT statements in a pgx.Batch, which sends them
+// in a single round trip and runs them in an implicit transaction unless the repository
+// is already bound to one. Each statement still returns its row, so callers get the IDs
+// and defaults of every created resource, and the triggers maintaining the tags and
+// search documents fire as for a single insert.
+//
+// CreateEach runs every insert behind a savepoint in one transaction, so that a rejected
+// row is rolled back alone. A failing statement makes the server skip the rest of the
+// batch, so the next round trip rolls back to the savepoint and sends the skipped rows
+// again: every row is inserted once, in one round trip more than there are rejected rows.
+
+// eachSavepoint guards each insert of CreateEach
+const eachSavepoint = "create_each"
+
+// CreateMany creates authors in a single round trip. Either every author is created or
+// none is; the failing author is reported by its position in a *repository.BatchError.
+func (r *authorRepository) CreateMany(ctx context.Context, params []repository.CreateAuthorParams) ([]*repository.Author, error) {
+	batch := &pgx.Batch{}
+	for i, p := range params {
+		profile, err := newProfileArgs(p.AuthorProfile)
+		if err != nil {
+			return nil, &repository.BatchError{Index: i, Err: err}
+		}
+		batch.Queue(createAuthor, p.Name, p.Bio,
+			profile.birth.year, profile.birth.month, profile.birth.day, profile.birth.circa,
+			profile.death.year, profile.death.month, profile.death.day, profile.death.circa,
+			p.Nationality, profile.occupations, p.WikidataID, profile.externalIDs, profile.aliases, p.PortraitURL)
+	}
+
+	results := r.db.SendBatch(ctx, batch)
+	defer results.Close()
+
+	authors := make([]*repository.Author, len(params))
+	for i := range params {
+		a, err := scanAuthor(results.QueryRow())
+		if err != nil {
+			return nil, &repository.BatchError{Index: i, Err: translateError(err, repository.ResourceAuthor, 0, "create author")}
+		}
+		authors[i] = a
+	}
+
+	if err := results.Close(); err != nil {
+		return nil, fmt.Errorf("failed to create authors: %w", err)
+	}
+	return authors, nil
+}
+
+// CreateEach creates authors independently of each other in few round trips. A rejected
+// author is not created and gets its domain error at its position in the returned errors;
+// other errors abort the whole call.
+func (r *authorRepository) CreateEach(ctx context.Context, params []repository.CreateAuthorParams) ([]*repository.Author, []error, error) {
+	args := make([][]any, len(params))
+	errs := make([]error, len(params))
+	for i, p := range params {
+		profile, err := newProfileArgs(p.AuthorProfile)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		args[i] = []any{p.Name, p.Bio,
+			profile.birth.year, profile.birth.month, profile.birth.day, profile.birth.circa,
+			profile.death.year, profile.death.month, profile.death.day, profile.death.circa,
+			p.Nationality, profile.occupations, p.WikidataID, profile.externalIDs, profile.aliases, p.PortraitURL}
+	}
+	return createEach(ctx, r.db, repository.ResourceAuthor, createAuthor, args, errs,
+		func(row pgx.Row) (*repository.Author, error) {
+			return scanAuthor(row)
+		})
+}
+
+// CreateMany creates quotes in a single round trip. Either every quote is created or
+// none is; the failing quote is reported by its position in a *repository.BatchError.
+func (r *quoteRepository) CreateMany(ctx context.Context, params []repository.CreateQuoteParams) ([]*repository.Quote, error) {
+	batch := &pgx.Batch{}
+	for _, p := range params {
+		batch.Queue(createQuote, p.Content, p.AuthorID, p.Source, p.Tags)
+	}
+
+	results := r.db.SendBatch(ctx, batch)
+	defer results.Close()
+
+	quotes := make([]*repository.Quote, len(params))
+	for i := range params {
+		var q repository.Quote
+		err := results.QueryRow().Scan(&q.ID, &q.Content, &q.AuthorID, &q.Source, &q.Tags, &q.CreatedAt, &q.UpdatedAt, &q.Version)
+		if err != nil {
+			return nil, &repository.BatchError{Index: i, Err: translateError(err, repository.ResourceQuote, 0, "create quote")}
+		}
+		quotes[i] = &q
+	}
+
+	if err := results.Close(); err != nil {
+		return nil, fmt.Errorf("failed to create quotes: %w", err)
+	}
+	return quotes, nil
+}
+
+// CreateEach creates quotes independently of each other in few round trips, like the authors' CreateEach
+func (r *quoteRepository) CreateEach(ctx context.Context, params []repository.CreateQuoteParams) ([]*repository.Quote, []error, error) {
+	args := make([][]any, len(params))
+	for i, p := range params {
+		args[i] = []any{p.Content, p.AuthorID, p.Source, p.Tags}
+	}
+	return createEach(ctx, r.db, repository.ResourceQuote, createQuote, args, make([]error, len(params)),
+		func(row pgx.Row) (*repository.Quote, error) {
+			var q repository.Quote
+			err := row.Scan(&q.ID, &q.Content, &q.AuthorID, &q.Source, &q.Tags, &q.CreatedAt, &q.UpdatedAt, &q.Version)
+			return &q, err
+		})
+}
+
+// createEach runs stmt with each of args in a transaction, skipping the positions that
+// already have an error in errs, and scans the returned rows with scan. Inserts rejected
+// with a domain error are rolled back alone and their error is recorded in errs.
+func createEach[T any](ctx context.Context, db conn, resource, stmt string, args [][]any, errs []error, scan func(pgx.Row) (T, error)) ([]T, []error, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	created := make([]T, len(args))
+	for start, aborted := 0, false; start < len(args) || aborted; {
+		batch := &pgx.Batch{}
+		if aborted {
+			batch.Queue("ROLLBACK TO SAVEPOINT " + eachSavepoint)
+			batch.Queue("RELEASE SAVEPOINT " + eachSavepoint)
+		}
+		for i := start; i < len(args); i++ {
+			if errs[i] == nil {
+				batch.Queue("SAVEPOINT " + eachSavepoint)
+				batch.Queue(stmt, args[i]...)
+				batch.Queue("RELEASE SAVEPOINT " + eachSavepoint)
+			}
+		}
+
+		start, aborted, err = readEach(tx.SendBatch(ctx, batch), resource, start, aborted, created, errs, scan)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create %ss: %w", resource, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to create %ss: %w", resource, err)
+	}
+	return created, errs, nil
+}
+
+// readEach reads the results of a batch of createEach from position start, returning the
+// position following a rejected insert, or the end, and whether an insert was rejected
+func readEach[T any](results pgx.BatchResults, resource string, start int, aborted bool, created []T, errs []error, scan func(pgx.Row) (T, error)) (int, bool, error) {
+	// Close reads the results the server skipped after a rejected insert and frees the
+	// connection; its error then repeats the rejection, which is already recorded
+	defer results.Close()
+
+	if aborted {
+		for range 2 {
+			if _, err := results.Exec(); err != nil {
+				return 0, false, err
+			}
+		}
+	}
+
+	for i := start; i < len(created); i++ {
+		if errs[i] != nil {
+			continue
+		}
+		if _, err := results.Exec(); err != nil {
+			return 0, false, err
+		}
+
+		value, err := scan(results.QueryRow())
+		if err != nil {
+			err = translateError(err, resource, 0, "create "+resource)
+			var domainErr *repository.Error
+			if !errors.As(err, &domainErr) {
+				return 0, false, err
+			}
+			errs[i] = err
+			return i + 1, true, nil
+		}
+		created[i] = value
+
+		if _, err := results.Exec(); err != nil {
+			return 0, false, err
+		}
+	}
+
+	return len(created), false, results.Close()
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+func TestCreateEachSkipsRejectedQuotes(t *testing.T) {
+	conn := testConn(t)
+	ctx := context.Background()
+
+	var authorID int64
+	name := fmt.Sprintf("Batch Test Author %d", time.Now().UnixNano())
+	if err := conn.QueryRow(ctx, "INSERT INTO authors (name) VALUES ($1) RETURNING id", name).Scan(&authorID); err != nil {
+		t.Fatalf("failed to create author: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Exec(ctx, "DELETE FROM quotes WHERE author_id = $1", authorID)
+		conn.Exec(ctx, "DELETE FROM authors WHERE id = $1", authorID)
+	})
+
+	// quotes of an author that does not exist are rejected, alone and in a row
+	rejected := map[int]bool{1: true, 3: true, 4: true}
+	params := make([]repository.CreateQuoteParams, 7)
+	for i := range params {
+		params[i] = repository.CreateQuoteParams{Content: fmt.Sprintf("%s quote %d", name, i), AuthorID: authorID}
+		if rejected[i] {
+			params[i].AuthorID = -1
+		}
+	}
+
+	repo := &quoteRepository{db: conn}
+	quotes, errs, err := repo.CreateEach(ctx, params)
+	if err != nil {
+		t.Fatalf("CreateEach() error = %v", err)
+	}
+
+	for i, p := range params {
+		var count int
+		if err := conn.QueryRow(ctx, "SELECT COUNT(*) FROM quotes WHERE content = $1", p.Content).Scan(&count); err != nil {
+			t.Fatalf("failed to count quote %d: %v", i, err)
+		}
+
+		if rejected[i] {
+			if !errors.Is(errs[i], repository.ErrConstraint) {
+				t.Errorf("quote %d error = %v, want %v", i, errs[i], repository.ErrConstraint)
+			}
+			if quotes[i] != nil {
+				t.Errorf("quote %d = %+v, want nil", i, quotes[i])
+			}
+			if count != 0 {
+				t.Errorf("quote %d was created %d times, want 0", i, count)
+			}
+			continue
+		}
+
+		if errs[i] != nil {
+			t.Errorf("quote %d error = %v, want nil", i, errs[i])
+		}
+		if quotes[i] == nil || quotes[i].Content != p.Content {
+			t.Errorf("quote %d = %+v, want content %q", i, quotes[i], p.Content)
+		}
+		if count != 1 {
+			t.Errorf("quote %d was created %d times, want 1", i, count)
+		}
+	}
+}
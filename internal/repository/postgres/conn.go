@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// conn is the database handle the repositories run their queries on:
+// the pool, or a transaction within Repository.WithTx
+type conn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// AfterConnect returns a pgxpool hook applying the session settings the queries rely on.
+// searchThreshold is the minimum word similarity, from 0 to 1, for an author name to match a search.
+func AfterConnect(searchThreshold float64) func(context.Context, *pgx.Conn) error {
+	threshold := strconv.FormatFloat(searchThreshold, 'f', -1, 64)
+	return func(ctx context.Context, conn *pgx.Conn) error {
+		if _, err := conn.Exec(ctx, "SELECT set_config('pg_trgm.word_similarity_threshold', $1, false)", threshold); err != nil {
+			return fmt.Errorf("failed to set author search threshold: %w", err)
+		}
+		return nil
+	}
+}
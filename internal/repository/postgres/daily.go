@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// dailyQuoteRepository implements repository.DailyQuoteRepository
+type dailyQuoteRepository struct {
+	db      conn
+	queries *Queries
+}
+
+// Get retrieves the quote of the day of a date
+func (r *dailyQuoteRepository) Get(ctx context.Context, tag string, day time.Time) (*repository.DailyQuote, error) {
+	row, err := r.queries.GetDailyQuote(ctx, GetDailyQuoteParams{
+		Tag: tag,
+		Day: day,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.NewError(repository.ErrNotFound, repository.ResourceQuote,
+				fmt.Sprintf("no quote of the day for %s", day.Format(repository.DateLayout)), nil)
+		}
+		return nil, fmt.Errorf("failed to get quote of the day: %w", err)
+	}
+
+	author, err := toAuthor(row.Author)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.DailyQuote{
+		Date:      row.Day.Format(repository.DateLayout),
+		Tag:       row.DailyTag,
+		Scheduled: row.Scheduled,
+		Quote: &repository.QuoteWithAuthor{
+			Quote: repository.Quote{
+				ID:        row.ID,
+				Content:   row.Content,
+				AuthorID:  row.AuthorID,
+				Source:    row.Source,
+				Tags:      row.Tags,
+				CreatedAt: row.CreatedAt,
+				UpdatedAt: row.UpdatedAt,
+				Version:   row.Version,
+				Author:    author,
+			},
+			AuthorName: author.Name,
+			AuthorBio:  author.Bio,
+		},
+	}, nil
+}
+
+// Create records the quote of the day of a date, keeping the existing one if another request recorded it first
+func (r *dailyQuoteRepository) Create(ctx context.Context, tag string, day time.Time, quoteID int64) error {
+	err := r.queries.CreateDailyQuote(ctx, CreateDailyQuoteParams{
+		Day:     day,
+		Tag:     tag,
+		QuoteID: quoteID,
+	})
+	if err != nil {
+		return translateError(err, repository.ResourceQuote, quoteID, "create quote of the day")
+	}
+	return nil
+}
+
+// Schedule sets the quote of the day of a date, replacing the quote scheduled for it but
+// never one that was already drawn
+func (r *dailyQuoteRepository) Schedule(ctx context.Context, tag string, day time.Time, quoteID int64) error {
+	rows, err := r.queries.ScheduleDailyQuote(ctx, ScheduleDailyQuoteParams{
+		Day:     day,
+		Tag:     tag,
+		QuoteID: quoteID,
+	})
+	if err != nil {
+		return translateError(err, repository.ResourceQuote, quoteID, "schedule quote of the day")
+	}
+	if rows == 0 {
+		return repository.NewError(repository.ErrConflict, repository.ResourceQuote,
+			fmt.Sprintf("quote of the day for %s was already drawn", day.Format(repository.DateLayout)), nil)
+	}
+	return nil
+}
+
+// Unschedule removes the scheduled quote of the day of a date
+func (r *dailyQuoteRepository) Unschedule(ctx context.Context, tag string, day time.Time) error {
+	rows, err := r.queries.UnscheduleDailyQuote(ctx, UnscheduleDailyQuoteParams{
+		Tag: tag,
+		Day: day,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unschedule quote of the day: %w", err)
+	}
+	if rows == 0 {
+		return repository.NewError(repository.ErrNotFound, repository.ResourceQuote,
+			fmt.Sprintf("no quote scheduled for %s", day.Format(repository.DateLayout)), nil)
+	}
+	return nil
+}
+
+// QuoteIDs returns the IDs of the quotes of the day between two dates, inclusive
+func (r *dailyQuoteRepository) QuoteIDs(ctx context.Context, tag string, from, to time.Time) ([]int64, error) {
+	ids, err := r.queries.ListDailyQuoteIDs(ctx, ListDailyQuoteIDsParams{
+		Tag:  tag,
+		From: from,
+		To:   to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quotes of the day: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PostgreSQL error codes translated into domain errors
+// See https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation          = "23505"
+	pgForeignKeyViolation      = "23503"
+	pgNotNullViolation         = "23502"
+	pgCheckViolation           = "23514"
+	pgStringDataRightTruncated = "22001"
+	pgInvalidTextRepresent     = "22P02"
+)
+
+// authorNameConstraint keeps the names and aliases of authors unique across authors
+const authorNameConstraint = "uq_author_aliases_normalized_name"
+
+// translateError converts pgx and pgconn errors into repository domain errors.
+// Errors that have no domain meaning are wrapped with the given operation description.
+func translateError(err error, resource string, id int64, op string) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.NotFound(resource, id)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return repository.NewError(repository.ErrConflict, resource, conflictMessage(resource, pgErr), err)
+		case pgForeignKeyViolation:
+			// authors are only ever referenced, so a violation means the author still has quotes
+			if resource == repository.ResourceAuthor {
+				return repository.NewError(repository.ErrConflict, resource, "author is still referenced by quotes", err)
+			}
+			return repository.NewError(repository.ErrConstraint, resource, fmt.Sprintf("%s references a resource that does not exist", resource), err)
+		case pgNotNullViolation, pgCheckViolation:
+			return repository.NewError(repository.ErrConstraint, resource, constraintMessage(resource, pgErr), err)
+		case pgStringDataRightTruncated, pgInvalidTextRepresent:
+			return repository.NewError(repository.ErrValidation, resource, pgErr.Message, err)
+		}
+	}
+
+	return fmt.Errorf("failed to %s: %w", op, err)
+}
+
+// conflictMessage describes a unique constraint violation
+func conflictMessage(resource string, pgErr *pgconn.PgError) string {
+	if pgErr.ConstraintName == authorNameConstraint {
+		if name, ok := conflictingKey(pgErr.Detail); ok {
+			return fmt.Sprintf("another author is already known as %q", name)
+		}
+		return "another author is already known by this name"
+	}
+	if pgErr.Detail != "" {
+		return fmt.Sprintf("%s already exists: %s", resource, pgErr.Detail)
+	}
+	return fmt.Sprintf("%s already exists", resource)
+}
+
+// constraintMessage describes a not null or check constraint violation
+func constraintMessage(resource string, pgErr *pgconn.PgError) string {
+	if pgErr.ColumnName != "" {
+		return fmt.Sprintf("invalid value for %s", pgErr.ColumnName)
+	}
+	return fmt.Sprintf("%s violates constraint %s", resource, pgErr.ConstraintName)
+}
+
+// conflictingKey extracts the key value from the detail of a unique constraint
+// violation, which reads "Key (column)=(value) already exists."
+func conflictingKey(detail string) (string, bool) {
+	_, rest, ok := strings.Cut(detail, ")=(")
+	if !ok {
+		return "", false
+	}
+	return strings.CutSuffix(rest, ") already exists.")
+}
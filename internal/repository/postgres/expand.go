@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// Expansions embed related resources in a page of results with one query per
+// expansion rather than one per result.
+
+// quotesByAuthorsSelect takes the newest quotes of each author through the author_id
+// index, the lateral subquery stopping after $2 rows per author
+const quotesByAuthorsSelect = `SELECT q.id, q.content, q.author_id, q.source, q.tags, q.created_at, q.updated_at, q.version
+FROM unnest($1::bigint[]) AS a(id)
+CROSS JOIN LATERAL (
+    SELECT *
+    FROM quotes
+    WHERE author_id = a.id
+    ORDER BY created_at DESC, id DESC
+    LIMIT $2
+) q
+ORDER BY q.author_id, q.created_at DESC, q.id DESC`
+
+// ListByAuthors retrieves the newest quotes of each author, at most perAuthor of them
+func (r *quoteRepository) ListByAuthors(ctx context.Context, authorIDs []int64, perAuthor int32) ([]*repository.Quote, error) {
+	rows, err := r.db.Query(ctx, quotesByAuthorsSelect, authorIDs, perAuthor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quotes by authors: %w", err)
+	}
+
+	quotes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*repository.Quote, error) {
+		var q repository.Quote
+		err := row.Scan(&q.ID, &q.Content, &q.AuthorID, &q.Source, &q.Tags, &q.CreatedAt, &q.UpdatedAt, &q.Version)
+		return &q, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quotes by authors: %w", err)
+	}
+	return quotes, nil
+}
+
+// CountByAuthors counts the quotes of each author. Authors without quotes are left out.
+func (r *quoteRepository) CountByAuthors(ctx context.Context, authorIDs []int64) (map[int64]int64, error) {
+	rows, err := r.db.Query(ctx, `SELECT author_id, COUNT(*)
+FROM quotes
+WHERE author_id = ANY($1::bigint[])
+GROUP BY author_id`, authorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count quotes by authors: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]int64, len(authorIDs))
+	for rows.Next() {
+		var authorID, count int64
+		if err := rows.Scan(&authorID, &count); err != nil {
+			return nil, fmt.Errorf("failed to count quotes by authors: %w", err)
+		}
+		counts[authorID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count quotes by authors: %w", err)
+	}
+	return counts, nil
+}
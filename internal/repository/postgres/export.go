@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+const quoteExportSelect = `SELECT q.id, q.content, q.author_id, q.source, q.tags, q.created_at, q.updated_at, q.version
+FROM quotes q`
+
+var quoteExportWithAuthorsSelect = `SELECT q.id, q.content, q.author_id, q.source, q.tags, q.created_at, q.updated_at, q.version,
+    ` + authorColumns("a") + `
+FROM quotes q
+JOIN authors a ON q.author_id = a.id`
+
+// Export calls fn with every quote matching a filter in ID order, with its author when
+// withAuthors is set. Rows are handed to fn as they arrive from the database, so the whole
+// result is never held in memory; an error returned by fn stops the export and is returned.
+func (r *quoteRepository) Export(ctx context.Context, filter repository.QuoteFilter, withAuthors bool, fn func(*repository.Quote) error) error {
+	var q listQuery
+	q.filterQuotes(filter, false)
+
+	base := quoteExportSelect
+	if withAuthors {
+		base = quoteExportWithAuthorsSelect
+	}
+
+	rows, err := r.db.Query(ctx, q.sql(base)+"\nORDER BY q.id", q.args...)
+	if err != nil {
+		return fmt.Errorf("failed to export quotes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var quote repository.Quote
+		var author Author
+		dest := []interface{}{&quote.ID, &quote.Content, &quote.AuthorID, &quote.Source, &quote.Tags,
+			&quote.CreatedAt, &quote.UpdatedAt, &quote.Version}
+		if withAuthors {
+			dest = append(dest, authorDest(&author)...)
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to export quotes: %w", err)
+		}
+		if withAuthors {
+			var err error
+			if quote.Author, err = toAuthor(author); err != nil {
+				return err
+			}
+		}
+		if err := fn(&quote); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to export quotes: %w", err)
+	}
+	return nil
+}
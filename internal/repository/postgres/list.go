@@ -0,0 +1,440 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// Listings are built by hand rather than generated by sqlc: filters are optional and
+// composable, sorts are chosen by clients and keyset pagination flips the sort
+// direction when paging backward.
+// User input only ever reaches these queries as bound arguments.
+
+var authorListSelect = `SELECT ` + authorColumns("") + `
+FROM authors`
+
+// authorSearchScore is the word similarity between the search query, bound to $1, and
+// the name or aliases of an author
+const authorSearchScore = "word_similarity(normalize_name($1::text), author_search_text(name, aliases))"
+
+var authorSearchSelect = `SELECT ` + authorColumns("") + `,
+    ` + authorSearchScore + `
+FROM authors`
+
+const authorCountSelect = `SELECT COUNT(*)
+FROM authors`
+
+var quoteListSelect = `SELECT q.id, q.content, q.author_id, q.source, q.tags, q.created_at, q.updated_at, q.version,
+    ` + authorColumns("a") + `
+FROM quotes q
+JOIN authors a ON q.author_id = a.id`
+
+var quoteSearchSelect = `SELECT q.id, q.content, q.author_id, q.source, q.tags, q.created_at, q.updated_at, q.version,
+    ` + authorColumns("a") + `,
+    ts_rank_cd(d.document, tsq),
+    ts_headline('english', q.content, tsq, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2')
+FROM quotes q
+JOIN authors a ON q.author_id = a.id
+JOIN quote_documents d ON d.quote_id = q.id
+CROSS JOIN websearch_to_tsquery('english', $1::text) tsq`
+
+const quoteCountSelect = `SELECT COUNT(*)
+FROM quotes q`
+
+// sortColumn is the expression a sort field orders by and the type its cursor values are cast to
+type sortColumn struct {
+	expr string
+	typ  string
+}
+
+var authorSortColumns = map[string]sortColumn{
+	repository.SortName:      {"name", "text"},
+	repository.SortCreatedAt: {"created_at", "timestamptz"},
+	repository.SortUpdatedAt: {"updated_at", "timestamptz"},
+	repository.SortID:        {"id", "bigint"},
+}
+
+var authorSearchSortColumns = withSortColumn(authorSortColumns, repository.SortScore,
+	sortColumn{authorSearchScore, "real"})
+
+var quoteSortColumns = map[string]sortColumn{
+	repository.SortCreatedAt:  {"q.created_at", "timestamptz"},
+	repository.SortUpdatedAt:  {"q.updated_at", "timestamptz"},
+	repository.SortAuthorName: {"a.name", "text"},
+	repository.SortLength:     {"char_length(q.content)", "integer"},
+	repository.SortID:         {"q.id", "bigint"},
+}
+
+// the search statement binds the query to tsq
+var quoteSearchSortColumns = withSortColumn(quoteSortColumns, repository.SortRank,
+	sortColumn{"ts_rank_cd(d.document, tsq)", "real"})
+
+// withSortColumn returns a copy of columns with another sort field
+func withSortColumn(columns map[string]sortColumn, field string, column sortColumn) map[string]sortColumn {
+	extended := make(map[string]sortColumn, len(columns)+1)
+	for f, c := range columns {
+		extended[f] = c
+	}
+	extended[field] = column
+	return extended
+}
+
+// listQuery accumulates the conditions and bound arguments of a listing query
+type listQuery struct {
+	conds []string
+	args  []interface{}
+}
+
+// arg binds a value and returns its placeholder
+func (q *listQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// where adds a condition
+func (q *listQuery) where(cond string) {
+	q.conds = append(q.conds, cond)
+}
+
+// sql appends the conditions to a SELECT statement
+func (q *listQuery) sql(base string) string {
+	if len(q.conds) == 0 {
+		return base
+	}
+	return base + "\nWHERE " + strings.Join(q.conds, "\n  AND ")
+}
+
+// page returns the statement selecting a page of rows from base, ordered by params.Sort
+// (defaultSort when empty) with the ID as tie-breaker. With a cursor the page starts next
+// to it and paging backward flips the order so the rows closest to the cursor come first;
+// otherwise params.Offset rows are skipped.
+func (q *listQuery) page(base string, columns map[string]sortColumn, defaultSort repository.Sort, params repository.ListParams) (string, error) {
+	sort := params.Sort
+	if len(sort) == 0 {
+		sort = defaultSort
+	}
+	sort = sort.WithTieBreaker()
+
+	if params.Cursor != nil && len(params.Cursor.Values) != len(sort) {
+		return "", fmt.Errorf("cursor has %d values for sort %q", len(params.Cursor.Values), sort)
+	}
+
+	keys := make([]string, len(sort))
+	desc := make([]bool, len(sort))
+	order := make([]string, len(sort))
+	for i, f := range sort {
+		column, ok := columns[f.Field]
+		if !ok {
+			return "", fmt.Errorf("unsupported sort field %q", f.Field)
+		}
+		keys[i] = column.expr
+
+		// paging backward walks the list in reverse from the cursor
+		desc[i] = f.Desc
+		if params.Cursor != nil && params.Cursor.Backward {
+			desc[i] = !desc[i]
+		}
+		order[i] = column.expr + " ASC"
+		if desc[i] {
+			order[i] = column.expr + " DESC"
+		}
+	}
+
+	if params.Cursor != nil {
+		values := make([]string, len(sort))
+		for i, f := range sort {
+			values[i] = q.arg(params.Cursor.Values[i]) + "::text"
+			if typ := columns[f.Field].typ; typ != "text" {
+				values[i] += "::" + typ
+			}
+		}
+		q.where(keysetCondition(keys, desc, values))
+	}
+
+	stmt := fmt.Sprintf("%s\nORDER BY %s\nLIMIT %s", q.sql(base), strings.Join(order, ", "), q.arg(params.Limit))
+	if params.Cursor == nil && params.Offset > 0 {
+		stmt += " OFFSET " + q.arg(params.Offset)
+	}
+	return stmt, nil
+}
+
+// keysetCondition returns the condition selecting the rows after the position made of values
+// in a list ordered by keys, descending where desc is set. When all keys share a direction it
+// is a row comparison, which indexes on the keys can serve; otherwise each key is compared in
+// its own direction.
+func keysetCondition(keys []string, desc []bool, values []string) string {
+	cmp := func(desc bool) string {
+		if desc {
+			return "<"
+		}
+		return ">"
+	}
+
+	if !slices.Contains(desc, !desc[0]) {
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(keys, ", "), cmp(desc[0]), strings.Join(values, ", "))
+	}
+
+	// (k1 > v1) OR (k1 = v1 AND k2 < v2) OR ...
+	var alternatives []string
+	for i := range keys {
+		var conds []string
+		for j := 0; j < i; j++ {
+			conds = append(conds, fmt.Sprintf("%s = %s", keys[j], values[j]))
+		}
+		conds = append(conds, fmt.Sprintf("%s %s %s", keys[i], cmp(desc[i]), values[i]))
+		alternatives = append(alternatives, "("+strings.Join(conds, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
+
+// filterQuotes adds the conditions of a quote filter. The full-text query is
+// left out when searching, since the search statement matches it itself.
+func (q *listQuery) filterQuotes(filter repository.QuoteFilter, searching bool) {
+	if len(filter.Tags) > 0 {
+		op := "&&"
+		if filter.MatchAllTags {
+			op = "@>"
+		}
+		q.where(fmt.Sprintf("q.tags %s %s::text[]", op, q.arg(filter.Tags)))
+	}
+	if len(filter.ExcludeTags) > 0 {
+		q.where(fmt.Sprintf("(q.tags IS NULL OR NOT q.tags && %s::text[])", q.arg(filter.ExcludeTags)))
+	}
+	if len(filter.AuthorIDs) > 0 {
+		q.where(fmt.Sprintf("q.author_id = ANY(%s::bigint[])", q.arg(filter.AuthorIDs)))
+	}
+	if filter.Source != "" {
+		q.where(fmt.Sprintf("q.source ILIKE '%%' || %s || '%%'", q.arg(likeEscaper.Replace(filter.Source))))
+	}
+	if len(filter.ExcludeIDs) > 0 {
+		q.where(fmt.Sprintf("q.id <> ALL(%s::bigint[])", q.arg(filter.ExcludeIDs)))
+	}
+	if filter.MaxLength > 0 {
+		q.where(fmt.Sprintf("char_length(q.content) <= %s", q.arg(filter.MaxLength)))
+	}
+	if filter.CreatedAfter != nil {
+		q.where(fmt.Sprintf("q.created_at > %s", q.arg(*filter.CreatedAfter)))
+	}
+	if filter.CreatedBefore != nil {
+		q.where(fmt.Sprintf("q.created_at < %s", q.arg(*filter.CreatedBefore)))
+	}
+	if filter.UpdatedSince != nil {
+		q.where(fmt.Sprintf("q.updated_at >= %s", q.arg(*filter.UpdatedSince)))
+	}
+	if filter.Query != "" && !searching {
+		q.where(fmt.Sprintf("EXISTS (SELECT 1 FROM quote_documents d WHERE d.quote_id = q.id AND d.document @@ websearch_to_tsquery('english', %s::text))",
+			q.arg(filter.Query)))
+	}
+}
+
+// filterAuthors adds the conditions of an author filter
+func (q *listQuery) filterAuthors(filter repository.AuthorFilter) {
+	// authors without a death date may still be alive, and are taken to have lived MaxLifespan years
+	if filter.EraFrom != nil {
+		q.where(fmt.Sprintf("COALESCE(death_year, birth_year + %d) >= %s", repository.MaxLifespan, q.arg(*filter.EraFrom)))
+	}
+	if filter.EraTo != nil {
+		q.where(fmt.Sprintf("COALESCE(birth_year, death_year - %d) <= %s", repository.MaxLifespan, q.arg(*filter.EraTo)))
+	}
+	if filter.Nationality != "" {
+		q.where(fmt.Sprintf("normalize_name(nationality) = normalize_name(%s::text)", q.arg(filter.Nationality)))
+	}
+	if len(filter.Occupations) > 0 {
+		q.where(fmt.Sprintf("occupations && %s::text[]", q.arg(filter.Occupations)))
+	}
+}
+
+// List retrieves a page of the authors matching a filter, by name unless sorted otherwise
+func (r *authorRepository) List(ctx context.Context, filter repository.AuthorFilter, params repository.ListParams) ([]*repository.Author, error) {
+	var q listQuery
+	q.filterAuthors(filter)
+	stmt, err := q.page(authorListSelect, authorSortColumns, repository.DefaultAuthorSort, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list authors: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, stmt, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list authors: %w", err)
+	}
+
+	authors, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*repository.Author, error) {
+		return scanAuthor(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list authors: %w", err)
+	}
+
+	if params.Cursor != nil && params.Cursor.Backward {
+		slices.Reverse(authors)
+	}
+	return authors, nil
+}
+
+// Count counts the authors matching a filter
+func (r *authorRepository) Count(ctx context.Context, filter repository.AuthorFilter) (int64, error) {
+	var q listQuery
+	q.filterAuthors(filter)
+
+	var count int64
+	if err := r.db.QueryRow(ctx, q.sql(authorCountSelect), q.args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count authors: %w", err)
+	}
+	return count, nil
+}
+
+// searchAuthors adds the condition of a fuzzy search over the names and aliases of authors
+func (q *listQuery) searchAuthors(query string) {
+	q.arg(query)
+	q.where("normalize_name($1::text) <% author_search_text(name, aliases)")
+}
+
+// CountSearch counts the authors matching a fuzzy search and a filter
+func (r *authorRepository) CountSearch(ctx context.Context, query string, filter repository.AuthorFilter) (int64, error) {
+	var q listQuery
+	q.searchAuthors(query)
+	q.filterAuthors(filter)
+
+	var count int64
+	if err := r.db.QueryRow(ctx, q.sql(authorCountSelect), q.args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count authors: %w", err)
+	}
+	return count, nil
+}
+
+// Search runs a fuzzy, accent-insensitive search over author names and aliases, narrowed
+// down by a filter, best matches first unless sorted otherwise
+func (r *authorRepository) Search(ctx context.Context, query string, filter repository.AuthorFilter, params repository.ListParams) ([]*repository.AuthorSearchResult, error) {
+	var q listQuery
+	q.searchAuthors(query)
+	q.filterAuthors(filter)
+	stmt, err := q.page(authorSearchSelect, authorSearchSortColumns, repository.DefaultAuthorSearchSort, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search authors: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, stmt, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search authors: %w", err)
+	}
+
+	results, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*repository.AuthorSearchResult, error) {
+		var score float32
+		author, err := scanAuthor(row, &score)
+		if err != nil {
+			return nil, err
+		}
+		return &repository.AuthorSearchResult{Author: *author, Score: score}, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search authors: %w", err)
+	}
+
+	if params.Cursor != nil && params.Cursor.Backward {
+		slices.Reverse(results)
+	}
+	return results, nil
+}
+
+// List retrieves a page of the quotes matching a filter with author information, newest first unless sorted otherwise
+func (r *quoteRepository) List(ctx context.Context, filter repository.QuoteFilter, params repository.ListParams) ([]*repository.QuoteWithAuthor, error) {
+	var q listQuery
+	q.filterQuotes(filter, false)
+
+	stmt, err := q.page(quoteListSelect, quoteSortColumns, repository.DefaultQuoteSort, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quotes: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, stmt, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quotes: %w", err)
+	}
+
+	quotes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*repository.QuoteWithAuthor, error) {
+		var q repository.QuoteWithAuthor
+		err := scanQuoteWithAuthor(row, &q)
+		return &q, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quotes: %w", err)
+	}
+
+	if params.Cursor != nil && params.Cursor.Backward {
+		slices.Reverse(quotes)
+	}
+	return quotes, nil
+}
+
+// scanQuoteWithAuthor scans a row of quoteListSelect or quoteSearchSelect, followed by
+// the columns scanned into extra, filling the nested author from the author columns
+func scanQuoteWithAuthor(row pgx.Row, q *repository.QuoteWithAuthor, extra ...interface{}) error {
+	var a Author
+	dest := []interface{}{&q.ID, &q.Content, &q.AuthorID, &q.Source, &q.Tags, &q.CreatedAt, &q.UpdatedAt, &q.Version}
+	dest = append(dest, authorDest(&a)...)
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	author, err := toAuthor(a)
+	if err != nil {
+		return err
+	}
+	q.Author, q.AuthorName, q.AuthorBio = author, author.Name, author.Bio
+	return nil
+}
+
+// Count counts the quotes matching a filter, including its full-text query
+func (r *quoteRepository) Count(ctx context.Context, filter repository.QuoteFilter) (int64, error) {
+	var q listQuery
+	q.filterQuotes(filter, false)
+
+	var count int64
+	if err := r.db.QueryRow(ctx, q.sql(quoteCountSelect), q.args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count quotes: %w", err)
+	}
+	return count, nil
+}
+
+// CountByAuthor counts the quotes of a specific author
+func (r *quoteRepository) CountByAuthor(ctx context.Context, authorID int64) (int64, error) {
+	return r.Count(ctx, repository.QuoteFilter{AuthorIDs: []int64{authorID}})
+}
+
+// Search runs a full-text search over quote content, source and author name, best matches first
+// unless sorted otherwise. The search query is filter.Query and the other fields of the filter narrow it down.
+func (r *quoteRepository) Search(ctx context.Context, filter repository.QuoteFilter, params repository.ListParams) ([]*repository.QuoteSearchResult, error) {
+	var q listQuery
+	q.arg(filter.Query)
+	q.where("d.document @@ tsq")
+	q.filterQuotes(filter, true)
+
+	stmt, err := q.page(quoteSearchSelect, quoteSearchSortColumns, repository.DefaultQuoteSearchSort, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search quotes: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, stmt, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search quotes: %w", err)
+	}
+
+	results, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*repository.QuoteSearchResult, error) {
+		var q repository.QuoteSearchResult
+		err := scanQuoteWithAuthor(row, &q.QuoteWithAuthor, &q.Rank, &q.Highlight)
+		return &q, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search quotes: %w", err)
+	}
+
+	if params.Cursor != nil && params.Cursor.Backward {
+		slices.Reverse(results)
+	}
+	return results, nil
+}
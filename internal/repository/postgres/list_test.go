@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+func TestKeysetCondition(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   []string
+		desc   []bool
+		values []string
+		want   string
+	}{
+		{
+			name:   "ascending",
+			keys:   []string{"name", "id"},
+			desc:   []bool{false, false},
+			values: []string{"$1", "$2"},
+			want:   "(name, id) > ($1, $2)",
+		},
+		{
+			name:   "descending",
+			keys:   []string{"q.created_at", "q.id"},
+			desc:   []bool{true, true},
+			values: []string{"$1", "$2"},
+			want:   "(q.created_at, q.id) < ($1, $2)",
+		},
+		{
+			name:   "single key",
+			keys:   []string{"id"},
+			desc:   []bool{true},
+			values: []string{"$3"},
+			want:   "(id) < ($3)",
+		},
+		{
+			name:   "ascending then descending",
+			keys:   []string{"a.name", "q.id"},
+			desc:   []bool{false, true},
+			values: []string{"$1", "$2"},
+			want:   "((a.name > $1) OR (a.name = $1 AND q.id < $2))",
+		},
+		{
+			name:   "descending then ascending",
+			keys:   []string{"char_length(q.content)", "q.created_at", "q.id"},
+			desc:   []bool{true, false, false},
+			values: []string{"$1", "$2", "$3"},
+			want: "((char_length(q.content) < $1)" +
+				" OR (char_length(q.content) = $1 AND q.created_at > $2)" +
+				" OR (char_length(q.content) = $1 AND q.created_at = $2 AND q.id > $3))",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keysetCondition(tt.keys, tt.desc, tt.values); got != tt.want {
+				t.Errorf("keysetCondition() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListQueryPage(t *testing.T) {
+	byAuthor := repository.Sort{{Field: repository.SortAuthorName}, {Field: repository.SortID, Desc: true}}
+
+	tests := []struct {
+		name     string
+		params   repository.ListParams
+		want     []string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "default sort",
+			params:   repository.ListParams{Limit: 10},
+			want:     []string{"ORDER BY q.created_at DESC, q.id DESC\nLIMIT $1"},
+			wantArgs: []interface{}{int32(10)},
+		},
+		{
+			name:     "offset",
+			params:   repository.ListParams{Limit: 10, Offset: 20},
+			want:     []string{"LIMIT $1 OFFSET $2"},
+			wantArgs: []interface{}{int32(10), int32(20)},
+		},
+		{
+			name: "cursor replaces offset",
+			params: repository.ListParams{Limit: 10, Offset: 20, Sort: byAuthor,
+				Cursor: &repository.Cursor{Values: []string{"Mark Twain", "3"}}},
+			want: []string{
+				"WHERE ((a.name > $1::text) OR (a.name = $1::text AND q.id < $2::text::bigint))",
+				"ORDER BY a.name ASC, q.id DESC\nLIMIT $3",
+			},
+			wantArgs: []interface{}{"Mark Twain", "3", int32(10)},
+		},
+		{
+			name: "backward cursor flips the order",
+			params: repository.ListParams{Limit: 5, Sort: byAuthor,
+				Cursor: &repository.Cursor{Values: []string{"Mark Twain", "3"}, Backward: true}},
+			want: []string{
+				"WHERE ((a.name < $1::text) OR (a.name = $1::text AND q.id > $2::text::bigint))",
+				"ORDER BY a.name DESC, q.id ASC\nLIMIT $3",
+			},
+			wantArgs: []interface{}{"Mark Twain", "3", int32(5)},
+		},
+		{
+			name: "cursor values are cast to the column type",
+			params: repository.ListParams{Limit: 5,
+				Cursor: &repository.Cursor{Values: []string{"2024-05-01T10:00:00Z", "42"}}},
+			want: []string{
+				"WHERE (q.created_at, q.id) < ($1::text::timestamptz, $2::text::bigint)",
+			},
+			wantArgs: []interface{}{"2024-05-01T10:00:00Z", "42", int32(5)},
+		},
+		{
+			name:     "tie-breaker is appended",
+			params:   repository.ListParams{Limit: 5, Sort: repository.Sort{{Field: repository.SortLength, Desc: true}}},
+			want:     []string{"ORDER BY char_length(q.content) DESC, q.id DESC\nLIMIT $1"},
+			wantArgs: []interface{}{int32(5)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q listQuery
+			stmt, err := q.page(quoteListSelect, quoteSortColumns, repository.DefaultQuoteSort, tt.params)
+			if err != nil {
+				t.Fatalf("page() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stmt, want) {
+					t.Errorf("page() = %q, want it to contain %q", stmt, want)
+				}
+			}
+			if !reflect.DeepEqual(q.args, tt.wantArgs) {
+				t.Errorf("page() args = %#v, want %#v", q.args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestListQueryPageErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		params repository.ListParams
+	}{
+		{
+			name: "cursor issued for a longer sort",
+			params: repository.ListParams{Limit: 5,
+				Cursor: &repository.Cursor{Values: []string{"Mark Twain", "2024-05-01T10:00:00Z", "3"}}},
+		},
+		{
+			name: "cursor issued for a shorter sort",
+			params: repository.ListParams{Limit: 5, Sort: repository.Sort{{Field: repository.SortAuthorName}},
+				Cursor: &repository.Cursor{Values: []string{"3"}}},
+		},
+		{
+			name:   "unsupported sort field",
+			params: repository.ListParams{Limit: 5, Sort: repository.Sort{{Field: repository.SortRank}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q listQuery
+			if stmt, err := q.page(quoteListSelect, quoteSortColumns, repository.DefaultQuoteSort, tt.params); err == nil {
+				t.Errorf("page() = %q, want an error", stmt)
+			}
+		})
+	}
+}
@@ -6,15 +6,39 @@ package postgres
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
 type Author struct {
-	ID        int64          `json:"id"`
-	Name      string         `json:"name"`
-	Bio       sql.NullString `json:"bio"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
+	ID          int64           `json:"id"`
+	Name        string          `json:"name"`
+	Bio         sql.NullString  `json:"bio"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Version     int64           `json:"version"`
+	BirthYear   sql.NullInt32   `json:"birth_year"`
+	BirthMonth  sql.NullInt32   `json:"birth_month"`
+	BirthDay    sql.NullInt32   `json:"birth_day"`
+	BirthCirca  bool            `json:"birth_circa"`
+	DeathYear   sql.NullInt32   `json:"death_year"`
+	DeathMonth  sql.NullInt32   `json:"death_month"`
+	DeathDay    sql.NullInt32   `json:"death_day"`
+	DeathCirca  bool            `json:"death_circa"`
+	Nationality sql.NullString  `json:"nationality"`
+	Occupations []string        `json:"occupations"`
+	WikidataID  sql.NullString  `json:"wikidata_id"`
+	ExternalIds json.RawMessage `json:"external_ids"`
+	Aliases     []string        `json:"aliases"`
+	PortraitUrl sql.NullString  `json:"portrait_url"`
+}
+
+type DailyQuote struct {
+	Day       time.Time `json:"day"`
+	Tag       string    `json:"tag"`
+	QuoteID   int64     `json:"quote_id"`
+	Scheduled bool      `json:"scheduled"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Quote struct {
@@ -25,4 +49,23 @@ type Quote struct {
 	Tags      []string       `json:"tags"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
+	Version   int64          `json:"version"`
+}
+
+type QuoteDocument struct {
+	QuoteID  int64       `json:"quote_id"`
+	Document interface{} `json:"document"`
+}
+
+type QuoteTag struct {
+	QuoteID int64 `json:"quote_id"`
+	TagID   int64 `json:"tag_id"`
+}
+
+type Tag struct {
+	ID        int64     `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
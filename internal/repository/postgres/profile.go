@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// authorColumnNames are the columns of authors in table order, as RETURNING * and
+// sqlc.embed list them
+var authorColumnNames = []string{"id", "name", "bio", "created_at", "updated_at", "version",
+	"birth_year", "birth_month", "birth_day", "birth_circa", "death_year", "death_month", "death_day", "death_circa",
+	"nationality", "occupations", "wikidata_id", "external_ids", "aliases", "portrait_url"}
+
+// authorColumns returns the select list of the authorColumnNames, qualified with a
+// table alias unless it is empty
+func authorColumns(alias string) string {
+	if alias == "" {
+		return strings.Join(authorColumnNames, ", ")
+	}
+	columns := make([]string, len(authorColumnNames))
+	for i, name := range authorColumnNames {
+		columns[i] = alias + "." + name
+	}
+	return strings.Join(columns, ", ")
+}
+
+// authorDest returns the scan destinations of the authorColumnNames
+func authorDest(a *Author) []interface{} {
+	return []interface{}{&a.ID, &a.Name, &a.Bio, &a.CreatedAt, &a.UpdatedAt, &a.Version,
+		&a.BirthYear, &a.BirthMonth, &a.BirthDay, &a.BirthCirca, &a.DeathYear, &a.DeathMonth, &a.DeathDay, &a.DeathCirca,
+		&a.Nationality, &a.Occupations, &a.WikidataID, &a.ExternalIds, &a.Aliases, &a.PortraitUrl}
+}
+
+// scanAuthor scans the author columns of a row, followed by the columns scanned into extra
+func scanAuthor(row pgx.Row, extra ...interface{}) (*repository.Author, error) {
+	var a Author
+	if err := row.Scan(append(authorDest(&a), extra...)...); err != nil {
+		return nil, err
+	}
+	return toAuthor(a)
+}
+
+// toAuthor converts an author row
+func toAuthor(a Author) (*repository.Author, error) {
+	author := &repository.Author{
+		ID:        a.ID,
+		Name:      a.Name,
+		Bio:       a.Bio,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+		Version:   a.Version,
+		AuthorProfile: repository.AuthorProfile{
+			BirthDate:   partialDate(a.BirthYear, a.BirthMonth, a.BirthDay, a.BirthCirca),
+			DeathDate:   partialDate(a.DeathYear, a.DeathMonth, a.DeathDay, a.DeathCirca),
+			Nationality: a.Nationality,
+			Occupations: a.Occupations,
+			WikidataID:  a.WikidataID,
+			Aliases:     a.Aliases,
+			PortraitURL: a.PortraitUrl,
+		},
+	}
+	if err := json.Unmarshal(a.ExternalIds, &author.ExternalIDs); err != nil {
+		return nil, fmt.Errorf("failed to decode external IDs of author %d: %w", a.ID, err)
+	}
+	return author, nil
+}
+
+// partialDate builds a partial date from its columns, nil when the year is not known
+func partialDate(year, month, day *int32, circa bool) *repository.PartialDate {
+	if year == nil {
+		return nil
+	}
+	d := &repository.PartialDate{Year: *year, Circa: circa}
+	if month != nil {
+		d.Month = *month
+	}
+	if day != nil {
+		d.Day = *day
+	}
+	return d
+}
+
+// dateArgs holds the column values of a partial date
+type dateArgs struct {
+	year, month, day *int32
+	circa            bool
+}
+
+// newDateArgs splits a partial date into its column values, all null for nil
+func newDateArgs(d *repository.PartialDate) dateArgs {
+	if d == nil {
+		return dateArgs{}
+	}
+	args := dateArgs{year: &d.Year, circa: d.Circa}
+	if d.Month != 0 {
+		args.month = &d.Month
+	}
+	if d.Day != 0 {
+		args.day = &d.Day
+	}
+	return args
+}
+
+// profileArgs holds the column values of an author profile
+type profileArgs struct {
+	birth, death dateArgs
+	occupations  []string
+	externalIDs  json.RawMessage
+	aliases      []string
+}
+
+// newProfileArgs converts an author profile into column values. The array and
+// object columns are not nullable, so missing values are stored empty.
+func newProfileArgs(p repository.AuthorProfile) (profileArgs, error) {
+	externalIDs, err := externalIDsArg(p.ExternalIDs)
+	if err != nil {
+		return profileArgs{}, err
+	}
+	return profileArgs{
+		birth:       newDateArgs(p.BirthDate),
+		death:       newDateArgs(p.DeathDate),
+		occupations: stringsArg(p.Occupations),
+		externalIDs: externalIDs,
+		aliases:     stringsArg(p.Aliases),
+	}, nil
+}
+
+// stringsArg returns the value of a text array column, empty rather than null
+func stringsArg(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// externalIDsArg encodes external identifiers as a JSON object, empty rather than null
+func externalIDsArg(ids map[string]string) (json.RawMessage, error) {
+	if ids == nil {
+		return json.RawMessage("{}"), nil
+	}
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode external IDs: %w", err)
+	}
+	return data, nil
+}
@@ -9,20 +9,32 @@ import (
 )
 
 type Querier interface {
-	CountAuthors(ctx context.Context) (int64, error)
-	CountQuotes(ctx context.Context) (int64, error)
+	AddAuthorAlias(ctx context.Context, arg AddAuthorAliasParams) (Author, error)
+	CountTags(ctx context.Context) (int64, error)
 	CreateAuthor(ctx context.Context, arg CreateAuthorParams) (Author, error)
+	CreateDailyQuote(ctx context.Context, arg CreateDailyQuoteParams) error
 	CreateQuote(ctx context.Context, arg CreateQuoteParams) (Quote, error)
-	DeleteAuthor(ctx context.Context, id int64) error
-	DeleteQuote(ctx context.Context, id int64) error
+	DeleteAuthor(ctx context.Context, arg DeleteAuthorParams) (int64, error)
+	DeleteQuote(ctx context.Context, arg DeleteQuoteParams) (int64, error)
+	DeleteTag(ctx context.Context, slug string) (int64, error)
 	GetAuthor(ctx context.Context, id int64) (Author, error)
+	GetAuthorByName(ctx context.Context, name string) (Author, error)
+	GetDailyQuote(ctx context.Context, arg GetDailyQuoteParams) (GetDailyQuoteRow, error)
 	GetQuote(ctx context.Context, id int64) (GetQuoteRow, error)
-	GetRandomQuote(ctx context.Context) (GetRandomQuoteRow, error)
-	ListAuthors(ctx context.Context, arg ListAuthorsParams) ([]Author, error)
-	ListQuotes(ctx context.Context, arg ListQuotesParams) ([]ListQuotesRow, error)
-	ListQuotesByAuthor(ctx context.Context, arg ListQuotesByAuthorParams) ([]ListQuotesByAuthorRow, error)
-	SearchAuthorsByName(ctx context.Context, arg SearchAuthorsByNameParams) ([]Author, error)
-	SearchQuotesByContent(ctx context.Context, arg SearchQuotesByContentParams) ([]SearchQuotesByContentRow, error)
+	GetTagBySlug(ctx context.Context, slug string) (GetTagBySlugRow, error)
+	ListDailyQuoteIDs(ctx context.Context, arg ListDailyQuoteIDsParams) ([]int64, error)
+	ListTags(ctx context.Context, arg ListTagsParams) ([]ListTagsRow, error)
+	PatchAuthor(ctx context.Context, arg PatchAuthorParams) (Author, error)
+	PatchQuote(ctx context.Context, arg PatchQuoteParams) (Quote, error)
+	RemoveAuthorAlias(ctx context.Context, arg RemoveAuthorAliasParams) (int64, error)
+	RemoveQuoteTag(ctx context.Context, slug string) (int64, error)
+	RenameTag(ctx context.Context, arg RenameTagParams) (Tag, error)
+	ReplaceQuoteTag(ctx context.Context, arg ReplaceQuoteTagParams) (int64, error)
+	ScheduleDailyQuote(ctx context.Context, arg ScheduleDailyQuoteParams) (int64, error)
+	SuggestAuthors(ctx context.Context, arg SuggestAuthorsParams) ([]SuggestAuthorsRow, error)
+	SuggestQuoteOpenings(ctx context.Context, arg SuggestQuoteOpeningsParams) ([]SuggestQuoteOpeningsRow, error)
+	SuggestTags(ctx context.Context, arg SuggestTagsParams) ([]SuggestTagsRow, error)
+	UnscheduleDailyQuote(ctx context.Context, arg UnscheduleDailyQuoteParams) (int64, error)
 	UpdateAuthor(ctx context.Context, arg UpdateAuthorParams) (Author, error)
 	UpdateQuote(ctx context.Context, arg UpdateQuoteParams) (Quote, error)
 }
@@ -2,36 +2,111 @@
 SELECT * FROM authors
 WHERE id = $1 LIMIT 1;
 
--- name: ListAuthors :many
+-- name: GetAuthorByName :one
 SELECT * FROM authors
-ORDER BY name
-LIMIT $1 OFFSET $2;
+WHERE id = (
+    SELECT author_id FROM author_aliases
+    WHERE normalized_name = author_name_key(sqlc.arg('name')::text)
+);
 
 -- name: CreateAuthor :one
 INSERT INTO authors (
-    name, bio
+    name, bio,
+    birth_year, birth_month, birth_day, birth_circa,
+    death_year, death_month, death_day, death_circa,
+    nationality, occupations, wikidata_id, external_ids, aliases, portrait_url
 ) VALUES (
-    $1, $2
+    $1, $2,
+    $3, $4, $5, $6,
+    $7, $8, $9, $10,
+    $11, $12, $13, $14, $15, $16
 )
 RETURNING *;
 
 -- name: UpdateAuthor :one
 UPDATE authors
 SET 
-    name = $2,
-    bio = $3
-WHERE id = $1
+    name = sqlc.arg('name'),
+    bio = sqlc.narg('bio'),
+    birth_year = sqlc.narg('birth_year'),
+    birth_month = sqlc.narg('birth_month'),
+    birth_day = sqlc.narg('birth_day'),
+    birth_circa = sqlc.arg('birth_circa'),
+    death_year = sqlc.narg('death_year'),
+    death_month = sqlc.narg('death_month'),
+    death_day = sqlc.narg('death_day'),
+    death_circa = sqlc.arg('death_circa'),
+    nationality = sqlc.narg('nationality'),
+    occupations = sqlc.arg('occupations'),
+    wikidata_id = sqlc.narg('wikidata_id'),
+    external_ids = sqlc.arg('external_ids'),
+    aliases = sqlc.arg('aliases'),
+    portrait_url = sqlc.narg('portrait_url')
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('expected_version')::bigint IS NULL OR version = sqlc.narg('expected_version'))
 RETURNING *;
 
--- name: DeleteAuthor :exec
+-- name: DeleteAuthor :execrows
 DELETE FROM authors
-WHERE id = $1;
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('expected_version')::bigint IS NULL OR version = sqlc.narg('expected_version'));
 
--- name: CountAuthors :one
-SELECT COUNT(*) FROM authors;
+-- name: PatchAuthor :one
+UPDATE authors
+SET
+    name = COALESCE(sqlc.narg('name'), name),
+    bio = CASE WHEN sqlc.arg('set_bio')::boolean THEN sqlc.narg('bio') ELSE bio END,
+    birth_year = CASE WHEN sqlc.arg('set_birth_date')::boolean THEN sqlc.narg('birth_year') ELSE birth_year END,
+    birth_month = CASE WHEN sqlc.arg('set_birth_date')::boolean THEN sqlc.narg('birth_month') ELSE birth_month END,
+    birth_day = CASE WHEN sqlc.arg('set_birth_date')::boolean THEN sqlc.narg('birth_day') ELSE birth_day END,
+    birth_circa = CASE WHEN sqlc.arg('set_birth_date')::boolean THEN sqlc.arg('birth_circa') ELSE birth_circa END,
+    death_year = CASE WHEN sqlc.arg('set_death_date')::boolean THEN sqlc.narg('death_year') ELSE death_year END,
+    death_month = CASE WHEN sqlc.arg('set_death_date')::boolean THEN sqlc.narg('death_month') ELSE death_month END,
+    death_day = CASE WHEN sqlc.arg('set_death_date')::boolean THEN sqlc.narg('death_day') ELSE death_day END,
+    death_circa = CASE WHEN sqlc.arg('set_death_date')::boolean THEN sqlc.arg('death_circa') ELSE death_circa END,
+    nationality = CASE WHEN sqlc.arg('set_nationality')::boolean THEN sqlc.narg('nationality') ELSE nationality END,
+    occupations = CASE WHEN sqlc.arg('set_occupations')::boolean THEN sqlc.arg('occupations')::text[] ELSE occupations END,
+    wikidata_id = CASE WHEN sqlc.arg('set_wikidata_id')::boolean THEN sqlc.narg('wikidata_id') ELSE wikidata_id END,
+    external_ids = CASE WHEN sqlc.arg('set_external_ids')::boolean THEN sqlc.arg('external_ids')::jsonb ELSE external_ids END,
+    aliases = CASE WHEN sqlc.arg('set_aliases')::boolean THEN sqlc.arg('aliases')::text[] ELSE aliases END,
+    portrait_url = CASE WHEN sqlc.arg('set_portrait_url')::boolean THEN sqlc.narg('portrait_url') ELSE portrait_url END
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('expected_version')::bigint IS NULL OR version = sqlc.narg('expected_version'))
+RETURNING *;
 
--- name: SearchAuthorsByName :many
-SELECT * FROM authors
-WHERE name ILIKE '%' || $1 || '%'
-ORDER BY name
-LIMIT $2 OFFSET $3;
+-- name: AddAuthorAlias :one
+UPDATE authors
+SET aliases = array_append(aliases, sqlc.arg('alias')::text)
+WHERE id = sqlc.arg('id')
+  AND NOT EXISTS (
+    SELECT 1 FROM author_aliases
+    WHERE author_id = sqlc.arg('id') AND normalized_name = author_name_key(sqlc.arg('alias')::text)
+  )
+  AND (sqlc.narg('expected_version')::bigint IS NULL OR version = sqlc.narg('expected_version'))
+RETURNING *;
+
+-- name: RemoveAuthorAlias :execrows
+UPDATE authors
+SET aliases = ARRAY(
+    SELECT a.alias
+    FROM unnest(aliases) WITH ORDINALITY AS a(alias, position)
+    WHERE author_name_key(a.alias) <> author_name_key(sqlc.arg('alias')::text)
+    ORDER BY a.position
+)
+WHERE id = sqlc.arg('id')
+  AND EXISTS (
+    SELECT 1 FROM unnest(aliases) AS alias
+    WHERE author_name_key(alias) = author_name_key(sqlc.arg('alias')::text)
+  )
+  AND (sqlc.narg('expected_version')::bigint IS NULL OR version = sqlc.narg('expected_version'));
+
+-- name: SuggestAuthors :many
+SELECT
+    id,
+    name,
+    word_similarity(normalize_name(sqlc.arg('query')::text), author_search_text(name, aliases)) as score
+FROM authors
+WHERE author_search_text(name, aliases) LIKE normalize_name(sqlc.arg('pattern')::text) || '%'
+   OR author_search_text(name, aliases) LIKE '% ' || normalize_name(sqlc.arg('pattern')::text) || '%'
+ORDER BY score DESC, length(name), name
+LIMIT sqlc.arg('limit');
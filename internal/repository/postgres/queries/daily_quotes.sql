@@ -0,0 +1,36 @@
+-- name: GetDailyQuote :one
+SELECT
+    d.day,
+    d.tag as daily_tag,
+    d.scheduled,
+    q.*,
+    sqlc.embed(a)
+FROM daily_quotes d
+JOIN quotes q ON q.id = d.quote_id
+JOIN authors a ON q.author_id = a.id
+WHERE d.tag = sqlc.arg('tag') AND d.day = sqlc.arg('day');
+
+-- name: CreateDailyQuote :exec
+INSERT INTO daily_quotes (
+    day, tag, quote_id
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (tag, day) DO NOTHING;
+
+-- name: ScheduleDailyQuote :execrows
+INSERT INTO daily_quotes (
+    day, tag, quote_id, scheduled
+) VALUES (
+    $1, $2, $3, TRUE
+)
+ON CONFLICT (tag, day) DO UPDATE SET quote_id = EXCLUDED.quote_id
+WHERE daily_quotes.scheduled;
+
+-- name: UnscheduleDailyQuote :execrows
+DELETE FROM daily_quotes
+WHERE tag = sqlc.arg('tag') AND day = sqlc.arg('day') AND scheduled;
+
+-- name: ListDailyQuoteIDs :many
+SELECT quote_id FROM daily_quotes
+WHERE tag = sqlc.arg('tag') AND day BETWEEN sqlc.arg('from')::date AND sqlc.arg('to')::date;
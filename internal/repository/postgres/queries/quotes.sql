@@ -1,42 +1,11 @@
 -- name: GetQuote :one
 SELECT 
     q.*,
-    a.id as author_id,
-    a.name as author_name,
-    a.bio as author_bio,
-    a.created_at as author_created_at,
-    a.updated_at as author_updated_at
+    sqlc.embed(a)
 FROM quotes q
 JOIN authors a ON q.author_id = a.id
 WHERE q.id = $1 LIMIT 1;
 
--- name: ListQuotes :many
-SELECT 
-    q.*,
-    a.id as author_id,
-    a.name as author_name,
-    a.bio as author_bio,
-    a.created_at as author_created_at,
-    a.updated_at as author_updated_at
-FROM quotes q
-JOIN authors a ON q.author_id = a.id
-ORDER BY q.created_at DESC
-LIMIT $1 OFFSET $2;
-
--- name: ListQuotesByAuthor :many
-SELECT 
-    q.*,
-    a.id as author_id,
-    a.name as author_name,
-    a.bio as author_bio,
-    a.created_at as author_created_at,
-    a.updated_at as author_updated_at
-FROM quotes q
-JOIN authors a ON q.author_id = a.id
-WHERE q.author_id = $1
-ORDER BY q.created_at DESC
-LIMIT $2 OFFSET $3;
-
 -- name: CreateQuote :one
 INSERT INTO quotes (
     content, author_id, source, tags
@@ -48,43 +17,50 @@ RETURNING *;
 -- name: UpdateQuote :one
 UPDATE quotes
 SET 
-    content = $2,
-    author_id = $3,
-    source = $4,
-    tags = $5
-WHERE id = $1
+    content = sqlc.arg('content'),
+    author_id = sqlc.arg('author_id'),
+    source = sqlc.narg('source'),
+    tags = sqlc.arg('tags')
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('expected_version')::bigint IS NULL OR version = sqlc.narg('expected_version'))
 RETURNING *;
 
--- name: DeleteQuote :exec
-DELETE FROM quotes
-WHERE id = $1;
+-- name: PatchQuote :one
+UPDATE quotes
+SET
+    content = COALESCE(sqlc.narg('content'), content),
+    author_id = COALESCE(sqlc.narg('author_id'), author_id),
+    source = CASE WHEN sqlc.arg('set_source')::boolean THEN sqlc.narg('source') ELSE source END,
+    tags = CASE WHEN sqlc.arg('set_tags')::boolean THEN sqlc.narg('tags')::text[] ELSE tags END
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('expected_version')::bigint IS NULL OR version = sqlc.narg('expected_version'))
+RETURNING *;
 
--- name: CountQuotes :one
-SELECT COUNT(*) FROM quotes;
+-- name: DeleteQuote :execrows
+DELETE FROM quotes
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('expected_version')::bigint IS NULL OR version = sqlc.narg('expected_version'));
 
--- name: SearchQuotesByContent :many
-SELECT 
-    q.*,
-    a.id as author_id,
-    a.name as author_name,
-    a.bio as author_bio,
-    a.created_at as author_created_at,
-    a.updated_at as author_updated_at
-FROM quotes q
-JOIN authors a ON q.author_id = a.id
-WHERE q.content ILIKE '%' || $1 || '%'
-ORDER BY q.created_at DESC
-LIMIT $2 OFFSET $3;
+-- name: SuggestTags :many
+SELECT
+    t.slug::text as tag,
+    u.count as uses,
+    word_similarity(normalize_name(sqlc.arg('query')::text), normalize_name(t.slug)) as score
+FROM tags t
+CROSS JOIN LATERAL (
+    SELECT COUNT(*) as count FROM quote_tags qt WHERE qt.tag_id = t.id
+) u
+WHERE normalize_name(t.slug) LIKE normalize_name(sqlc.arg('pattern')::text) || '%'
+  AND u.count > 0
+ORDER BY score DESC, uses DESC, t.slug
+LIMIT sqlc.arg('limit');
 
--- name: GetRandomQuote :one
-SELECT 
-    q.*,
-    a.id as author_id,
-    a.name as author_name,
-    a.bio as author_bio,
-    a.created_at as author_created_at,
-    a.updated_at as author_updated_at
-FROM quotes q
-JOIN authors a ON q.author_id = a.id
-ORDER BY RANDOM()
-LIMIT 1;
+-- name: SuggestQuoteOpenings :many
+SELECT
+    id,
+    content,
+    word_similarity(normalize_name(sqlc.arg('query')::text), normalize_name(left(content, 100))) as score
+FROM quotes
+WHERE normalize_name(left(content, 100)) LIKE normalize_name(sqlc.arg('pattern')::text) || '%'
+ORDER BY score DESC, length(content), id DESC
+LIMIT sqlc.arg('limit');
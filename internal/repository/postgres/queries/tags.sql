@@ -0,0 +1,50 @@
+-- name: GetTagBySlug :one
+SELECT
+    t.*,
+    COUNT(q.id) as quote_count,
+    COUNT(DISTINCT q.author_id) as author_count
+FROM tags t
+LEFT JOIN quote_tags qt ON qt.tag_id = t.id
+LEFT JOIN quotes q ON q.id = qt.quote_id
+WHERE t.slug = $1
+GROUP BY t.id;
+
+-- name: ListTags :many
+SELECT
+    t.*,
+    COUNT(q.id) as quote_count,
+    COUNT(DISTINCT q.author_id) as author_count
+FROM tags t
+LEFT JOIN quote_tags qt ON qt.tag_id = t.id
+LEFT JOIN quotes q ON q.id = qt.quote_id
+GROUP BY t.id
+ORDER BY quote_count DESC, t.slug
+LIMIT $1 OFFSET $2;
+
+-- name: CountTags :one
+SELECT COUNT(*) FROM tags;
+
+-- name: RenameTag :one
+UPDATE tags
+SET
+    slug = sqlc.arg('new_slug'),
+    name = sqlc.arg('name')
+WHERE slug = sqlc.arg('slug')
+RETURNING *;
+
+-- name: ReplaceQuoteTag :execrows
+UPDATE quotes
+SET tags = CASE
+    WHEN tags @> ARRAY[sqlc.arg('new_slug')::text] THEN array_remove(tags, sqlc.arg('slug')::text)
+    ELSE array_replace(tags, sqlc.arg('slug')::text, sqlc.arg('new_slug')::text)
+END
+WHERE tags @> ARRAY[sqlc.arg('slug')::text];
+
+-- name: RemoveQuoteTag :execrows
+UPDATE quotes
+SET tags = NULLIF(array_remove(tags, sqlc.arg('slug')::text), '{}')
+WHERE tags @> ARRAY[sqlc.arg('slug')::text];
+
+-- name: DeleteTag :execrows
+DELETE FROM tags
+WHERE slug = $1;
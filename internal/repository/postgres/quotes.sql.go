@@ -13,24 +13,13 @@ import (
 	"github.com/lib/pq"
 )
 
-const countQuotes = `-- name: CountQuotes :one
-SELECT COUNT(*) FROM quotes
-`
-
-func (q *Queries) CountQuotes(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countQuotes)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
-}
-
 const createQuote = `-- name: CreateQuote :one
 INSERT INTO quotes (
     content, author_id, source, tags
 ) VALUES (
     $1, $2, $3, $4
 )
-RETURNING id, content, author_id, source, tags, created_at, updated_at
+RETURNING id, content, author_id, source, tags, created_at, updated_at, version
 `
 
 type CreateQuoteParams struct {
@@ -56,46 +45,49 @@ func (q *Queries) CreateQuote(ctx context.Context, arg CreateQuoteParams) (Quote
 		pq.Array(&i.Tags),
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
-const deleteQuote = `-- name: DeleteQuote :exec
+const deleteQuote = `-- name: DeleteQuote :execrows
 DELETE FROM quotes
 WHERE id = $1
+  AND ($2::bigint IS NULL OR version = $2)
 `
 
-func (q *Queries) DeleteQuote(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteQuote, id)
-	return err
+type DeleteQuoteParams struct {
+	ID              int64         `json:"id"`
+	ExpectedVersion sql.NullInt64 `json:"expected_version"`
+}
+
+func (q *Queries) DeleteQuote(ctx context.Context, arg DeleteQuoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteQuote, arg.ID, arg.ExpectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getQuote = `-- name: GetQuote :one
 SELECT 
-    q.id, q.content, q.author_id, q.source, q.tags, q.created_at, q.updated_at,
-    a.id as author_id,
-    a.name as author_name,
-    a.bio as author_bio,
-    a.created_at as author_created_at,
-    a.updated_at as author_updated_at
+    q.id, q.content, q.author_id, q.source, q.tags, q.created_at, q.updated_at, q.version,
+    a.id, a.name, a.bio, a.created_at, a.updated_at, a.version, a.birth_year, a.birth_month, a.birth_day, a.birth_circa, a.death_year, a.death_month, a.death_day, a.death_circa, a.nationality, a.occupations, a.wikidata_id, a.external_ids, a.aliases, a.portrait_url
 FROM quotes q
 JOIN authors a ON q.author_id = a.id
 WHERE q.id = $1 LIMIT 1
 `
 
 type GetQuoteRow struct {
-	ID              int64          `json:"id"`
-	Content         string         `json:"content"`
-	AuthorID        int64          `json:"author_id"`
-	Source          sql.NullString `json:"source"`
-	Tags            []string       `json:"tags"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	AuthorID_2      int64          `json:"author_id_2"`
-	AuthorName      string         `json:"author_name"`
-	AuthorBio       sql.NullString `json:"author_bio"`
-	AuthorCreatedAt time.Time      `json:"author_created_at"`
-	AuthorUpdatedAt time.Time      `json:"author_updated_at"`
+	ID        int64          `json:"id"`
+	Content   string         `json:"content"`
+	AuthorID  int64          `json:"author_id"`
+	Source    sql.NullString `json:"source"`
+	Tags      []string       `json:"tags"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	Version   int64          `json:"version"`
+	Author    Author         `json:"author"`
 }
 
 func (q *Queries) GetQuote(ctx context.Context, id int64) (GetQuoteRow, error) {
@@ -109,47 +101,66 @@ func (q *Queries) GetQuote(ctx context.Context, id int64) (GetQuoteRow, error) {
 		pq.Array(&i.Tags),
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.AuthorID_2,
-		&i.AuthorName,
-		&i.AuthorBio,
-		&i.AuthorCreatedAt,
-		&i.AuthorUpdatedAt,
+		&i.Version,
+		&i.Author.ID,
+		&i.Author.Name,
+		&i.Author.Bio,
+		&i.Author.CreatedAt,
+		&i.Author.UpdatedAt,
+		&i.Author.Version,
+		&i.Author.BirthYear,
+		&i.Author.BirthMonth,
+		&i.Author.BirthDay,
+		&i.Author.BirthCirca,
+		&i.Author.DeathYear,
+		&i.Author.DeathMonth,
+		&i.Author.DeathDay,
+		&i.Author.DeathCirca,
+		&i.Author.Nationality,
+		pq.Array(&i.Author.Occupations),
+		&i.Author.WikidataID,
+		&i.Author.ExternalIds,
+		pq.Array(&i.Author.Aliases),
+		&i.Author.PortraitUrl,
 	)
 	return i, err
 }
 
-const getRandomQuote = `-- name: GetRandomQuote :one
-SELECT 
-    q.id, q.content, q.author_id, q.source, q.tags, q.created_at, q.updated_at,
-    a.id as author_id,
-    a.name as author_name,
-    a.bio as author_bio,
-    a.created_at as author_created_at,
-    a.updated_at as author_updated_at
-FROM quotes q
-JOIN authors a ON q.author_id = a.id
-ORDER BY RANDOM()
-LIMIT 1
+const patchQuote = `-- name: PatchQuote :one
+UPDATE quotes
+SET
+    content = COALESCE($1, content),
+    author_id = COALESCE($2, author_id),
+    source = CASE WHEN $3::boolean THEN $4 ELSE source END,
+    tags = CASE WHEN $5::boolean THEN $6::text[] ELSE tags END
+WHERE id = $7
+  AND ($8::bigint IS NULL OR version = $8)
+RETURNING id, content, author_id, source, tags, created_at, updated_at, version
 `
 
-type GetRandomQuoteRow struct {
-	ID              int64          `json:"id"`
-	Content         string         `json:"content"`
-	AuthorID        int64          `json:"author_id"`
+type PatchQuoteParams struct {
+	Content         sql.NullString `json:"content"`
+	AuthorID        sql.NullInt64  `json:"author_id"`
+	SetSource       bool           `json:"set_source"`
 	Source          sql.NullString `json:"source"`
+	SetTags         bool           `json:"set_tags"`
 	Tags            []string       `json:"tags"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	AuthorID_2      int64          `json:"author_id_2"`
-	AuthorName      string         `json:"author_name"`
-	AuthorBio       sql.NullString `json:"author_bio"`
-	AuthorCreatedAt time.Time      `json:"author_created_at"`
-	AuthorUpdatedAt time.Time      `json:"author_updated_at"`
+	ID              int64          `json:"id"`
+	ExpectedVersion sql.NullInt64  `json:"expected_version"`
 }
 
-func (q *Queries) GetRandomQuote(ctx context.Context) (GetRandomQuoteRow, error) {
-	row := q.db.QueryRowContext(ctx, getRandomQuote)
-	var i GetRandomQuoteRow
+func (q *Queries) PatchQuote(ctx context.Context, arg PatchQuoteParams) (Quote, error) {
+	row := q.db.QueryRowContext(ctx, patchQuote,
+		arg.Content,
+		arg.AuthorID,
+		arg.SetSource,
+		arg.Source,
+		arg.SetTags,
+		pq.Array(arg.Tags),
+		arg.ID,
+		arg.ExpectedVersion,
+	)
+	var i Quote
 	err := row.Scan(
 		&i.ID,
 		&i.Content,
@@ -158,143 +169,47 @@ func (q *Queries) GetRandomQuote(ctx context.Context) (GetRandomQuoteRow, error)
 		pq.Array(&i.Tags),
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.AuthorID_2,
-		&i.AuthorName,
-		&i.AuthorBio,
-		&i.AuthorCreatedAt,
-		&i.AuthorUpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
-const listQuotes = `-- name: ListQuotes :many
-SELECT 
-    q.id, q.content, q.author_id, q.source, q.tags, q.created_at, q.updated_at,
-    a.id as author_id,
-    a.name as author_name,
-    a.bio as author_bio,
-    a.created_at as author_created_at,
-    a.updated_at as author_updated_at
-FROM quotes q
-JOIN authors a ON q.author_id = a.id
-ORDER BY q.created_at DESC
-LIMIT $1 OFFSET $2
-`
-
-type ListQuotesParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
-}
-
-type ListQuotesRow struct {
-	ID              int64          `json:"id"`
-	Content         string         `json:"content"`
-	AuthorID        int64          `json:"author_id"`
-	Source          sql.NullString `json:"source"`
-	Tags            []string       `json:"tags"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	AuthorID_2      int64          `json:"author_id_2"`
-	AuthorName      string         `json:"author_name"`
-	AuthorBio       sql.NullString `json:"author_bio"`
-	AuthorCreatedAt time.Time      `json:"author_created_at"`
-	AuthorUpdatedAt time.Time      `json:"author_updated_at"`
-}
-
-func (q *Queries) ListQuotes(ctx context.Context, arg ListQuotesParams) ([]ListQuotesRow, error) {
-	rows, err := q.db.QueryContext(ctx, listQuotes, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []ListQuotesRow{}
-	for rows.Next() {
-		var i ListQuotesRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Content,
-			&i.AuthorID,
-			&i.Source,
-			pq.Array(&i.Tags),
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.AuthorID_2,
-			&i.AuthorName,
-			&i.AuthorBio,
-			&i.AuthorCreatedAt,
-			&i.AuthorUpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const listQuotesByAuthor = `-- name: ListQuotesByAuthor :many
-SELECT 
-    q.id, q.content, q.author_id, q.source, q.tags, q.created_at, q.updated_at,
-    a.id as author_id,
-    a.name as author_name,
-    a.bio as author_bio,
-    a.created_at as author_created_at,
-    a.updated_at as author_updated_at
-FROM quotes q
-JOIN authors a ON q.author_id = a.id
-WHERE q.author_id = $1
-ORDER BY q.created_at DESC
-LIMIT $2 OFFSET $3
+const suggestQuoteOpenings = `-- name: SuggestQuoteOpenings :many
+SELECT
+    id,
+    content,
+    word_similarity(normalize_name($1::text), normalize_name(left(content, 100))) as score
+FROM quotes
+WHERE normalize_name(left(content, 100)) LIKE normalize_name($2::text) || '%'
+ORDER BY score DESC, length(content), id DESC
+LIMIT $3
 `
 
-type ListQuotesByAuthorParams struct {
-	AuthorID int64 `json:"author_id"`
-	Limit    int32 `json:"limit"`
-	Offset   int32 `json:"offset"`
+type SuggestQuoteOpeningsParams struct {
+	Query   string `json:"query"`
+	Pattern string `json:"pattern"`
+	Limit   int32  `json:"limit"`
 }
 
-type ListQuotesByAuthorRow struct {
-	ID              int64          `json:"id"`
-	Content         string         `json:"content"`
-	AuthorID        int64          `json:"author_id"`
-	Source          sql.NullString `json:"source"`
-	Tags            []string       `json:"tags"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	AuthorID_2      int64          `json:"author_id_2"`
-	AuthorName      string         `json:"author_name"`
-	AuthorBio       sql.NullString `json:"author_bio"`
-	AuthorCreatedAt time.Time      `json:"author_created_at"`
-	AuthorUpdatedAt time.Time      `json:"author_updated_at"`
+type SuggestQuoteOpeningsRow struct {
+	ID      int64   `json:"id"`
+	Content string  `json:"content"`
+	Score   float32 `json:"score"`
 }
 
-func (q *Queries) ListQuotesByAuthor(ctx context.Context, arg ListQuotesByAuthorParams) ([]ListQuotesByAuthorRow, error) {
-	rows, err := q.db.QueryContext(ctx, listQuotesByAuthor, arg.AuthorID, arg.Limit, arg.Offset)
+func (q *Queries) SuggestQuoteOpenings(ctx context.Context, arg SuggestQuoteOpeningsParams) ([]SuggestQuoteOpeningsRow, error) {
+	rows, err := q.db.QueryContext(ctx, suggestQuoteOpenings, arg.Query, arg.Pattern, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListQuotesByAuthorRow{}
+	items := []SuggestQuoteOpeningsRow{}
 	for rows.Next() {
-		var i ListQuotesByAuthorRow
+		var i SuggestQuoteOpeningsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Content,
-			&i.AuthorID,
-			&i.Source,
-			pq.Array(&i.Tags),
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.AuthorID_2,
-			&i.AuthorName,
-			&i.AuthorBio,
-			&i.AuthorCreatedAt,
-			&i.AuthorUpdatedAt,
+			&i.Score,
 		); err != nil {
 			return nil, err
 		}
@@ -309,64 +224,46 @@ func (q *Queries) ListQuotesByAuthor(ctx context.Context, arg ListQuotesByAuthor
 	return items, nil
 }
 
-const searchQuotesByContent = `-- name: SearchQuotesByContent :many
-SELECT 
-    q.id, q.content, q.author_id, q.source, q.tags, q.created_at, q.updated_at,
-    a.id as author_id,
-    a.name as author_name,
-    a.bio as author_bio,
-    a.created_at as author_created_at,
-    a.updated_at as author_updated_at
-FROM quotes q
-JOIN authors a ON q.author_id = a.id
-WHERE q.content ILIKE '%' || $1 || '%'
-ORDER BY q.created_at DESC
-LIMIT $2 OFFSET $3
+const suggestTags = `-- name: SuggestTags :many
+SELECT
+    t.slug::text as tag,
+    u.count as uses,
+    word_similarity(normalize_name($1::text), normalize_name(t.slug)) as score
+FROM tags t
+CROSS JOIN LATERAL (
+    SELECT COUNT(*) as count FROM quote_tags qt WHERE qt.tag_id = t.id
+) u
+WHERE normalize_name(t.slug) LIKE normalize_name($2::text) || '%'
+  AND u.count > 0
+ORDER BY score DESC, uses DESC, t.slug
+LIMIT $3
 `
 
-type SearchQuotesByContentParams struct {
-	Column1 sql.NullString `json:"column_1"`
-	Limit   int32          `json:"limit"`
-	Offset  int32          `json:"offset"`
+type SuggestTagsParams struct {
+	Query   string `json:"query"`
+	Pattern string `json:"pattern"`
+	Limit   int32  `json:"limit"`
 }
 
-type SearchQuotesByContentRow struct {
-	ID              int64          `json:"id"`
-	Content         string         `json:"content"`
-	AuthorID        int64          `json:"author_id"`
-	Source          sql.NullString `json:"source"`
-	Tags            []string       `json:"tags"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	AuthorID_2      int64          `json:"author_id_2"`
-	AuthorName      string         `json:"author_name"`
-	AuthorBio       sql.NullString `json:"author_bio"`
-	AuthorCreatedAt time.Time      `json:"author_created_at"`
-	AuthorUpdatedAt time.Time      `json:"author_updated_at"`
+type SuggestTagsRow struct {
+	Tag   string  `json:"tag"`
+	Uses  int64   `json:"uses"`
+	Score float32 `json:"score"`
 }
 
-func (q *Queries) SearchQuotesByContent(ctx context.Context, arg SearchQuotesByContentParams) ([]SearchQuotesByContentRow, error) {
-	rows, err := q.db.QueryContext(ctx, searchQuotesByContent, arg.Column1, arg.Limit, arg.Offset)
+func (q *Queries) SuggestTags(ctx context.Context, arg SuggestTagsParams) ([]SuggestTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, suggestTags, arg.Query, arg.Pattern, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []SearchQuotesByContentRow{}
+	items := []SuggestTagsRow{}
 	for rows.Next() {
-		var i SearchQuotesByContentRow
+		var i SuggestTagsRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Content,
-			&i.AuthorID,
-			&i.Source,
-			pq.Array(&i.Tags),
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.AuthorID_2,
-			&i.AuthorName,
-			&i.AuthorBio,
-			&i.AuthorCreatedAt,
-			&i.AuthorUpdatedAt,
+			&i.Tag,
+			&i.Uses,
+			&i.Score,
 		); err != nil {
 			return nil, err
 		}
@@ -384,29 +281,32 @@ func (q *Queries) SearchQuotesByContent(ctx context.Context, arg SearchQuotesByC
 const updateQuote = `-- name: UpdateQuote :one
 UPDATE quotes
 SET 
-    content = $2,
-    author_id = $3,
-    source = $4,
-    tags = $5
-WHERE id = $1
-RETURNING id, content, author_id, source, tags, created_at, updated_at
+    content = $1,
+    author_id = $2,
+    source = $3,
+    tags = $4
+WHERE id = $5
+  AND ($6::bigint IS NULL OR version = $6)
+RETURNING id, content, author_id, source, tags, created_at, updated_at, version
 `
 
 type UpdateQuoteParams struct {
-	ID       int64          `json:"id"`
-	Content  string         `json:"content"`
-	AuthorID int64          `json:"author_id"`
-	Source   sql.NullString `json:"source"`
-	Tags     []string       `json:"tags"`
+	Content         string         `json:"content"`
+	AuthorID        int64          `json:"author_id"`
+	Source          sql.NullString `json:"source"`
+	Tags            []string       `json:"tags"`
+	ID              int64          `json:"id"`
+	ExpectedVersion sql.NullInt64  `json:"expected_version"`
 }
 
 func (q *Queries) UpdateQuote(ctx context.Context, arg UpdateQuoteParams) (Quote, error) {
 	row := q.db.QueryRowContext(ctx, updateQuote,
-		arg.ID,
 		arg.Content,
 		arg.AuthorID,
 		arg.Source,
 		pq.Array(arg.Tags),
+		arg.ID,
+		arg.ExpectedVersion,
 	)
 	var i Quote
 	err := row.Scan(
@@ -417,6 +317,7 @@ func (q *Queries) UpdateQuote(ctx context.Context, arg UpdateQuoteParams) (Quote
 		pq.Array(&i.Tags),
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// Random quotes are never picked with ORDER BY random(), which sorts every matching quote.
+// Large sets of matching quotes are sampled by ID: each pick draws an ID between the lowest
+// and highest one and takes the first matching quote from there, wrapping around to the
+// lowest IDs, which is a primary key index probe per pick and all picks run in one query.
+// Quotes following a gap in the IDs, or a run of quotes the filter leaves out, are more
+// likely to be picked: for large sets speed wins over exact uniformity. Sets of up to
+// randomExactLimit matching quotes, which a few gaps would skew the most, are sampled
+// exactly instead: distinct positions among the matching quotes in ID order are drawn and
+// the quotes at those positions fetched, which reads every matching ID but stays cheap at
+// that size. Telling the sets apart counts at most randomExactLimit+1 matching quotes.
+
+// randomExactLimit is the largest number of matching quotes sampled exactly
+const randomExactLimit = 1000
+
+// quoteRandomMatches numbers the matching quotes in ID order from 0
+const quoteRandomMatches = `SELECT q.id, row_number() OVER (ORDER BY q.id) - 1 AS position
+FROM quotes q`
+
+// Random picks up to params.Count distinct quotes matching a filter with author information
+func (r *quoteRepository) Random(ctx context.Context, filter repository.QuoteFilter, params repository.RandomParams) ([]*repository.QuoteWithAuthor, error) {
+	seed := rand.Uint64()
+	if params.Seed != nil {
+		seed = uint64(*params.Seed)
+	}
+	rng := rand.New(rand.NewPCG(seed, seed))
+
+	var q listQuery
+	q.filterQuotes(filter, false)
+	stmt := fmt.Sprintf("SELECT COUNT(*) FROM (%s\nLIMIT %d) m", q.sql("SELECT 1\nFROM quotes q"), randomExactLimit+1)
+
+	var matching int64
+	if err := r.db.QueryRow(ctx, stmt, q.args...).Scan(&matching); err != nil {
+		return nil, fmt.Errorf("failed to count random quote candidates: %w", err)
+	}
+	if matching == 0 {
+		return nil, nil
+	}
+	if matching <= randomExactLimit {
+		return r.randomByPosition(ctx, filter, samplePositions(rng, matching, int64(params.Count)))
+	}
+	return r.randomByID(ctx, filter, rng, int(params.Count))
+}
+
+// randomByPosition retrieves the matching quotes at positions in ID order, in the order of positions
+func (r *quoteRepository) randomByPosition(ctx context.Context, filter repository.QuoteFilter, positions []int64) ([]*repository.QuoteWithAuthor, error) {
+	var q listQuery
+	q.filterQuotes(filter, false)
+	picked := q.arg(positions)
+	stmt := "WITH matches AS (\n" + q.sql(quoteRandomMatches) + "\n)\n" + quoteListSelect +
+		"\nJOIN matches m ON m.id = q.id" +
+		fmt.Sprintf("\nWHERE m.position = ANY(%s::bigint[])", picked) +
+		fmt.Sprintf("\nORDER BY array_position(%s::bigint[], m.position)", picked)
+
+	return r.queryRandom(ctx, stmt, q.args)
+}
+
+// randomByID picks count distinct matching quotes by ID. Pivots landing on a quote picked
+// already are drawn again, until count quotes are picked or every matching quote is.
+func (r *quoteRepository) randomByID(ctx context.Context, filter repository.QuoteFilter, rng *rand.Rand, count int) ([]*repository.QuoteWithAuthor, error) {
+	var lowest, highest *int64
+	if err := r.db.QueryRow(ctx, "SELECT min(id), max(id) FROM quotes").Scan(&lowest, &highest); err != nil {
+		return nil, fmt.Errorf("failed to get quote ID range: %w", err)
+	}
+	if lowest == nil {
+		return nil, nil
+	}
+
+	var quotes []*repository.QuoteWithAuthor
+	var picked []int64
+	for len(quotes) < count {
+		pivots := make([]int64, count-len(quotes))
+		for i := range pivots {
+			pivots[i] = *lowest + rng.Int64N(*highest-*lowest+1)
+		}
+
+		found, err := r.pickByID(ctx, filter, pivots, picked)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			// every matching quote has been picked
+			break
+		}
+		for _, quote := range found {
+			quotes = append(quotes, quote)
+			picked = append(picked, quote.ID)
+		}
+	}
+
+	return quotes, nil
+}
+
+// pickByID retrieves for each pivot the matching quote with the lowest ID from it, or the
+// lowest ID overall past the highest match, leaving out picked quotes. Pivots landing on the
+// same quote pick it once. Quotes come in the order of the pivots picking them.
+func (r *quoteRepository) pickByID(ctx context.Context, filter repository.QuoteFilter, pivots, picked []int64) ([]*repository.QuoteWithAuthor, error) {
+	var q listQuery
+	q.filterQuotes(filter, false)
+	if len(picked) > 0 {
+		q.where(fmt.Sprintf("q.id <> ALL(%s::bigint[])", q.arg(picked)))
+	}
+	match := "TRUE"
+	if len(q.conds) > 0 {
+		match = strings.Join(q.conds, " AND ")
+	}
+
+	stmt := fmt.Sprintf(`WITH picks AS (
+    SELECT DISTINCT ON (id) id, n
+    FROM (
+        SELECT COALESCE(
+            (SELECT q.id FROM quotes q WHERE q.id >= p.pivot AND %[1]s ORDER BY q.id LIMIT 1),
+            (SELECT q.id FROM quotes q WHERE %[1]s ORDER BY q.id LIMIT 1)
+        ) AS id, p.n
+        FROM unnest(%[2]s::bigint[]) WITH ORDINALITY AS p(pivot, n)
+    ) drawn
+    WHERE id IS NOT NULL
+    ORDER BY id, n
+)
+%[3]s
+JOIN picks ON picks.id = q.id
+ORDER BY picks.n`, match, q.arg(pivots), quoteListSelect)
+
+	return r.queryRandom(ctx, stmt, q.args)
+}
+
+// queryRandom runs a statement selecting random quotes with quoteListSelect
+func (r *quoteRepository) queryRandom(ctx context.Context, stmt string, args []interface{}) ([]*repository.QuoteWithAuthor, error) {
+	rows, err := r.db.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pick random quotes: %w", err)
+	}
+	quotes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*repository.QuoteWithAuthor, error) {
+		var q repository.QuoteWithAuthor
+		err := scanQuoteWithAuthor(row, &q)
+		return &q, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to pick random quotes: %w", err)
+	}
+	return quotes, nil
+}
+
+// samplePositions draws up to count distinct positions below n in the order they are drawn
+func samplePositions(rng *rand.Rand, n, count int64) []int64 {
+	if count > n {
+		count = n
+	}
+
+	positions := make([]int64, 0, count)
+	drawn := make(map[int64]bool, count)
+	for int64(len(positions)) < count {
+		p := rng.Int64N(n)
+		if drawn[p] {
+			continue
+		}
+		drawn[p] = true
+		positions = append(positions, p)
+	}
+	return positions
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestSamplePositions(t *testing.T) {
+	tests := []struct {
+		name     string
+		n, count int64
+		want     int
+	}{
+		{"fewer than matching", 100, 5, 5},
+		{"as many as matching", 7, 7, 7},
+		{"more than matching", 3, 50, 3},
+		{"single match", 1, 10, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positions := samplePositions(rand.New(rand.NewPCG(42, 42)), tt.n, tt.count)
+			if len(positions) != tt.want {
+				t.Fatalf("samplePositions() = %v, want %d positions", positions, tt.want)
+			}
+
+			seen := make(map[int64]bool)
+			for _, p := range positions {
+				if p < 0 || p >= tt.n {
+					t.Errorf("samplePositions() drew %d, want a position below %d", p, tt.n)
+				}
+				if seen[p] {
+					t.Errorf("samplePositions() drew %d twice", p)
+				}
+				seen[p] = true
+			}
+		})
+	}
+}
+
+func TestSamplePositionsIsSeeded(t *testing.T) {
+	first := samplePositions(rand.New(rand.NewPCG(7, 7)), 1000, 20)
+	again := samplePositions(rand.New(rand.NewPCG(7, 7)), 1000, 20)
+	if !reflect.DeepEqual(first, again) {
+		t.Errorf("samplePositions() with the same seed = %v, then %v", first, again)
+	}
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/igferreira/quotes-api/internal/repository"
@@ -11,7 +12,7 @@ import (
 
 // Repository wraps the sqlc queries and implements the repository interfaces
 type Repository struct {
-	db      *pgxpool.Pool
+	db      conn
 	queries *Queries
 }
 
@@ -39,7 +40,24 @@ func (r *Repository) QuoteRepo() repository.QuoteRepository {
 	}
 }
 
-// WithTx executes a function within a database transaction
+// TagRepo returns the tag repository
+func (r *Repository) TagRepo() repository.TagRepository {
+	return &tagRepository{
+		db:      r.db,
+		queries: r.queries,
+	}
+}
+
+// DailyQuoteRepo returns the quote of the day repository
+func (r *Repository) DailyQuoteRepo() repository.DailyQuoteRepository {
+	return &dailyQuoteRepository{
+		db:      r.db,
+		queries: r.queries,
+	}
+}
+
+// WithTx executes a function within a database transaction. Every query of the
+// repositories passed to fn, including listings, runs in the transaction.
 func (r *Repository) WithTx(ctx context.Context, fn func(repository.AuthorRepository, repository.QuoteRepository) error) error {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
@@ -49,7 +67,7 @@ func (r *Repository) WithTx(ctx context.Context, fn func(repository.AuthorReposi
 
 	qtx := r.queries.WithTx(tx)
 	txRepo := &Repository{
-		db:      r.db,
+		db:      tx,
 		queries: qtx,
 	}
 
@@ -66,141 +84,193 @@ func (r *Repository) WithTx(ctx context.Context, fn func(repository.AuthorReposi
 
 // authorRepository implements repository.AuthorRepository
 type authorRepository struct {
-	db      *pgxpool.Pool
+	db      conn
 	queries *Queries
 }
 
 // Create creates a new author
 func (r *authorRepository) Create(ctx context.Context, params repository.CreateAuthorParams) (*repository.Author, error) {
+	profile, err := newProfileArgs(params.AuthorProfile)
+	if err != nil {
+		return nil, err
+	}
+
 	author, err := r.queries.CreateAuthor(ctx, CreateAuthorParams{
-		Name: params.Name,
-		Bio:  params.Bio,
+		Name:        params.Name,
+		Bio:         params.Bio,
+		BirthYear:   profile.birth.year,
+		BirthMonth:  profile.birth.month,
+		BirthDay:    profile.birth.day,
+		BirthCirca:  profile.birth.circa,
+		DeathYear:   profile.death.year,
+		DeathMonth:  profile.death.month,
+		DeathDay:    profile.death.day,
+		DeathCirca:  profile.death.circa,
+		Nationality: params.Nationality,
+		Occupations: profile.occupations,
+		WikidataID:  params.WikidataID,
+		ExternalIds: profile.externalIDs,
+		Aliases:     profile.aliases,
+		PortraitUrl: params.PortraitURL,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create author: %w", err)
+		return nil, translateError(err, repository.ResourceAuthor, 0, "create author")
 	}
 
-	return &repository.Author{
-		ID:        author.ID,
-		Name:      author.Name,
-		Bio:       author.Bio,
-		CreatedAt: author.CreatedAt,
-		UpdatedAt: author.UpdatedAt,
-	}, nil
+	return toAuthor(author)
 }
 
 // GetByID retrieves an author by ID
 func (r *authorRepository) GetByID(ctx context.Context, id int64) (*repository.Author, error) {
 	author, err := r.queries.GetAuthor(ctx, id)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("author not found")
-		}
-		return nil, fmt.Errorf("failed to get author: %w", err)
+		return nil, translateError(err, repository.ResourceAuthor, id, "get author")
 	}
 
-	return &repository.Author{
-		ID:        author.ID,
-		Name:      author.Name,
-		Bio:       author.Bio,
-		CreatedAt: author.CreatedAt,
-		UpdatedAt: author.UpdatedAt,
-	}, nil
+	return toAuthor(author)
 }
 
-// List retrieves a paginated list of authors
-func (r *authorRepository) List(ctx context.Context, params repository.ListParams) ([]*repository.Author, error) {
-	authors, err := r.queries.ListAuthors(ctx, ListAuthorsParams{
-		Limit:  params.Limit,
-		Offset: params.Offset,
-	})
+// GetByName retrieves the author going by a name or an alias, ignoring case, accents and spacing
+func (r *authorRepository) GetByName(ctx context.Context, name string) (*repository.Author, error) {
+	author, err := r.queries.GetAuthorByName(ctx, name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list authors: %w", err)
-	}
-
-	result := make([]*repository.Author, len(authors))
-	for i, author := range authors {
-		result[i] = &repository.Author{
-			ID:        author.ID,
-			Name:      author.Name,
-			Bio:       author.Bio,
-			CreatedAt: author.CreatedAt,
-			UpdatedAt: author.UpdatedAt,
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.NewError(repository.ErrNotFound, repository.ResourceAuthor, fmt.Sprintf("author %q not found", name), nil)
 		}
+		return nil, fmt.Errorf("failed to get author by name: %w", err)
 	}
 
-	return result, nil
+	return toAuthor(author)
 }
 
-// Update updates an existing author
+// Update updates an existing author, replacing its whole profile
 func (r *authorRepository) Update(ctx context.Context, id int64, params repository.UpdateAuthorParams) (*repository.Author, error) {
+	profile, err := newProfileArgs(params.AuthorProfile)
+	if err != nil {
+		return nil, err
+	}
+
 	author, err := r.queries.UpdateAuthor(ctx, UpdateAuthorParams{
-		ID:   id,
-		Name: params.Name,
-		Bio:  params.Bio,
+		ID:              id,
+		Name:            params.Name,
+		Bio:             params.Bio,
+		BirthYear:       profile.birth.year,
+		BirthMonth:      profile.birth.month,
+		BirthDay:        profile.birth.day,
+		BirthCirca:      profile.birth.circa,
+		DeathYear:       profile.death.year,
+		DeathMonth:      profile.death.month,
+		DeathDay:        profile.death.day,
+		DeathCirca:      profile.death.circa,
+		Nationality:     params.Nationality,
+		Occupations:     profile.occupations,
+		WikidataID:      params.WikidataID,
+		ExternalIds:     profile.externalIDs,
+		Aliases:         profile.aliases,
+		PortraitUrl:     params.PortraitURL,
+		ExpectedVersion: params.ExpectedVersion,
 	})
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("author not found")
+		if errors.Is(err, pgx.ErrNoRows) && params.ExpectedVersion != nil {
+			return nil, r.versionMismatch(ctx, id)
 		}
-		return nil, fmt.Errorf("failed to update author: %w", err)
+		return nil, translateError(err, repository.ResourceAuthor, id, "update author")
 	}
 
-	return &repository.Author{
-		ID:        author.ID,
-		Name:      author.Name,
-		Bio:       author.Bio,
-		CreatedAt: author.CreatedAt,
-		UpdatedAt: author.UpdatedAt,
-	}, nil
+	return toAuthor(author)
 }
 
-// Delete deletes an author
-func (r *authorRepository) Delete(ctx context.Context, id int64) error {
-	err := r.queries.DeleteAuthor(ctx, id)
+// Patch partially updates an existing author
+func (r *authorRepository) Patch(ctx context.Context, id int64, params repository.PatchAuthorParams) (*repository.Author, error) {
+	birth := newDateArgs(params.BirthDate.Value)
+	death := newDateArgs(params.DeathDate.Value)
+
+	var occupations, aliases []string
+	if params.Occupations.Value != nil {
+		occupations = *params.Occupations.Value
+	}
+	if params.Aliases.Value != nil {
+		aliases = *params.Aliases.Value
+	}
+	var ids map[string]string
+	if params.ExternalIDs.Value != nil {
+		ids = *params.ExternalIDs.Value
+	}
+	externalIDs, err := externalIDsArg(ids)
 	if err != nil {
-		return fmt.Errorf("failed to delete author: %w", err)
+		return nil, err
 	}
-	return nil
-}
 
-// Count returns the total number of authors
-func (r *authorRepository) Count(ctx context.Context) (int64, error) {
-	count, err := r.queries.CountAuthors(ctx)
+	author, err := r.queries.PatchAuthor(ctx, PatchAuthorParams{
+		ID:              id,
+		Name:            params.Name.Value,
+		SetBio:          params.Bio.Set,
+		Bio:             params.Bio.Value,
+		SetBirthDate:    params.BirthDate.Set,
+		BirthYear:       birth.year,
+		BirthMonth:      birth.month,
+		BirthDay:        birth.day,
+		BirthCirca:      birth.circa,
+		SetDeathDate:    params.DeathDate.Set,
+		DeathYear:       death.year,
+		DeathMonth:      death.month,
+		DeathDay:        death.day,
+		DeathCirca:      death.circa,
+		SetNationality:  params.Nationality.Set,
+		Nationality:     params.Nationality.Value,
+		SetOccupations:  params.Occupations.Set,
+		Occupations:     stringsArg(occupations),
+		SetWikidataID:   params.WikidataID.Set,
+		WikidataID:      params.WikidataID.Value,
+		SetExternalIds:  params.ExternalIDs.Set,
+		ExternalIds:     externalIDs,
+		SetAliases:      params.Aliases.Set,
+		Aliases:         stringsArg(aliases),
+		SetPortraitUrl:  params.PortraitURL.Set,
+		PortraitUrl:     params.PortraitURL.Value,
+		ExpectedVersion: params.ExpectedVersion,
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to count authors: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) && params.ExpectedVersion != nil {
+			return nil, r.versionMismatch(ctx, id)
+		}
+		return nil, translateError(err, repository.ResourceAuthor, id, "patch author")
 	}
-	return count, nil
+
+	return toAuthor(author)
 }
 
-// Search searches for authors by name
-func (r *authorRepository) Search(ctx context.Context, query string, params repository.ListParams) ([]*repository.Author, error) {
-	authors, err := r.queries.SearchAuthorsByName(ctx, SearchAuthorsByNameParams{
-		Column1: query,
-		Limit:   params.Limit,
-		Offset:  params.Offset,
+// Delete deletes an author, optionally only if its version matches expectedVersion
+func (r *authorRepository) Delete(ctx context.Context, id int64, expectedVersion *int64) error {
+	rows, err := r.queries.DeleteAuthor(ctx, DeleteAuthorParams{
+		ID:              id,
+		ExpectedVersion: expectedVersion,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to search authors: %w", err)
+		return translateError(err, repository.ResourceAuthor, id, "delete author")
 	}
-
-	result := make([]*repository.Author, len(authors))
-	for i, author := range authors {
-		result[i] = &repository.Author{
-			ID:        author.ID,
-			Name:      author.Name,
-			Bio:       author.Bio,
-			CreatedAt: author.CreatedAt,
-			UpdatedAt: author.UpdatedAt,
+	if rows == 0 {
+		if expectedVersion != nil {
+			return r.versionMismatch(ctx, id)
 		}
+		return repository.NotFound(repository.ResourceAuthor, id)
 	}
+	return nil
+}
 
-	return result, nil
+// versionMismatch explains why a versioned write matched no row: either the
+// author does not exist or it was modified since the expected version
+func (r *authorRepository) versionMismatch(ctx context.Context, id int64) error {
+	author, err := r.queries.GetAuthor(ctx, id)
+	if err != nil {
+		return translateError(err, repository.ResourceAuthor, id, "get author")
+	}
+	return repository.PreconditionFailed(repository.ResourceAuthor, id, author.Version)
 }
 
 // quoteRepository implements repository.QuoteRepository
 type quoteRepository struct {
-	db      *pgxpool.Pool
+	db      conn
 	queries *Queries
 }
 
@@ -213,7 +283,7 @@ func (r *quoteRepository) Create(ctx context.Context, params repository.CreateQu
 		Tags:     params.Tags,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create quote: %w", err)
+		return nil, translateError(err, repository.ResourceQuote, 0, "create quote")
 	}
 
 	return &repository.Quote{
@@ -224,6 +294,7 @@ func (r *quoteRepository) Create(ctx context.Context, params repository.CreateQu
 		Tags:      quote.Tags,
 		CreatedAt: quote.CreatedAt,
 		UpdatedAt: quote.UpdatedAt,
+		Version:   quote.Version,
 	}, nil
 }
 
@@ -231,10 +302,12 @@ func (r *quoteRepository) Create(ctx context.Context, params repository.CreateQu
 func (r *quoteRepository) GetByID(ctx context.Context, id int64) (*repository.QuoteWithAuthor, error) {
 	row, err := r.queries.GetQuote(ctx, id)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("quote not found")
-		}
-		return nil, fmt.Errorf("failed to get quote: %w", err)
+		return nil, translateError(err, repository.ResourceQuote, id, "get quote")
+	}
+
+	author, err := toAuthor(row.Author)
+	if err != nil {
+		return nil, err
 	}
 
 	return &repository.QuoteWithAuthor{
@@ -246,87 +319,29 @@ func (r *quoteRepository) GetByID(ctx context.Context, id int64) (*repository.Qu
 			Tags:      row.Tags,
 			CreatedAt: row.CreatedAt,
 			UpdatedAt: row.UpdatedAt,
+			Version:   row.Version,
+			Author:    author,
 		},
-		AuthorName: row.AuthorName,
-		AuthorBio:  row.AuthorBio,
+		AuthorName: author.Name,
+		AuthorBio:  author.Bio,
 	}, nil
 }
 
-// List retrieves a paginated list of quotes with author information
-func (r *quoteRepository) List(ctx context.Context, params repository.ListParams) ([]*repository.QuoteWithAuthor, error) {
-	rows, err := r.queries.ListQuotes(ctx, ListQuotesParams{
-		Limit:  params.Limit,
-		Offset: params.Offset,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list quotes: %w", err)
-	}
-
-	result := make([]*repository.QuoteWithAuthor, len(rows))
-	for i, row := range rows {
-		result[i] = &repository.QuoteWithAuthor{
-			Quote: repository.Quote{
-				ID:        row.ID,
-				Content:   row.Content,
-				AuthorID:  row.AuthorID,
-				Source:    row.Source,
-				Tags:      row.Tags,
-				CreatedAt: row.CreatedAt,
-				UpdatedAt: row.UpdatedAt,
-			},
-			AuthorName: row.AuthorName,
-			AuthorBio:  row.AuthorBio,
-		}
-	}
-
-	return result, nil
-}
-
-// ListByAuthor retrieves quotes by a specific author
-func (r *quoteRepository) ListByAuthor(ctx context.Context, authorID int64, params repository.ListParams) ([]*repository.QuoteWithAuthor, error) {
-	rows, err := r.queries.ListQuotesByAuthor(ctx, ListQuotesByAuthorParams{
-		AuthorID: authorID,
-		Limit:    params.Limit,
-		Offset:   params.Offset,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list quotes by author: %w", err)
-	}
-
-	result := make([]*repository.QuoteWithAuthor, len(rows))
-	for i, row := range rows {
-		result[i] = &repository.QuoteWithAuthor{
-			Quote: repository.Quote{
-				ID:        row.ID,
-				Content:   row.Content,
-				AuthorID:  row.AuthorID,
-				Source:    row.Source,
-				Tags:      row.Tags,
-				CreatedAt: row.CreatedAt,
-				UpdatedAt: row.UpdatedAt,
-			},
-			AuthorName: row.AuthorName,
-			AuthorBio:  row.AuthorBio,
-		}
-	}
-
-	return result, nil
-}
-
 // Update updates an existing quote
 func (r *quoteRepository) Update(ctx context.Context, id int64, params repository.UpdateQuoteParams) (*repository.Quote, error) {
 	quote, err := r.queries.UpdateQuote(ctx, UpdateQuoteParams{
-		ID:       id,
-		Content:  params.Content,
-		AuthorID: params.AuthorID,
-		Source:   params.Source,
-		Tags:     params.Tags,
+		ID:              id,
+		Content:         params.Content,
+		AuthorID:        params.AuthorID,
+		Source:          params.Source,
+		Tags:            params.Tags,
+		ExpectedVersion: params.ExpectedVersion,
 	})
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("quote not found")
+		if errors.Is(err, pgx.ErrNoRows) && params.ExpectedVersion != nil {
+			return nil, r.versionMismatch(ctx, id)
 		}
-		return nil, fmt.Errorf("failed to update quote: %w", err)
+		return nil, translateError(err, repository.ResourceQuote, id, "update quote")
 	}
 
 	return &repository.Quote{
@@ -337,79 +352,70 @@ func (r *quoteRepository) Update(ctx context.Context, id int64, params repositor
 		Tags:      quote.Tags,
 		CreatedAt: quote.CreatedAt,
 		UpdatedAt: quote.UpdatedAt,
+		Version:   quote.Version,
 	}, nil
 }
 
-// Delete deletes a quote
-func (r *quoteRepository) Delete(ctx context.Context, id int64) error {
-	err := r.queries.DeleteQuote(ctx, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete quote: %w", err)
+// Patch partially updates an existing quote
+func (r *quoteRepository) Patch(ctx context.Context, id int64, params repository.PatchQuoteParams) (*repository.Quote, error) {
+	var tags []string
+	if params.Tags.Value != nil {
+		tags = *params.Tags.Value
 	}
-	return nil
-}
 
-// Count returns the total number of quotes
-func (r *quoteRepository) Count(ctx context.Context) (int64, error) {
-	count, err := r.queries.CountQuotes(ctx)
+	quote, err := r.queries.PatchQuote(ctx, PatchQuoteParams{
+		ID:              id,
+		Content:         params.Content.Value,
+		AuthorID:        params.AuthorID.Value,
+		SetSource:       params.Source.Set,
+		Source:          params.Source.Value,
+		SetTags:         params.Tags.Set,
+		Tags:            tags,
+		ExpectedVersion: params.ExpectedVersion,
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to count quotes: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) && params.ExpectedVersion != nil {
+			return nil, r.versionMismatch(ctx, id)
+		}
+		return nil, translateError(err, repository.ResourceQuote, id, "patch quote")
 	}
-	return count, nil
+
+	return &repository.Quote{
+		ID:        quote.ID,
+		Content:   quote.Content,
+		AuthorID:  quote.AuthorID,
+		Source:    quote.Source,
+		Tags:      quote.Tags,
+		CreatedAt: quote.CreatedAt,
+		UpdatedAt: quote.UpdatedAt,
+		Version:   quote.Version,
+	}, nil
 }
 
-// Search searches for quotes by content
-func (r *quoteRepository) Search(ctx context.Context, query string, params repository.ListParams) ([]*repository.QuoteWithAuthor, error) {
-	rows, err := r.queries.SearchQuotesByContent(ctx, SearchQuotesByContentParams{
-		Column1: query,
-		Limit:   params.Limit,
-		Offset:  params.Offset,
+// Delete deletes a quote, optionally only if its version matches expectedVersion
+func (r *quoteRepository) Delete(ctx context.Context, id int64, expectedVersion *int64) error {
+	rows, err := r.queries.DeleteQuote(ctx, DeleteQuoteParams{
+		ID:              id,
+		ExpectedVersion: expectedVersion,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to search quotes: %w", err)
-	}
-
-	result := make([]*repository.QuoteWithAuthor, len(rows))
-	for i, row := range rows {
-		result[i] = &repository.QuoteWithAuthor{
-			Quote: repository.Quote{
-				ID:        row.ID,
-				Content:   row.Content,
-				AuthorID:  row.AuthorID,
-				Source:    row.Source,
-				Tags:      row.Tags,
-				CreatedAt: row.CreatedAt,
-				UpdatedAt: row.UpdatedAt,
-			},
-			AuthorName: row.AuthorName,
-			AuthorBio:  row.AuthorBio,
+		return translateError(err, repository.ResourceQuote, id, "delete quote")
+	}
+	if rows == 0 {
+		if expectedVersion != nil {
+			return r.versionMismatch(ctx, id)
 		}
+		return repository.NotFound(repository.ResourceQuote, id)
 	}
-
-	return result, nil
+	return nil
 }
 
-// GetRandom retrieves a random quote
-func (r *quoteRepository) GetRandom(ctx context.Context) (*repository.QuoteWithAuthor, error) {
-	row, err := r.queries.GetRandomQuote(ctx)
+// versionMismatch explains why a versioned write matched no row: either the
+// quote does not exist or it was modified since the expected version
+func (r *quoteRepository) versionMismatch(ctx context.Context, id int64) error {
+	row, err := r.queries.GetQuote(ctx, id)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("no quotes found")
-		}
-		return nil, fmt.Errorf("failed to get random quote: %w", err)
+		return translateError(err, repository.ResourceQuote, id, "get quote")
 	}
-
-	return &repository.QuoteWithAuthor{
-		Quote: repository.Quote{
-			ID:        row.ID,
-			Content:   row.Content,
-			AuthorID:  row.AuthorID,
-			Source:    row.Source,
-			Tags:      row.Tags,
-			CreatedAt: row.CreatedAt,
-			UpdatedAt: row.UpdatedAt,
-		},
-		AuthorName: row.AuthorName,
-		AuthorBio:  row.AuthorBio,
-	}, nil
+	return repository.PreconditionFailed(repository.ResourceQuote, id, row.Version)
 }
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/igferreira/quotes-api/internal/service"
+	"github.com/jackc/pgx/v5"
+)
+
+// slugifyInputs covers ASCII words, punctuation runs and the letters of other scripts
+var slugifyInputs = []string{
+	"Wisdom",
+	"self esteem",
+	"Top 10 Quotes",
+	"rock'n'roll -- live!!",
+	"tab\tand \n newline",
+	"  --Hello, World!--  ",
+	"C++ & Go",
+	"Café Crème",
+	"Straße",
+	"Любовь и Жизнь",
+	"ΣΟΦΙΑ",
+	"日本語 テキスト",
+	"«Liberté»—égalité…",
+	"party🎉time",
+	"été",
+	"?!… 🎉",
+}
+
+// testConn connects to the migrated database named by TEST_DATABASE_URL, skipping the test without one
+func testConn(t *testing.T) *pgx.Conn {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close(ctx) })
+	return conn
+}
+
+func TestSlugifyMatchesSQL(t *testing.T) {
+	conn := testConn(t)
+
+	for _, in := range slugifyInputs {
+		var got string
+		if err := conn.QueryRow(context.Background(), "SELECT slugify($1::text)", in).Scan(&got); err != nil {
+			t.Fatalf("slugify(%q) error = %v", in, err)
+		}
+		if want := service.Slugify(in); got != want {
+			t.Errorf("SQL slugify(%q) = %q, Slugify() = %q", in, got, want)
+		}
+	}
+}
+
+func TestSlugifyInNonUTF8Locale(t *testing.T) {
+	conn := testConn(t)
+	ctx := context.Background()
+
+	// the C collation gives lower() and [:alnum:] the ASCII-only behavior of a C or
+	// non-UTF-8 database locale, in which the functions still agree on ASCII tags
+	for _, in := range []string{"Wisdom", "rock'n'roll -- live!!", "  --Hello, World!--  ", "C++ & Go"} {
+		var got string
+		if err := conn.QueryRow(ctx, `SELECT slugify($1::text COLLATE "C")`, in).Scan(&got); err != nil {
+			t.Fatalf("slugify(%q) error = %v", in, err)
+		}
+		if want := service.Slugify(in); got != want {
+			t.Errorf(`SQL slugify(%q COLLATE "C") = %q, Slugify() = %q`, in, got, want)
+		}
+	}
+
+	// but not on other scripts, which the check of the tags migration detects
+	var got string
+	if err := conn.QueryRow(ctx, `SELECT slugify('Ça Été Ωμέγα' COLLATE "C")`).Scan(&got); err != nil {
+		t.Fatalf("slugify() error = %v", err)
+	}
+	if want := service.Slugify("Ça Été Ωμέγα"); got == want {
+		t.Errorf(`SQL slugify() COLLATE "C" = %q agrees with Slugify(), so the migration check cannot detect ASCII-only locales`, got)
+	}
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// likeEscaper escapes the LIKE wildcards of user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Suggest suggests authors whose name, or a word of it or of an alias, starts with prefix.
+// The match runs on the author search text, served by the trigram index of author searches.
+func (r *authorRepository) Suggest(ctx context.Context, prefix string, limit int32) ([]*repository.Suggestion, error) {
+	rows, err := r.queries.SuggestAuthors(ctx, SuggestAuthorsParams{
+		Query:   prefix,
+		Pattern: likeEscaper.Replace(prefix),
+		Limit:   limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest authors: %w", err)
+	}
+
+	result := make([]*repository.Suggestion, len(rows))
+	for i, row := range rows {
+		result[i] = &repository.Suggestion{
+			Type:  repository.SuggestionAuthor,
+			Text:  row.Name,
+			ID:    row.ID,
+			Score: row.Score,
+		}
+	}
+
+	return result, nil
+}
+
+// SuggestTags suggests tags in use whose slug starts with prefix, most used first among
+// equally good matches. Only the matching tags are counted, never every quote.
+func (r *quoteRepository) SuggestTags(ctx context.Context, prefix string, limit int32) ([]*repository.Suggestion, error) {
+	rows, err := r.queries.SuggestTags(ctx, SuggestTagsParams{
+		Query:   prefix,
+		Pattern: likeEscaper.Replace(prefix),
+		Limit:   limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest tags: %w", err)
+	}
+
+	result := make([]*repository.Suggestion, len(rows))
+	for i, row := range rows {
+		result[i] = &repository.Suggestion{
+			Type:  repository.SuggestionTag,
+			Text:  row.Tag,
+			Score: row.Score,
+		}
+	}
+
+	return result, nil
+}
+
+// SuggestOpenings suggests quotes whose content starts with prefix. Text holds the full content.
+func (r *quoteRepository) SuggestOpenings(ctx context.Context, prefix string, limit int32) ([]*repository.Suggestion, error) {
+	rows, err := r.queries.SuggestQuoteOpenings(ctx, SuggestQuoteOpeningsParams{
+		Query:   prefix,
+		Pattern: likeEscaper.Replace(prefix),
+		Limit:   limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest quotes: %w", err)
+	}
+
+	result := make([]*repository.Suggestion, len(rows))
+	for i, row := range rows {
+		result[i] = &repository.Suggestion{
+			Type:  repository.SuggestionQuote,
+			Text:  row.Content,
+			ID:    row.ID,
+			Score: row.Score,
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// tagRepository implements repository.TagRepository
+type tagRepository struct {
+	db      conn
+	queries *Queries
+}
+
+// tagNotFound creates an ErrNotFound error for the tag with the given slug
+func tagNotFound(slug string) error {
+	return repository.NewError(repository.ErrNotFound, repository.ResourceTag, fmt.Sprintf("tag %q not found", slug), nil)
+}
+
+// GetBySlug retrieves a tag with its statistics
+func (r *tagRepository) GetBySlug(ctx context.Context, slug string) (*repository.Tag, error) {
+	return r.getBySlug(ctx, r.queries, slug)
+}
+
+// getBySlug retrieves a tag with the given queries, which may run in a transaction
+func (r *tagRepository) getBySlug(ctx context.Context, queries *Queries, slug string) (*repository.Tag, error) {
+	tag, err := queries.GetTagBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, tagNotFound(slug)
+		}
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	return &repository.Tag{
+		ID:          tag.ID,
+		Slug:        tag.Slug,
+		Name:        tag.Name,
+		QuoteCount:  tag.QuoteCount,
+		AuthorCount: tag.AuthorCount,
+		CreatedAt:   tag.CreatedAt,
+		UpdatedAt:   tag.UpdatedAt,
+	}, nil
+}
+
+// List retrieves a paginated list of tags, most used first
+func (r *tagRepository) List(ctx context.Context, params repository.ListParams) ([]*repository.Tag, error) {
+	tags, err := r.queries.ListTags(ctx, ListTagsParams{
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	result := make([]*repository.Tag, len(tags))
+	for i, tag := range tags {
+		result[i] = &repository.Tag{
+			ID:          tag.ID,
+			Slug:        tag.Slug,
+			Name:        tag.Name,
+			QuoteCount:  tag.QuoteCount,
+			AuthorCount: tag.AuthorCount,
+			CreatedAt:   tag.CreatedAt,
+			UpdatedAt:   tag.UpdatedAt,
+		}
+	}
+
+	return result, nil
+}
+
+// Count returns the total number of tags
+func (r *tagRepository) Count(ctx context.Context) (int64, error) {
+	count, err := r.queries.CountTags(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tags: %w", err)
+	}
+	return count, nil
+}
+
+// Rename renames a tag and replaces its slug on every quote carrying it.
+// Renaming onto the slug of another tag is a conflict; such tags are merged instead.
+func (r *tagRepository) Rename(ctx context.Context, slug string, params repository.RenameTagParams) (*repository.Tag, error) {
+	var tag *repository.Tag
+	err := r.inTx(ctx, func(queries *Queries) error {
+		_, err := queries.RenameTag(ctx, RenameTagParams{
+			NewSlug: params.Slug,
+			Name:    params.Name,
+			Slug:    slug,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return tagNotFound(slug)
+			}
+			return translateError(err, repository.ResourceTag, 0, "rename tag")
+		}
+
+		if params.Slug != slug {
+			_, err = queries.ReplaceQuoteTag(ctx, ReplaceQuoteTagParams{
+				NewSlug: params.Slug,
+				Slug:    slug,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to rename quote tags: %w", err)
+			}
+		}
+
+		tag, err = r.getBySlug(ctx, queries, params.Slug)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// Merge moves every quote carrying a tag over to another tag and deletes it
+func (r *tagRepository) Merge(ctx context.Context, slug, into string) (*repository.Tag, error) {
+	var tag *repository.Tag
+	err := r.inTx(ctx, func(queries *Queries) error {
+		if _, err := r.getBySlug(ctx, queries, into); err != nil {
+			return err
+		}
+
+		_, err := queries.ReplaceQuoteTag(ctx, ReplaceQuoteTagParams{
+			NewSlug: into,
+			Slug:    slug,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to merge quote tags: %w", err)
+		}
+
+		rows, err := queries.DeleteTag(ctx, slug)
+		if err != nil {
+			return fmt.Errorf("failed to delete tag: %w", err)
+		}
+		if rows == 0 {
+			return tagNotFound(slug)
+		}
+
+		tag, err = r.getBySlug(ctx, queries, into)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// Delete removes a tag from every quote carrying it and deletes it
+func (r *tagRepository) Delete(ctx context.Context, slug string) error {
+	return r.inTx(ctx, func(queries *Queries) error {
+		if _, err := queries.RemoveQuoteTag(ctx, slug); err != nil {
+			return fmt.Errorf("failed to remove quote tags: %w", err)
+		}
+
+		rows, err := queries.DeleteTag(ctx, slug)
+		if err != nil {
+			return fmt.Errorf("failed to delete tag: %w", err)
+		}
+		if rows == 0 {
+			return tagNotFound(slug)
+		}
+		return nil
+	})
+}
+
+// inTx runs fn with queries bound to a transaction, committing it when fn succeeds
+func (r *tagRepository) inTx(ctx context.Context, fn func(*Queries) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(r.queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -2,19 +2,42 @@ package repository
 
 import (
 	"context"
+	"strings"
 	"time"
 )
 
-// Author represents an author in the system
+// Author represents an author in the system.
+// QuoteCount and Quotes are only set when they are expanded, so that an author
+// without quotes has a count of 0 and an empty list rather than none.
 type Author struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Bio       *string   `json:"bio,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID   int64   `json:"id"`
+	Name string  `json:"name"`
+	Bio  *string `json:"bio,omitempty"`
+	AuthorProfile
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Version    int64     `json:"version"`
+	QuoteCount *int64    `json:"quote_count,omitempty"`
+	Quotes     *[]*Quote `json:"quotes,omitempty"`
+}
+
+// AuthorProfile holds the biographical metadata of an author, all of it optional.
+// Occupations are lowercase, ExternalIDs maps the name of a catalog such as viaf or
+// goodreads to the identifier of the author in it, and Aliases lists other names the
+// author is known by, such as pen names. No two authors share a name or an alias.
+type AuthorProfile struct {
+	BirthDate   *PartialDate      `json:"birth_date,omitempty"`
+	DeathDate   *PartialDate      `json:"death_date,omitempty"`
+	Nationality *string           `json:"nationality,omitempty" validate:"omitempty,max=100"`
+	Occupations []string          `json:"occupations,omitempty" validate:"omitempty,max=20,dive,required,max=100"`
+	WikidataID  *string           `json:"wikidata_id,omitempty" validate:"omitempty,max=20"`
+	ExternalIDs map[string]string `json:"external_ids,omitempty" validate:"omitempty,max=20"`
+	Aliases     []string          `json:"aliases,omitempty" validate:"omitempty,max=20,dive,required,max=255"`
+	PortraitURL *string           `json:"portrait_url,omitempty" validate:"omitempty,max=2048"`
 }
 
-// Quote represents a quote in the system
+// Quote represents a quote in the system.
+// Author is only set when it is expanded or exported.
 type Quote struct {
 	ID        int64     `json:"id"`
 	Content   string    `json:"content"`
@@ -24,6 +47,7 @@ type Quote struct {
 	Tags      []string  `json:"tags,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Version   int64     `json:"version"`
 }
 
 // QuoteWithAuthor represents a quote with its author information
@@ -33,60 +57,409 @@ type QuoteWithAuthor struct {
 	AuthorBio  *string `json:"author_bio,omitempty"`
 }
 
+// Tag represents a tag shared by quotes. Quotes refer to it by its slug, the
+// normalized form of its name, and the counts cover the quotes carrying it.
+type Tag struct {
+	ID          int64     `json:"id"`
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	QuoteCount  int64     `json:"quote_count"`
+	AuthorCount int64     `json:"author_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DateLayout is the format of calendar dates
+const DateLayout = "2006-01-02"
+
+// DailyQuote represents the quote of the day of a date, among all quotes or those with Tag.
+// Scheduled is set when the quote was picked by hand rather than drawn.
+type DailyQuote struct {
+	Date      string           `json:"date"`
+	Tag       string           `json:"tag,omitempty"`
+	Scheduled bool             `json:"scheduled"`
+	Quote     *QuoteWithAuthor `json:"quote"`
+}
+
+// ScheduleDailyQuoteParams represents parameters for scheduling the quote of the day of a date
+type ScheduleDailyQuoteParams struct {
+	QuoteID int64  `json:"quote_id" validate:"required,min=1"`
+	Tag     string `json:"tag,omitempty" validate:"omitempty,max=50"`
+}
+
+// AuthorSearchResult represents an author matching a fuzzy name search.
+// Score is the word similarity between the query and the name or the closest alias, from 0 to 1.
+type AuthorSearchResult struct {
+	Author
+	Score float32 `json:"score"`
+}
+
+// QuoteSearchResult represents a quote matching a full-text search
+type QuoteSearchResult struct {
+	QuoteWithAuthor
+	Rank      float32 `json:"rank"`
+	Highlight string  `json:"highlight"`
+}
+
+// Suggestion types
+const (
+	SuggestionAuthor = "author"
+	SuggestionTag    = "tag"
+	SuggestionQuote  = "quote"
+)
+
+// Suggestion represents a type-ahead suggestion.
+// ID refers to the suggested author or quote and is not set for tags.
+type Suggestion struct {
+	Type  string  `json:"type"`
+	Text  string  `json:"text"`
+	ID    int64   `json:"id,omitempty"`
+	Score float32 `json:"score"`
+}
+
+// SuggestParams represents parameters for type-ahead suggestions
+type SuggestParams struct {
+	Query string   `json:"q" validate:"required,max=100"`
+	Types []string `json:"types"`
+	Limit int32    `json:"limit" validate:"min=1,max=20"`
+}
+
 // CreateAuthorParams represents parameters for creating an author
 type CreateAuthorParams struct {
 	Name string  `json:"name" validate:"required,min=1,max=255"`
-	Bio  *string `json:"bio,omitempty"`
+	Bio  *string `json:"bio,omitempty" validate:"omitempty,max=5000"`
+	AuthorProfile
 }
 
 // UpdateAuthorParams represents parameters for updating an author
 type UpdateAuthorParams struct {
 	Name string  `json:"name" validate:"required,min=1,max=255"`
-	Bio  *string `json:"bio,omitempty"`
+	Bio  *string `json:"bio,omitempty" validate:"omitempty,max=5000"`
+	AuthorProfile
+
+	// ExpectedVersion makes the update fail with ErrPreconditionFailed unless the
+	// stored version matches. It is taken from the If-Match header, never the body.
+	ExpectedVersion *int64 `json:"-"`
+}
+
+// PatchAuthorParams represents parameters for partially updating an author.
+// Absent fields are left unchanged and an explicit null clears the bio or a profile field.
+type PatchAuthorParams struct {
+	Name        Optional[string]            `json:"name" validate:"required,min=1,max=255"`
+	Bio         Optional[string]            `json:"bio" validate:"omitempty,max=5000"`
+	BirthDate   Optional[PartialDate]       `json:"birth_date"`
+	DeathDate   Optional[PartialDate]       `json:"death_date"`
+	Nationality Optional[string]            `json:"nationality" validate:"omitempty,max=100"`
+	Occupations Optional[[]string]          `json:"occupations" validate:"omitempty,max=20,dive,required,max=100"`
+	WikidataID  Optional[string]            `json:"wikidata_id" validate:"omitempty,max=20"`
+	ExternalIDs Optional[map[string]string] `json:"external_ids" validate:"omitempty,max=20"`
+	Aliases     Optional[[]string]          `json:"aliases" validate:"omitempty,max=20,dive,required,max=255"`
+	PortraitURL Optional[string]            `json:"portrait_url" validate:"omitempty,max=2048"`
+
+	ExpectedVersion *int64 `json:"-"` // see UpdateAuthorParams
+}
+
+// AddAliasParams represents parameters for adding an alias to an author
+type AddAliasParams struct {
+	Name string `json:"name" validate:"required,min=1,max=255"`
+
+	ExpectedVersion *int64 `json:"-"` // see UpdateAuthorParams
 }
 
 // CreateQuoteParams represents parameters for creating a quote
 type CreateQuoteParams struct {
-	Content  string   `json:"content" validate:"required,min=1"`
+	Content  string   `json:"content" validate:"required,min=1,max=5000"`
 	AuthorID int64    `json:"author_id" validate:"required,min=1"`
 	Source   *string  `json:"source,omitempty" validate:"omitempty,max=500"`
-	Tags     []string `json:"tags,omitempty"`
+	Tags     []string `json:"tags,omitempty" validate:"omitempty,max=20,dive,required,max=50"`
 }
 
 // UpdateQuoteParams represents parameters for updating a quote
 type UpdateQuoteParams struct {
-	Content  string   `json:"content" validate:"required,min=1"`
+	Content  string   `json:"content" validate:"required,min=1,max=5000"`
 	AuthorID int64    `json:"author_id" validate:"required,min=1"`
 	Source   *string  `json:"source,omitempty" validate:"omitempty,max=500"`
-	Tags     []string `json:"tags,omitempty"`
+	Tags     []string `json:"tags,omitempty" validate:"omitempty,max=20,dive,required,max=50"`
+
+	ExpectedVersion *int64 `json:"-"` // see UpdateAuthorParams
+}
+
+// PatchQuoteParams represents parameters for partially updating a quote.
+// Absent fields are left unchanged and an explicit null clears the source or tags.
+type PatchQuoteParams struct {
+	Content  Optional[string]   `json:"content" validate:"required,min=1,max=5000"`
+	AuthorID Optional[int64]    `json:"author_id" validate:"required,min=1"`
+	Source   Optional[string]   `json:"source" validate:"omitempty,max=500"`
+	Tags     Optional[[]string] `json:"tags" validate:"omitempty,max=20,dive,required,max=50"`
+
+	ExpectedVersion *int64 `json:"-"` // see UpdateAuthorParams
+}
+
+// RenameTagParams represents parameters for renaming a tag on every quote
+type RenameTagParams struct {
+	Name string `json:"name" validate:"required,min=1,max=50"`
+
+	// Slug is the normalized name, derived from Name by the service
+	Slug string `json:"-"`
+}
+
+// MergeTagParams represents parameters for merging a tag into another one
+type MergeTagParams struct {
+	Into string `json:"into" validate:"required,min=1,max=50"`
+}
+
+// Operations of a batch
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Operation is one write of a batch: OpCreate creates a resource from Create,
+// OpUpdate replaces resource ID with Update and OpDelete deletes resource ID.
+// Updates and deletes fail with ErrPreconditionFailed when ExpectedVersion is
+// set and does not match the stored version.
+type Operation[C, U any] struct {
+	Op              string
+	ID              int64
+	ExpectedVersion *int64
+	Create          C
+	Update          U
+}
+
+// AuthorOperation is one write of an author batch
+type AuthorOperation = Operation[CreateAuthorParams, UpdateAuthorParams]
+
+// QuoteOperation is one write of a quote batch
+type QuoteOperation = Operation[CreateQuoteParams, UpdateQuoteParams]
+
+// OperationResult is the outcome of one operation of a batch: the created or
+// updated resource, nothing for a delete, or the error the operation failed with
+type OperationResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// Related resources that can be expanded in a response
+const (
+	ExpandAuthor     = "author"
+	ExpandQuotes     = "quotes"
+	ExpandQuoteCount = "quote_count"
+)
+
+// AuthorExpansion selects the related resources embedded in authors: with Quotes
+// their newest quotes, at most QuotesLimit each, and with QuoteCount their number
+// of quotes
+type AuthorExpansion struct {
+	Quotes      bool
+	QuotesLimit int32
+	QuoteCount  bool
 }
 
-// ListParams represents pagination parameters
+// ListParams represents pagination parameters.
+// Sort orders the list, each list falling back to its default sort when it is empty.
+// When Cursor is set, the page is fetched with a keyset predicate and Offset is ignored.
+// SkipTotal lets callers that do not need the total count save the count query.
 type ListParams struct {
-	Limit  int32 `json:"limit" validate:"min=1,max=100"`
-	Offset int32 `json:"offset" validate:"min=0"`
+	Limit     int32   `json:"limit" validate:"min=1,max=100"`
+	Offset    int32   `json:"offset" validate:"min=0"`
+	Sort      Sort    `json:"-"`
+	Cursor    *Cursor `json:"-"`
+	SkipTotal bool    `json:"-"`
+}
+
+// RandomParams represents parameters for picking random quotes.
+// The same seed picks the same quotes as long as the quotes do not change.
+type RandomParams struct {
+	Count int32  `json:"count" validate:"min=1,max=50"`
+	Seed  *int64 `json:"seed,omitempty"`
+}
+
+// Sort fields
+const (
+	SortID         = "id"
+	SortName       = "name"
+	SortCreatedAt  = "created_at"
+	SortUpdatedAt  = "updated_at"
+	SortAuthorName = "author_name"
+	SortLength     = "length"
+	SortRank       = "rank"
+	SortScore      = "score"
+)
+
+// Fields each list can be sorted by
+var (
+	AuthorSortFields       = []string{SortName, SortCreatedAt, SortUpdatedAt, SortID}
+	AuthorSearchSortFields = []string{SortScore, SortName, SortCreatedAt, SortUpdatedAt, SortID}
+	QuoteSortFields        = []string{SortCreatedAt, SortUpdatedAt, SortAuthorName, SortLength, SortID}
+	QuoteSearchSortFields  = []string{SortRank, SortCreatedAt, SortUpdatedAt, SortAuthorName, SortLength, SortID}
+)
+
+// Default sort of each list
+var (
+	DefaultAuthorSort       = Sort{{Field: SortName}}
+	DefaultAuthorSearchSort = Sort{{Field: SortScore, Desc: true}}
+	DefaultQuoteSort        = Sort{{Field: SortCreatedAt, Desc: true}}
+	DefaultQuoteSearchSort  = Sort{{Field: SortRank, Desc: true}}
+)
+
+// SortField is a field a list is ordered by
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Sort orders a list by its first field, then by the next ones to break ties
+type Sort []SortField
+
+// String formats the sort as a comma-separated list of fields, prefixed with - when descending
+func (s Sort) String() string {
+	fields := make([]string, len(s))
+	for i, f := range s {
+		fields[i] = f.Field
+		if f.Desc {
+			fields[i] = "-" + f.Field
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// WithTieBreaker returns the sort ending with the ID, which makes the order of a list stable.
+// Fields after the ID are dropped; without one, the ID is appended in the direction of the last field.
+func (s Sort) WithTieBreaker() Sort {
+	for i, f := range s {
+		if f.Field == SortID {
+			return s[:i+1]
+		}
+	}
+
+	var desc bool
+	if len(s) > 0 {
+		desc = s[len(s)-1].Desc
+	}
+	return append(s[:len(s):len(s)], SortField{Field: SortID, Desc: desc})
 }
 
-// AuthorRepository defines the interface for author data access
+// QuoteFilter narrows down a list of quotes. Every set field must match; the zero value matches all quotes.
+type QuoteFilter struct {
+	// Tags the quotes carry: all of them when MatchAllTags is set, any of them otherwise
+	Tags         []string
+	MatchAllTags bool
+	ExcludeTags  []string
+
+	AuthorIDs []int64
+
+	// Source matches quotes whose source contains it, ignoring case
+	Source string
+
+	// MaxLength is the maximum number of characters of the content, 0 for no limit
+	MaxLength int
+
+	ExcludeIDs []int64
+
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedSince  *time.Time
+
+	// Query is a full-text search query in web search syntax
+	Query string
+}
+
+// AuthorFilter narrows down a list of authors. Every set field must match; the zero value matches all authors.
+type AuthorFilter struct {
+	// EraFrom and EraTo are years bounding the lifetime of the authors, who match when
+	// they lived at some point between them. Authors without a death date are taken to
+	// have lived MaxLifespan years; those without any date never match.
+	EraFrom *int32
+	EraTo   *int32
+
+	// Nationality matches ignoring case and accents
+	Nationality string
+
+	// Occupations the authors had, any of them
+	Occupations []string
+}
+
+// MaxLifespan is the lifetime assumed by era filters for authors without a death date
+const MaxLifespan = 100
+
+// Cursor is a keyset position in a sorted list.
+// Values holds the values of the sort fields at the position as text, in sort order and
+// ending with the ID: times in RFC 3339 with nanoseconds, numbers in their shortest form.
+type Cursor struct {
+	Values []string
+
+	// Backward selects the page before the position instead of the one after it
+	Backward bool
+}
+
+// AuthorRepository defines the interface for author data access.
+// Names and aliases are unique across authors, ignoring case, accents and spacing:
+// writes giving an author a name another one goes by fail with ErrConflict.
 type AuthorRepository interface {
 	Create(ctx context.Context, params CreateAuthorParams) (*Author, error)
+	CreateMany(ctx context.Context, params []CreateAuthorParams) ([]*Author, error)
+	CreateEach(ctx context.Context, params []CreateAuthorParams) ([]*Author, []error, error)
 	GetByID(ctx context.Context, id int64) (*Author, error)
-	List(ctx context.Context, params ListParams) ([]*Author, error)
+	GetByName(ctx context.Context, name string) (*Author, error)
+	List(ctx context.Context, filter AuthorFilter, params ListParams) ([]*Author, error)
 	Update(ctx context.Context, id int64, params UpdateAuthorParams) (*Author, error)
-	Delete(ctx context.Context, id int64) error
-	Count(ctx context.Context) (int64, error)
-	Search(ctx context.Context, query string, params ListParams) ([]*Author, error)
+	Patch(ctx context.Context, id int64, params PatchAuthorParams) (*Author, error)
+	Delete(ctx context.Context, id int64, expectedVersion *int64) error
+	AddAlias(ctx context.Context, id int64, params AddAliasParams) (*Author, error)
+	RemoveAlias(ctx context.Context, id int64, alias string, expectedVersion *int64) error
+	Count(ctx context.Context, filter AuthorFilter) (int64, error)
+	CountSearch(ctx context.Context, query string, filter AuthorFilter) (int64, error)
+	Search(ctx context.Context, query string, filter AuthorFilter, params ListParams) ([]*AuthorSearchResult, error)
+	Suggest(ctx context.Context, prefix string, limit int32) ([]*Suggestion, error)
 }
 
 // QuoteRepository defines the interface for quote data access
 type QuoteRepository interface {
 	Create(ctx context.Context, params CreateQuoteParams) (*Quote, error)
+	CreateMany(ctx context.Context, params []CreateQuoteParams) ([]*Quote, error)
+	CreateEach(ctx context.Context, params []CreateQuoteParams) ([]*Quote, []error, error)
 	GetByID(ctx context.Context, id int64) (*QuoteWithAuthor, error)
-	List(ctx context.Context, params ListParams) ([]*QuoteWithAuthor, error)
-	ListByAuthor(ctx context.Context, authorID int64, params ListParams) ([]*QuoteWithAuthor, error)
+	List(ctx context.Context, filter QuoteFilter, params ListParams) ([]*QuoteWithAuthor, error)
 	Update(ctx context.Context, id int64, params UpdateQuoteParams) (*Quote, error)
-	Delete(ctx context.Context, id int64) error
+	Patch(ctx context.Context, id int64, params PatchQuoteParams) (*Quote, error)
+	Delete(ctx context.Context, id int64, expectedVersion *int64) error
+	Count(ctx context.Context, filter QuoteFilter) (int64, error)
+	CountByAuthor(ctx context.Context, authorID int64) (int64, error)
+	Search(ctx context.Context, filter QuoteFilter, params ListParams) ([]*QuoteSearchResult, error)
+	SuggestTags(ctx context.Context, prefix string, limit int32) ([]*Suggestion, error)
+	SuggestOpenings(ctx context.Context, prefix string, limit int32) ([]*Suggestion, error)
+	Random(ctx context.Context, filter QuoteFilter, params RandomParams) ([]*QuoteWithAuthor, error)
+	Export(ctx context.Context, filter QuoteFilter, withAuthors bool, fn func(*Quote) error) error
+	ListByAuthors(ctx context.Context, authorIDs []int64, perAuthor int32) ([]*Quote, error)
+	CountByAuthors(ctx context.Context, authorIDs []int64) (map[int64]int64, error)
+}
+
+// Transactor runs functions against author and quote repositories bound to a
+// database transaction, which is committed when the function succeeds and
+// rolled back otherwise
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(AuthorRepository, QuoteRepository) error) error
+}
+
+// TagRepository defines the interface for tag data access.
+// Tags are created when a quote first uses them; renaming, merging and deleting
+// a tag rewrites the tags of every quote carrying it.
+type TagRepository interface {
+	GetBySlug(ctx context.Context, slug string) (*Tag, error)
+	List(ctx context.Context, params ListParams) ([]*Tag, error)
 	Count(ctx context.Context) (int64, error)
-	Search(ctx context.Context, query string, params ListParams) ([]*QuoteWithAuthor, error)
-	GetRandom(ctx context.Context) (*QuoteWithAuthor, error)
+	Rename(ctx context.Context, slug string, params RenameTagParams) (*Tag, error)
+	Merge(ctx context.Context, slug, into string) (*Tag, error)
+	Delete(ctx context.Context, slug string) error
+}
+
+// DailyQuoteRepository defines the interface for quote of the day data access.
+// Days are dates at midnight UTC and the empty tag stands for all quotes.
+type DailyQuoteRepository interface {
+	Get(ctx context.Context, tag string, day time.Time) (*DailyQuote, error)
+	Create(ctx context.Context, tag string, day time.Time, quoteID int64) error
+	Schedule(ctx context.Context, tag string, day time.Time, quoteID int64) error
+	Unschedule(ctx context.Context, tag string, day time.Time) error
+	QuoteIDs(ctx context.Context, tag string, from, to time.Time) ([]int64, error)
 }
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSortWithTieBreaker(t *testing.T) {
+	tests := []struct {
+		name string
+		sort Sort
+		want Sort
+	}{
+		{
+			name: "empty",
+			sort: nil,
+			want: Sort{{Field: SortID}},
+		},
+		{
+			name: "ascending",
+			sort: Sort{{Field: SortName}},
+			want: Sort{{Field: SortName}, {Field: SortID}},
+		},
+		{
+			name: "follows the last field",
+			sort: Sort{{Field: SortName}, {Field: SortCreatedAt, Desc: true}},
+			want: Sort{{Field: SortName}, {Field: SortCreatedAt, Desc: true}, {Field: SortID, Desc: true}},
+		},
+		{
+			name: "ends with the ID",
+			sort: Sort{{Field: SortLength}, {Field: SortID, Desc: true}},
+			want: Sort{{Field: SortLength}, {Field: SortID, Desc: true}},
+		},
+		{
+			name: "fields after the ID are dropped",
+			sort: Sort{{Field: SortID}, {Field: SortName, Desc: true}, {Field: SortCreatedAt}},
+			want: Sort{{Field: SortID}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sort.WithTieBreaker(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WithTieBreaker() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortWithTieBreakerKeepsReceiver(t *testing.T) {
+	// appending the tie-breaker must not write into the backing array of the sort
+	backing := make(Sort, 2, 4)
+	backing[0] = SortField{Field: SortName}
+	backing[1] = SortField{Field: SortLength, Desc: true}
+	sort := backing[:1]
+
+	sort.WithTieBreaker()
+	if backing[1] != (SortField{Field: SortLength, Desc: true}) {
+		t.Errorf("WithTieBreaker() overwrote the backing array: %v", backing[:2])
+	}
+}
+
+func TestSortString(t *testing.T) {
+	sort := Sort{{Field: SortAuthorName}, {Field: SortCreatedAt, Desc: true}, {Field: SortID, Desc: true}}
+	if got, want := sort.String(), "author_name,-created_at,-id"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestAuthorQuotesJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		quotes *[]*Quote
+		want   string
+	}{
+		{"not expanded", nil, ""},
+		{"expanded without quotes", &[]*Quote{}, `"quotes":[]`},
+		{"expanded", &[]*Quote{{ID: 3}}, `"quotes":[{"id":3,`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(Author{ID: 1, Name: "Anonymous", Quotes: tt.quotes})
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if tt.want == "" {
+				if strings.Contains(string(data), `"quotes"`) {
+					t.Errorf("Marshal() = %s, want no quotes", data)
+				}
+				return
+			}
+			if !strings.Contains(string(data), tt.want) {
+				t.Errorf("Marshal() = %s, want it to contain %s", data, tt.want)
+			}
+		})
+	}
+}
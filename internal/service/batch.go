@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// BatchAuthors applies author operations in order. An atomic batch runs in a single
+// transaction and stops at the first failing operation, which is returned as a
+// *repository.BatchError with nothing written. Otherwise every operation is applied
+// on its own and its outcome, success or failure, is reported in its result.
+func (s *Service) BatchAuthors(ctx context.Context, ops []repository.AuthorOperation, atomic bool) ([]repository.OperationResult[*repository.Author], error) {
+	b := batch[repository.CreateAuthorParams, repository.UpdateAuthorParams, *repository.Author]{
+		resource: repository.ResourceAuthor,
+		prepare: func(_ context.Context, _ *Service, params repository.CreateAuthorParams, _ []repository.CreateAuthorParams) (repository.CreateAuthorParams, error) {
+			// names taken by other authors, including earlier ones of the batch, are rejected on insert
+			return params, normalizeProfile(params.Name, &params.AuthorProfile)
+		},
+		createMany: func(ctx context.Context, s *Service, params []repository.CreateAuthorParams) ([]*repository.Author, error) {
+			return s.authorRepo.CreateMany(ctx, params)
+		},
+		createEach: func(ctx context.Context, s *Service, params []repository.CreateAuthorParams) ([]*repository.Author, []error, error) {
+			return s.authorRepo.CreateEach(ctx, params)
+		},
+		update: func(ctx context.Context, s *Service, id int64, params repository.UpdateAuthorParams, expectedVersion *int64) (*repository.Author, error) {
+			params.ExpectedVersion = expectedVersion
+			return s.UpdateAuthor(ctx, id, params)
+		},
+		delete: func(ctx context.Context, s *Service, id int64, expectedVersion *int64) error {
+			return s.DeleteAuthor(ctx, id, expectedVersion)
+		},
+	}
+	return b.run(ctx, s, ops, atomic)
+}
+
+// BatchQuotes applies quote operations in order, atomically or not, like BatchAuthors
+func (s *Service) BatchQuotes(ctx context.Context, ops []repository.QuoteOperation, atomic bool) ([]repository.OperationResult[*repository.Quote], error) {
+	// most batches add many quotes of few authors
+	knownAuthors := make(map[int64]bool)
+
+	b := batch[repository.CreateQuoteParams, repository.UpdateQuoteParams, *repository.Quote]{
+		resource: repository.ResourceQuote,
+		prepare: func(ctx context.Context, s *Service, params repository.CreateQuoteParams, _ []repository.CreateQuoteParams) (repository.CreateQuoteParams, error) {
+			if !knownAuthors[params.AuthorID] {
+				if err := s.ensureAuthorExists(ctx, params.AuthorID); err != nil {
+					return params, err
+				}
+				knownAuthors[params.AuthorID] = true
+			}
+
+			tags, err := normalizeTags(params.Tags)
+			if err != nil {
+				return params, err
+			}
+			params.Tags = tags
+			return params, nil
+		},
+		createMany: func(ctx context.Context, s *Service, params []repository.CreateQuoteParams) ([]*repository.Quote, error) {
+			return s.quoteRepo.CreateMany(ctx, params)
+		},
+		createEach: func(ctx context.Context, s *Service, params []repository.CreateQuoteParams) ([]*repository.Quote, []error, error) {
+			return s.quoteRepo.CreateEach(ctx, params)
+		},
+		update: func(ctx context.Context, s *Service, id int64, params repository.UpdateQuoteParams, expectedVersion *int64) (*repository.Quote, error) {
+			params.ExpectedVersion = expectedVersion
+			return s.UpdateQuote(ctx, id, params)
+		},
+		delete: func(ctx context.Context, s *Service, id int64, expectedVersion *int64) error {
+			return s.DeleteQuote(ctx, id, expectedVersion)
+		},
+	}
+	return b.run(ctx, s, ops, atomic)
+}
+
+// batch applies the operations of a batch of one resource with create params C,
+// update params U and resources T
+type batch[C, U, T any] struct {
+	resource string
+
+	// prepare checks and normalizes a create; pending holds the creates
+	// prepared before it that are inserted along with it
+	prepare    func(ctx context.Context, s *Service, params C, pending []C) (C, error)
+	createMany func(ctx context.Context, s *Service, params []C) ([]T, error)
+	createEach func(ctx context.Context, s *Service, params []C) ([]T, []error, error)
+	update     func(ctx context.Context, s *Service, id int64, params U, expectedVersion *int64) (T, error)
+	delete     func(ctx context.Context, s *Service, id int64, expectedVersion *int64) error
+}
+
+// run applies ops in order. Consecutive creates are inserted together.
+func (b *batch[C, U, T]) run(ctx context.Context, s *Service, ops []repository.Operation[C, U], atomic bool) ([]repository.OperationResult[T], error) {
+	results := make([]repository.OperationResult[T], len(ops))
+
+	apply := func(s *Service) error {
+		for start := 0; start < len(ops); {
+			end := start + 1
+			if ops[start].Op == repository.OpCreate {
+				for end < len(ops) && ops[end].Op == repository.OpCreate {
+					end++
+				}
+				if err := b.create(ctx, s, ops[start:end], results[start:end], atomic); err != nil {
+					return offsetBatchError(err, start)
+				}
+				start = end
+				continue
+			}
+
+			if atomic {
+				value, err := b.apply(ctx, s, ops[start])
+				if err != nil {
+					return &repository.BatchError{Index: start, Err: err}
+				}
+				results[start].Value = value
+			} else {
+				// the checks and the write of an operation see the same data
+				results[start].Err = s.withTx(ctx, func(tx *Service) error {
+					var err error
+					results[start].Value, err = b.apply(ctx, tx, ops[start])
+					return err
+				})
+			}
+			start = end
+		}
+		return nil
+	}
+
+	if !atomic {
+		return results, apply(s)
+	}
+	if err := s.withTx(ctx, apply); err != nil {
+		var batchErr *repository.BatchError
+		if errors.As(err, &batchErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to run %s batch: %w", b.resource, err)
+	}
+	return results, nil
+}
+
+// apply applies an update or a delete
+func (b *batch[C, U, T]) apply(ctx context.Context, s *Service, op repository.Operation[C, U]) (T, error) {
+	var none T
+	switch op.Op {
+	case repository.OpUpdate:
+		return b.update(ctx, s, op.ID, op.Update, op.ExpectedVersion)
+	case repository.OpDelete:
+		return none, b.delete(ctx, s, op.ID, op.ExpectedVersion)
+	default:
+		return none, repository.NewError(repository.ErrValidation, b.resource, fmt.Sprintf("unknown operation %q", op.Op), nil)
+	}
+}
+
+// create inserts a run of creates, recording the created resources in results. An
+// atomic batch inserts them in one round trip and fails on the first rejected create;
+// otherwise each create is inserted on its own and rejected creates get their error.
+func (b *batch[C, U, T]) create(ctx context.Context, s *Service, ops []repository.Operation[C, U], results []repository.OperationResult[T], atomic bool) error {
+	var params []C
+	var indexes []int
+	for i, op := range ops {
+		p, err := b.prepare(ctx, s, op.Create, params)
+		if err != nil {
+			if atomic {
+				return &repository.BatchError{Index: i, Err: err}
+			}
+			results[i].Err = err
+			continue
+		}
+		params = append(params, p)
+		indexes = append(indexes, i)
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	if !atomic {
+		created, errs, err := b.createEach(ctx, s, params)
+		for j, i := range indexes {
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			results[i].Value, results[i].Err = created[j], errs[j]
+		}
+		return nil
+	}
+
+	created, err := b.createMany(ctx, s, params)
+	if err != nil {
+		var batchErr *repository.BatchError
+		if errors.As(err, &batchErr) {
+			return &repository.BatchError{Index: indexes[batchErr.Index], Err: batchErr.Err}
+		}
+		return err
+	}
+	for j, value := range created {
+		results[indexes[j]].Value = value
+	}
+	return nil
+}
+
+// offsetBatchError shifts the position reported by a *repository.BatchError
+// for operations starting at offset in the batch
+func offsetBatchError(err error, offset int) error {
+	var batchErr *repository.BatchError
+	if errors.As(err, &batchErr) {
+		return &repository.BatchError{Index: batchErr.Index + offset, Err: batchErr.Err}
+	}
+	return err
+}
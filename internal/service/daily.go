@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"time"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// DailyQuote returns the quote of the day of a date (midnight UTC), among all quotes or
+// those with tag. The first request for a date draws the quote, seeded by the date and
+// tag so that concurrent requests agree, and records it so that it never changes. Only
+// the dates from yesterday to tomorrow, the current date in some time zone, are drawn:
+// the others only have a quote when it was drawn or scheduled.
+// Quotes of the day within repeatDays of the date are avoided when others are left.
+func (s *Service) DailyQuote(ctx context.Context, day time.Time, tag string, repeatDays int) (*repository.DailyQuote, error) {
+	tag = Slugify(tag)
+
+	daily, err := s.dailyRepo.Get(ctx, tag, day)
+	if err == nil {
+		return daily, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get quote of the day: %w", err)
+	}
+
+	// dates past in every time zone were never drawn, and dates ahead of every time zone are
+	// only known once scheduled; requests cannot record quotes for them
+	if day.Before(today().AddDate(0, 0, -1)) || day.After(today().AddDate(0, 0, 1)) {
+		return nil, err
+	}
+
+	filter := repository.QuoteFilter{}
+	if tag != "" {
+		filter.Tags = []string{tag}
+	}
+	if repeatDays > 0 {
+		filter.ExcludeIDs, err = s.dailyRepo.QuoteIDs(ctx, tag, day.AddDate(0, 0, -repeatDays), day.AddDate(0, 0, repeatDays))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get recent quotes of the day: %w", err)
+		}
+	}
+
+	seed := dailySeed(day, tag)
+	params := repository.RandomParams{Count: 1, Seed: &seed}
+	quotes, err := s.quoteRepo.Random(ctx, filter, params)
+	if err == nil && len(quotes) == 0 && len(filter.ExcludeIDs) > 0 {
+		// every quote was shown recently, repeat one
+		filter.ExcludeIDs = nil
+		quotes, err = s.quoteRepo.Random(ctx, filter, params)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to draw quote of the day: %w", err)
+	}
+	if len(quotes) == 0 {
+		return nil, repository.NewError(repository.ErrNotFound, repository.ResourceQuote, "no quotes found", nil)
+	}
+
+	if err := s.dailyRepo.Create(ctx, tag, day, quotes[0].ID); err != nil {
+		return nil, fmt.Errorf("failed to record quote of the day: %w", err)
+	}
+
+	// read back the recorded quote in case a concurrent request recorded another one first
+	daily, err = s.dailyRepo.Get(ctx, tag, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quote of the day: %w", err)
+	}
+	return daily, nil
+}
+
+// ScheduleDailyQuote sets the quote of the day of a future date, as long as it was not drawn yet
+func (s *Service) ScheduleDailyQuote(ctx context.Context, day time.Time, params repository.ScheduleDailyQuoteParams) (*repository.DailyQuote, error) {
+	if !day.After(today()) {
+		return nil, repository.NewError(repository.ErrConflict, repository.ResourceQuote,
+			fmt.Sprintf("quote of the day for %s is part of the history", day.Format(repository.DateLayout)), nil)
+	}
+
+	quote, err := s.quoteRepo.GetByID(ctx, params.QuoteID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, repository.NewError(repository.ErrConstraint, repository.ResourceQuote,
+				fmt.Sprintf("quote %d does not exist", params.QuoteID), nil)
+		}
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+
+	tag := Slugify(params.Tag)
+	if tag != "" && !slices.Contains(quote.Tags, tag) {
+		return nil, repository.NewError(repository.ErrConstraint, repository.ResourceQuote,
+			fmt.Sprintf("quote %d is not tagged %q", params.QuoteID, tag), nil)
+	}
+
+	if err := s.dailyRepo.Schedule(ctx, tag, day, params.QuoteID); err != nil {
+		return nil, fmt.Errorf("failed to schedule quote of the day: %w", err)
+	}
+
+	daily, err := s.dailyRepo.Get(ctx, tag, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quote of the day: %w", err)
+	}
+	return daily, nil
+}
+
+// UnscheduleDailyQuote removes the scheduled quote of the day of a future date
+func (s *Service) UnscheduleDailyQuote(ctx context.Context, day time.Time, tag string) error {
+	if !day.After(today()) {
+		return repository.NewError(repository.ErrConflict, repository.ResourceQuote,
+			fmt.Sprintf("quote of the day for %s is part of the history", day.Format(repository.DateLayout)), nil)
+	}
+
+	if err := s.dailyRepo.Unschedule(ctx, Slugify(tag), day); err != nil {
+		return fmt.Errorf("failed to unschedule quote of the day: %w", err)
+	}
+	return nil
+}
+
+// today returns the current date at midnight UTC
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// dailySeed derives the seed drawing the quote of the day of a date and tag
+func dailySeed(day time.Time, tag string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(day.Format(repository.DateLayout) + "/" + tag))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/importer"
+	"github.com/igferreira/quotes-api/internal/repository"
+	"github.com/igferreira/quotes-api/internal/validation"
+)
+
+// importChunkSize is the number of quotes inserted together during an import
+const importChunkSize = 500
+
+// ImportQuotes imports the quotes read from r. Authors are resolved by name or alias,
+// ignoring case, accents and spacing, and with opts.CreateAuthors, created when they do
+// not exist. Invalid rows are reported and skipped; valid ones are inserted in chunks as
+// they are read, so an import is not all-or-nothing. A fatal error stops the import and is returned with the report so far.
+func (s *Service) ImportQuotes(ctx context.Context, r importer.Reader, opts importer.Options) (*importer.Report, error) {
+	imp := quoteImport{
+		service: s,
+		opts:    opts,
+		authors: make(map[string]int64),
+		report:  &importer.Report{DryRun: opts.DryRun, Errors: []importer.RowError{}},
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return imp.report, err
+		}
+
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var rowErr *importer.RowError
+			if !errors.As(err, &rowErr) {
+				return imp.report, err
+			}
+			imp.report.Rows++
+			imp.report.Fail(*rowErr)
+			continue
+		}
+
+		imp.report.Rows++
+		if err := imp.add(ctx, record); err != nil {
+			return imp.report, err
+		}
+	}
+
+	if err := imp.flush(ctx); err != nil {
+		return imp.report, err
+	}
+	return imp.report, nil
+}
+
+// quoteImport holds the state of a running import
+type quoteImport struct {
+	service *Service
+	opts    importer.Options
+	report  *importer.Report
+
+	// IDs of the authors resolved so far by lowercase name with collapsed spaces;
+	// 0 for authors a dry run would create
+	authors map[string]int64
+
+	// quotes waiting to be inserted and their rows
+	pending []repository.CreateQuoteParams
+	rows    []int
+}
+
+// add checks a record and queues its quote for insertion. Only fatal errors are returned.
+func (imp *quoteImport) add(ctx context.Context, record *importer.Record) error {
+	if err := validation.Validate(record); err != nil {
+		imp.fail(record.Row, err)
+		return nil
+	}
+
+	tags, err := normalizeTags(record.Tags)
+	if err != nil {
+		imp.fail(record.Row, err)
+		return nil
+	}
+
+	authorID, err := imp.resolveAuthor(ctx, record)
+	if err != nil {
+		var domainErr *repository.Error
+		if !errors.As(err, &domainErr) {
+			return err
+		}
+		imp.fail(record.Row, err)
+		return nil
+	}
+
+	if imp.opts.DryRun {
+		imp.report.Imported++
+		return nil
+	}
+
+	imp.pending = append(imp.pending, repository.CreateQuoteParams{
+		Content:  record.Content,
+		AuthorID: authorID,
+		Source:   record.Source,
+		Tags:     tags,
+	})
+	imp.rows = append(imp.rows, record.Row)
+	if len(imp.pending) >= importChunkSize {
+		return imp.flush(ctx)
+	}
+	return nil
+}
+
+// resolveAuthor returns the ID of the author of a record, creating the author when allowed
+func (imp *quoteImport) resolveAuthor(ctx context.Context, record *importer.Record) (int64, error) {
+	key := strings.Join(strings.Fields(strings.ToLower(record.Author)), " ")
+	if id, ok := imp.authors[key]; ok {
+		return id, nil
+	}
+
+	id, err := imp.lookupAuthor(ctx, record.Author)
+	if err == nil {
+		imp.authors[key] = id
+		return id, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return 0, err
+	}
+
+	if !imp.opts.CreateAuthors {
+		return 0, repository.NewError(repository.ErrConstraint, repository.ResourceQuote,
+			fmt.Sprintf("author %q does not exist", record.Author), nil)
+	}
+
+	if !imp.opts.DryRun {
+		author, err := imp.service.authorRepo.Create(ctx, repository.CreateAuthorParams{
+			Name: record.Author,
+			Bio:  record.AuthorBio,
+		})
+		if errors.Is(err, repository.ErrConflict) {
+			// created since it was looked up, by another import or request
+			if id, err = imp.lookupAuthor(ctx, record.Author); err != nil {
+				return 0, err
+			}
+			imp.authors[key] = id
+			return id, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to create author: %w", err)
+		}
+		id = author.ID
+	}
+	imp.authors[key] = id
+	imp.report.AuthorsCreated++
+	return id, nil
+}
+
+// lookupAuthor returns the ID of the author going by a name or an alias
+func (imp *quoteImport) lookupAuthor(ctx context.Context, name string) (int64, error) {
+	author, err := imp.service.authorRepo.GetByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("failed to get author: %w", err)
+	}
+	return author.ID, nil
+}
+
+// flush inserts the pending quotes, each on its own so that a rejected quote is reported
+// without holding back the others
+func (imp *quoteImport) flush(ctx context.Context) error {
+	if len(imp.pending) == 0 {
+		return nil
+	}
+
+	_, errs, err := imp.service.quoteRepo.CreateEach(ctx, imp.pending)
+	if err != nil {
+		return fmt.Errorf("failed to import quotes: %w", err)
+	}
+	for j, err := range errs {
+		if err != nil {
+			imp.fail(imp.rows[j], err)
+			continue
+		}
+		imp.report.Imported++
+	}
+
+	imp.pending = imp.pending[:0]
+	imp.rows = imp.rows[:0]
+	return nil
+}
+
+// fail reports a row rejected with a domain error
+func (imp *quoteImport) fail(row int, err error) {
+	rowErr := importer.RowError{Row: row, Message: err.Error()}
+
+	var domainErr *repository.Error
+	if errors.As(err, &domainErr) {
+		rowErr.Fields = domainErr.Fields
+	}
+	imp.report.Fail(rowErr)
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// wikidataIDPattern matches the identifier of a Wikidata item, such as Q7245
+var wikidataIDPattern = regexp.MustCompile(`^Q[1-9][0-9]*$`)
+
+// catalogPattern matches the names of the catalogs of external identifiers, such as viaf
+var catalogPattern = regexp.MustCompile(`^[a-z0-9_]{1,50}$`)
+
+// maxExternalIDLength is the maximum number of characters of an external identifier
+const maxExternalIDLength = 255
+
+// maxAliases is the maximum number of aliases of an author, as validated on AuthorProfile
+const maxAliases = 20
+
+// normalizeProfile normalizes the profile of an author named name, dropping duplicate
+// occupations and aliases along with aliases repeating the name, and checks what the
+// validation tags cannot
+func normalizeProfile(name string, p *repository.AuthorProfile) error {
+	p.Occupations = normalizeOccupations(p.Occupations)
+	p.Aliases = normalizeAliases(name, p.Aliases)
+	p.ExternalIDs = normalizeExternalIDs(p.ExternalIDs)
+
+	var fields []repository.FieldError
+	fields = appendDateError(fields, "birth_date", p.BirthDate)
+	fields = appendDateError(fields, "death_date", p.DeathDate)
+	fields = appendLifespanError(fields, p.BirthDate, p.DeathDate)
+	fields = appendWikidataIDError(fields, p.WikidataID)
+	fields = appendExternalIDsError(fields, p.ExternalIDs)
+	fields = appendPortraitURLError(fields, p.PortraitURL)
+	return profileError(fields)
+}
+
+// normalizeProfilePatch normalizes and checks the profile fields present in a partial
+// update like normalizeProfile. Aliases are only compared with the name when it is
+// updated too, and the lifespan only checked when both dates are.
+func normalizeProfilePatch(params *repository.PatchAuthorParams) error {
+	var name string
+	if params.Name.Value != nil {
+		name = *params.Name.Value
+	}
+	if params.Occupations.Value != nil {
+		*params.Occupations.Value = normalizeOccupations(*params.Occupations.Value)
+	}
+	if params.Aliases.Value != nil {
+		*params.Aliases.Value = normalizeAliases(name, *params.Aliases.Value)
+	}
+	if params.ExternalIDs.Value != nil {
+		*params.ExternalIDs.Value = normalizeExternalIDs(*params.ExternalIDs.Value)
+	}
+
+	var fields []repository.FieldError
+	fields = appendDateError(fields, "birth_date", params.BirthDate.Value)
+	fields = appendDateError(fields, "death_date", params.DeathDate.Value)
+	fields = appendLifespanError(fields, params.BirthDate.Value, params.DeathDate.Value)
+	fields = appendWikidataIDError(fields, params.WikidataID.Value)
+	if params.ExternalIDs.Value != nil {
+		fields = appendExternalIDsError(fields, *params.ExternalIDs.Value)
+	}
+	fields = appendPortraitURLError(fields, params.PortraitURL.Value)
+	return profileError(fields)
+}
+
+// normalizeOccupation lowercases an occupation and collapses its whitespace, so that
+// occupations match however they are spelled
+func normalizeOccupation(occupation string) string {
+	return strings.Join(strings.Fields(strings.ToLower(occupation)), " ")
+}
+
+// normalizeOccupations normalizes occupations, dropping empty ones and duplicates
+func normalizeOccupations(occupations []string) []string {
+	if occupations == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(occupations))
+	seen := make(map[string]bool, len(occupations))
+	for _, occupation := range occupations {
+		occupation = normalizeOccupation(occupation)
+		if occupation != "" && !seen[occupation] {
+			seen[occupation] = true
+			normalized = append(normalized, occupation)
+		}
+	}
+	return normalized
+}
+
+// normalizeAliases trims aliases, dropping blank ones, duplicates ignoring case and
+// those repeating the name
+func normalizeAliases(name string, aliases []string) []string {
+	if aliases == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(aliases))
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(name)): true}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		key := strings.ToLower(alias)
+		if alias != "" && !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, alias)
+		}
+	}
+	return normalized
+}
+
+// normalizeExternalIDs lowercases the catalog names of external identifiers and trims
+// the identifiers
+func normalizeExternalIDs(ids map[string]string) map[string]string {
+	if ids == nil {
+		return nil
+	}
+
+	normalized := make(map[string]string, len(ids))
+	for catalog, id := range ids {
+		normalized[strings.ToLower(strings.TrimSpace(catalog))] = strings.TrimSpace(id)
+	}
+	return normalized
+}
+
+// appendDateError reports a partial date that does not exist
+func appendDateError(fields []repository.FieldError, field string, d *repository.PartialDate) []repository.FieldError {
+	if d == nil {
+		return fields
+	}
+	if err := d.Validate(); err != nil {
+		return append(fields, repository.FieldError{Field: field, Message: fmt.Sprintf("%s is invalid: %s", field, err)})
+	}
+	return fields
+}
+
+// appendLifespanError reports a death date before the birth date. Dates are compared
+// down to the finest precision both of them have.
+func appendLifespanError(fields []repository.FieldError, birth, death *repository.PartialDate) []repository.FieldError {
+	if birth == nil || death == nil {
+		return fields
+	}
+
+	before := death.Year < birth.Year
+	if death.Year == birth.Year && death.Month != 0 && birth.Month != 0 {
+		before = death.Month < birth.Month ||
+			(death.Month == birth.Month && death.Day != 0 && birth.Day != 0 && death.Day < birth.Day)
+	}
+	if before {
+		return append(fields, repository.FieldError{Field: "death_date", Message: "death_date must not be before birth_date"})
+	}
+	return fields
+}
+
+// appendWikidataIDError reports an identifier that is not a Wikidata item
+func appendWikidataIDError(fields []repository.FieldError, id *string) []repository.FieldError {
+	if id != nil && !wikidataIDPattern.MatchString(*id) {
+		return append(fields, repository.FieldError{Field: "wikidata_id",
+			Message: fmt.Sprintf("wikidata_id must be the identifier of a Wikidata item such as Q7245, got %q", *id)})
+	}
+	return fields
+}
+
+// appendExternalIDsError reports malformed catalog names and empty or overlong identifiers
+func appendExternalIDsError(fields []repository.FieldError, ids map[string]string) []repository.FieldError {
+	catalogs := make([]string, 0, len(ids))
+	for catalog := range ids {
+		catalogs = append(catalogs, catalog)
+	}
+	sort.Strings(catalogs)
+
+	for _, catalog := range catalogs {
+		id := ids[catalog]
+		field := "external_ids." + catalog
+		switch {
+		case !catalogPattern.MatchString(catalog):
+			fields = append(fields, repository.FieldError{Field: "external_ids",
+				Message: fmt.Sprintf("external_ids has catalog name %q, names are made of lowercase letters, digits and underscores", catalog)})
+		case id == "":
+			fields = append(fields, repository.FieldError{Field: field, Message: fmt.Sprintf("%s is required", field)})
+		case len([]rune(id)) > maxExternalIDLength:
+			fields = append(fields, repository.FieldError{Field: field,
+				Message: fmt.Sprintf("%s must be at most %d characters", field, maxExternalIDLength)})
+		}
+	}
+	return fields
+}
+
+// appendPortraitURLError reports a portrait URL that is not an absolute http or https URL
+func appendPortraitURLError(fields []repository.FieldError, portraitURL *string) []repository.FieldError {
+	if portraitURL == nil {
+		return fields
+	}
+	u, err := url.Parse(*portraitURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return append(fields, repository.FieldError{Field: "portrait_url", Message: "portrait_url must be an absolute http or https URL"})
+	}
+	return fields
+}
+
+// profileError builds the validation error reporting the rejected profile fields, nil when there are none
+func profileError(fields []repository.FieldError) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	message := fields[0].Message
+	if len(fields) > 1 {
+		message = fmt.Sprintf("%s (and %d more)", message, len(fields)-1)
+	}
+	return &repository.Error{
+		Kind:     repository.ErrValidation,
+		Resource: repository.ResourceAuthor,
+		Message:  message,
+		Fields:   fields,
+	}
+}
+
+// normalizeAuthorFilter normalizes the occupations of an author filter, so filters match
+// occupations however they are spelled
+func normalizeAuthorFilter(filter repository.AuthorFilter) repository.AuthorFilter {
+	filter.Occupations = normalizeOccupations(filter.Occupations)
+	return filter
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/igferreira/quotes-api/internal/repository"
 )
@@ -11,27 +13,42 @@ import (
 type Service struct {
 	authorRepo repository.AuthorRepository
 	quoteRepo  repository.QuoteRepository
+	tagRepo    repository.TagRepository
+	dailyRepo  repository.DailyQuoteRepository
+	tx         repository.Transactor
 }
 
-// NewService creates a new service instance
-func NewService(authorRepo repository.AuthorRepository, quoteRepo repository.QuoteRepository) *Service {
+// NewService creates a new service instance. Batches run their writes through tx.
+func NewService(authorRepo repository.AuthorRepository, quoteRepo repository.QuoteRepository, tagRepo repository.TagRepository, dailyRepo repository.DailyQuoteRepository, tx repository.Transactor) *Service {
 	return &Service{
 		authorRepo: authorRepo,
 		quoteRepo:  quoteRepo,
+		tagRepo:    tagRepo,
+		dailyRepo:  dailyRepo,
+		tx:         tx,
 	}
 }
 
+// withTx runs fn with a service whose author and quote repositories are bound to a
+// transaction, committed when fn succeeds
+func (s *Service) withTx(ctx context.Context, fn func(*Service) error) error {
+	return s.tx.WithTx(ctx, func(authorRepo repository.AuthorRepository, quoteRepo repository.QuoteRepository) error {
+		return fn(&Service{
+			authorRepo: authorRepo,
+			quoteRepo:  quoteRepo,
+			tagRepo:    s.tagRepo,
+			dailyRepo:  s.dailyRepo,
+		})
+	})
+}
+
 // CreateAuthor creates a new author
 func (s *Service) CreateAuthor(ctx context.Context, params repository.CreateAuthorParams) (*repository.Author, error) {
-	// Check if author already exists
-	authors, err := s.authorRepo.Search(ctx, params.Name, repository.ListParams{Limit: 1, Offset: 0})
-	if err != nil {
-		return nil, fmt.Errorf("failed to check existing authors: %w", err)
-	}
-	if len(authors) > 0 && authors[0].Name == params.Name {
-		return nil, fmt.Errorf("author with name %q already exists", params.Name)
+	if err := normalizeProfile(params.Name, &params.AuthorProfile); err != nil {
+		return nil, err
 	}
 
+	// The repository rejects a name or an alias another author already goes by
 	author, err := s.authorRepo.Create(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create author: %w", err)
@@ -49,16 +66,23 @@ func (s *Service) GetAuthor(ctx context.Context, id int64) (*repository.Author,
 	return author, nil
 }
 
-// ListAuthors retrieves a paginated list of authors
-func (s *Service) ListAuthors(ctx context.Context, params repository.ListParams) ([]*repository.Author, int64, error) {
+// ListAuthors retrieves a paginated list of the authors matching a filter.
+// The total is 0 when params.SkipTotal is set.
+func (s *Service) ListAuthors(ctx context.Context, filter repository.AuthorFilter, params repository.ListParams) ([]*repository.Author, int64, error) {
+	filter = normalizeAuthorFilter(filter)
+
 	// Get total count
-	total, err := s.authorRepo.Count(ctx)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count authors: %w", err)
+	var total int64
+	if !params.SkipTotal {
+		var err error
+		total, err = s.authorRepo.Count(ctx, filter)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count authors: %w", err)
+		}
 	}
 
 	// Get authors
-	authors, err := s.authorRepo.List(ctx, params)
+	authors, err := s.authorRepo.List(ctx, filter, params)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list authors: %w", err)
 	}
@@ -68,10 +92,14 @@ func (s *Service) ListAuthors(ctx context.Context, params repository.ListParams)
 
 // UpdateAuthor updates an existing author
 func (s *Service) UpdateAuthor(ctx context.Context, id int64, params repository.UpdateAuthorParams) (*repository.Author, error) {
+	if err := normalizeProfile(params.Name, &params.AuthorProfile); err != nil {
+		return nil, err
+	}
+
 	// Check if author exists
 	_, err := s.authorRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("author not found: %w", err)
+		return nil, fmt.Errorf("failed to get author: %w", err)
 	}
 
 	author, err := s.authorRepo.Update(ctx, id, params)
@@ -82,18 +110,44 @@ func (s *Service) UpdateAuthor(ctx context.Context, id int64, params repository.
 	return author, nil
 }
 
-// DeleteAuthor deletes an author
-func (s *Service) DeleteAuthor(ctx context.Context, id int64) error {
+// PatchAuthor partially updates an existing author
+func (s *Service) PatchAuthor(ctx context.Context, id int64, params repository.PatchAuthorParams) (*repository.Author, error) {
+	if err := normalizeProfilePatch(&params); err != nil {
+		return nil, err
+	}
+
+	author, err := s.authorRepo.Patch(ctx, id, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to patch author: %w", err)
+	}
+
+	return author, nil
+}
+
+// DeleteAuthor deletes an author, optionally only if its version matches expectedVersion
+func (s *Service) DeleteAuthor(ctx context.Context, id int64, expectedVersion *int64) error {
+	// A stale version fails the precondition before the author quotes are looked at
+	if expectedVersion != nil {
+		author, err := s.authorRepo.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get author: %w", err)
+		}
+		if author.Version != *expectedVersion {
+			return repository.PreconditionFailed(repository.ResourceAuthor, id, author.Version)
+		}
+	}
+
 	// Check if author has quotes
-	quotes, err := s.quoteRepo.ListByAuthor(ctx, id, repository.ListParams{Limit: 1, Offset: 0})
+	quotes, err := s.quoteRepo.CountByAuthor(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to check author quotes: %w", err)
 	}
-	if len(quotes) > 0 {
-		return fmt.Errorf("cannot delete author with existing quotes")
+	if quotes > 0 {
+		return repository.NewError(repository.ErrConflict, repository.ResourceAuthor,
+			"cannot delete author with existing quotes", nil)
 	}
 
-	err = s.authorRepo.Delete(ctx, id)
+	err = s.authorRepo.Delete(ctx, id, expectedVersion)
 	if err != nil {
 		return fmt.Errorf("failed to delete author: %w", err)
 	}
@@ -101,25 +155,128 @@ func (s *Service) DeleteAuthor(ctx context.Context, id int64) error {
 	return nil
 }
 
-// SearchAuthors searches for authors by name
-func (s *Service) SearchAuthors(ctx context.Context, query string, params repository.ListParams) ([]*repository.Author, int64, error) {
-	authors, err := s.authorRepo.Search(ctx, query, params)
+// AddAuthorAlias adds an alias to an author. An alias another author already goes by
+// is a conflict.
+func (s *Service) AddAuthorAlias(ctx context.Context, id int64, params repository.AddAliasParams) (*repository.Author, error) {
+	params.Name = strings.TrimSpace(params.Name)
+
+	author, err := s.authorRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get author: %w", err)
+	}
+	if len(author.Aliases) >= maxAliases {
+		return nil, repository.NewError(repository.ErrValidation, repository.ResourceAuthor,
+			fmt.Sprintf("author %d already has %d aliases, the most an author can have", id, maxAliases), nil)
+	}
+
+	author, err = s.authorRepo.AddAlias(ctx, id, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add author alias: %w", err)
+	}
+
+	return author, nil
+}
+
+// RemoveAuthorAlias removes an alias from an author, optionally only if its version
+// matches expectedVersion
+func (s *Service) RemoveAuthorAlias(ctx context.Context, id int64, alias string, expectedVersion *int64) error {
+	err := s.authorRepo.RemoveAlias(ctx, id, alias, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("failed to remove author alias: %w", err)
+	}
+	return nil
+}
+
+// SearchAuthors runs a fuzzy search over author names and aliases, narrowed down by a filter,
+// returning the total number of matches. The total is 0 when params.SkipTotal is set.
+func (s *Service) SearchAuthors(ctx context.Context, query string, filter repository.AuthorFilter, params repository.ListParams) ([]*repository.AuthorSearchResult, int64, error) {
+	filter = normalizeAuthorFilter(filter)
+
+	var total int64
+	if !params.SkipTotal {
+		var err error
+		total, err = s.authorRepo.CountSearch(ctx, query, filter)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count authors: %w", err)
+		}
+	}
+
+	authors, err := s.authorRepo.Search(ctx, query, filter, params)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to search authors: %w", err)
 	}
 
-	// For search, we don't have an efficient way to get total count
-	// In a real application, you might want to implement a separate count search method
-	return authors, int64(len(authors)), nil
+	return authors, total, nil
+}
+
+// ExpandAuthors embeds the related resources selected by expand in authors, with one
+// query per expansion for all of them
+func (s *Service) ExpandAuthors(ctx context.Context, authors []*repository.Author, expand repository.AuthorExpansion) error {
+	if len(authors) == 0 || (!expand.Quotes && !expand.QuoteCount) {
+		return nil
+	}
+
+	ids := make([]int64, len(authors))
+	byID := make(map[int64]*repository.Author, len(authors))
+	for i, author := range authors {
+		ids[i] = author.ID
+		byID[author.ID] = author
+	}
+
+	if expand.QuoteCount {
+		counts, err := s.quoteRepo.CountByAuthors(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to count author quotes: %w", err)
+		}
+		for _, author := range authors {
+			count := counts[author.ID]
+			author.QuoteCount = &count
+		}
+	}
+
+	if expand.Quotes {
+		quotes, err := s.quoteRepo.ListByAuthors(ctx, ids, expand.QuotesLimit)
+		if err != nil {
+			return fmt.Errorf("failed to list author quotes: %w", err)
+		}
+		for _, author := range authors {
+			author.Quotes = &[]*repository.Quote{}
+		}
+		for _, quote := range quotes {
+			author := byID[quote.AuthorID]
+			*author.Quotes = append(*author.Quotes, quote)
+		}
+	}
+
+	return nil
+}
+
+// ensureAuthorExists checks that a quote references an existing author.
+// A missing author is reported as a constraint violation on the quote rather than a not found error.
+func (s *Service) ensureAuthorExists(ctx context.Context, authorID int64) error {
+	_, err := s.authorRepo.GetByID(ctx, authorID)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return repository.NewError(repository.ErrConstraint, repository.ResourceQuote,
+			fmt.Sprintf("author %d does not exist", authorID), nil)
+	}
+	return fmt.Errorf("failed to get author: %w", err)
 }
 
 // CreateQuote creates a new quote
 func (s *Service) CreateQuote(ctx context.Context, params repository.CreateQuoteParams) (*repository.Quote, error) {
 	// Verify author exists
-	_, err := s.authorRepo.GetByID(ctx, params.AuthorID)
+	if err := s.ensureAuthorExists(ctx, params.AuthorID); err != nil {
+		return nil, err
+	}
+
+	tags, err := normalizeTags(params.Tags)
 	if err != nil {
-		return nil, fmt.Errorf("author not found: %w", err)
+		return nil, err
 	}
+	params.Tags = tags
 
 	quote, err := s.quoteRepo.Create(ctx, params)
 	if err != nil {
@@ -138,16 +295,23 @@ func (s *Service) GetQuote(ctx context.Context, id int64) (*repository.QuoteWith
 	return quote, nil
 }
 
-// ListQuotes retrieves a paginated list of quotes
-func (s *Service) ListQuotes(ctx context.Context, params repository.ListParams) ([]*repository.QuoteWithAuthor, int64, error) {
+// ListQuotes retrieves a paginated list of the quotes matching a filter.
+// The total is 0 when params.SkipTotal is set.
+func (s *Service) ListQuotes(ctx context.Context, filter repository.QuoteFilter, params repository.ListParams) ([]*repository.QuoteWithAuthor, int64, error) {
+	filter = normalizeFilterTags(filter)
+
 	// Get total count
-	total, err := s.quoteRepo.Count(ctx)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count quotes: %w", err)
+	var total int64
+	if !params.SkipTotal {
+		var err error
+		total, err = s.quoteRepo.Count(ctx, filter)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count quotes: %w", err)
+		}
 	}
 
 	// Get quotes
-	quotes, err := s.quoteRepo.List(ctx, params)
+	quotes, err := s.quoteRepo.List(ctx, filter, params)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list quotes: %w", err)
 	}
@@ -155,20 +319,17 @@ func (s *Service) ListQuotes(ctx context.Context, params repository.ListParams)
 	return quotes, total, nil
 }
 
-// ListQuotesByAuthor retrieves quotes by a specific author
-func (s *Service) ListQuotesByAuthor(ctx context.Context, authorID int64, params repository.ListParams) ([]*repository.QuoteWithAuthor, error) {
+// ListQuotesByAuthor retrieves a paginated list of the quotes of an existing author matching a filter.
+// The author filter is replaced by the author. The total is 0 when params.SkipTotal is set.
+func (s *Service) ListQuotesByAuthor(ctx context.Context, authorID int64, filter repository.QuoteFilter, params repository.ListParams) ([]*repository.QuoteWithAuthor, int64, error) {
 	// Verify author exists
 	_, err := s.authorRepo.GetByID(ctx, authorID)
 	if err != nil {
-		return nil, fmt.Errorf("author not found: %w", err)
-	}
-
-	quotes, err := s.quoteRepo.ListByAuthor(ctx, authorID, params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list quotes by author: %w", err)
+		return nil, 0, fmt.Errorf("failed to get author: %w", err)
 	}
 
-	return quotes, nil
+	filter.AuthorIDs = []int64{authorID}
+	return s.ListQuotes(ctx, filter, params)
 }
 
 // UpdateQuote updates an existing quote
@@ -176,13 +337,17 @@ func (s *Service) UpdateQuote(ctx context.Context, id int64, params repository.U
 	// Check if quote exists
 	_, err := s.quoteRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("quote not found: %w", err)
+		return nil, fmt.Errorf("failed to get quote: %w", err)
 	}
 
 	// Verify new author exists
-	_, err = s.authorRepo.GetByID(ctx, params.AuthorID)
+	if err := s.ensureAuthorExists(ctx, params.AuthorID); err != nil {
+		return nil, err
+	}
+
+	params.Tags, err = normalizeTags(params.Tags)
 	if err != nil {
-		return nil, fmt.Errorf("author not found: %w", err)
+		return nil, err
 	}
 
 	quote, err := s.quoteRepo.Update(ctx, id, params)
@@ -193,30 +358,87 @@ func (s *Service) UpdateQuote(ctx context.Context, id int64, params repository.U
 	return quote, nil
 }
 
-// DeleteQuote deletes a quote
-func (s *Service) DeleteQuote(ctx context.Context, id int64) error {
-	err := s.quoteRepo.Delete(ctx, id)
+// PatchQuote partially updates an existing quote
+func (s *Service) PatchQuote(ctx context.Context, id int64, params repository.PatchQuoteParams) (*repository.Quote, error) {
+	// Check if quote exists
+	_, err := s.quoteRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+
+	// Verify new author exists
+	if params.AuthorID.Value != nil {
+		if err := s.ensureAuthorExists(ctx, *params.AuthorID.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if params.Tags.Value != nil {
+		tags, err := normalizeTags(*params.Tags.Value)
+		if err != nil {
+			return nil, err
+		}
+		params.Tags.Value = &tags
+	}
+
+	quote, err := s.quoteRepo.Patch(ctx, id, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to patch quote: %w", err)
+	}
+
+	return quote, nil
+}
+
+// DeleteQuote deletes a quote, optionally only if its version matches expectedVersion
+func (s *Service) DeleteQuote(ctx context.Context, id int64, expectedVersion *int64) error {
+	err := s.quoteRepo.Delete(ctx, id, expectedVersion)
 	if err != nil {
 		return fmt.Errorf("failed to delete quote: %w", err)
 	}
 	return nil
 }
 
-// SearchQuotes searches for quotes by content
-func (s *Service) SearchQuotes(ctx context.Context, query string, params repository.ListParams) ([]*repository.QuoteWithAuthor, int64, error) {
-	quotes, err := s.quoteRepo.Search(ctx, query, params)
+// SearchQuotes runs the full-text search of filter.Query over the quotes matching the rest
+// of the filter, returning the total number of matches.
+// The total is 0 when params.SkipTotal is set.
+func (s *Service) SearchQuotes(ctx context.Context, filter repository.QuoteFilter, params repository.ListParams) ([]*repository.QuoteSearchResult, int64, error) {
+	filter = normalizeFilterTags(filter)
+
+	var total int64
+	if !params.SkipTotal {
+		var err error
+		total, err = s.quoteRepo.Count(ctx, filter)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count quotes: %w", err)
+		}
+	}
+
+	quotes, err := s.quoteRepo.Search(ctx, filter, params)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to search quotes: %w", err)
 	}
 
-	return quotes, int64(len(quotes)), nil
+	return quotes, total, nil
 }
 
-// GetRandomQuote retrieves a random quote
-func (s *Service) GetRandomQuote(ctx context.Context) (*repository.QuoteWithAuthor, error) {
-	quote, err := s.quoteRepo.GetRandom(ctx)
+// RandomQuotes picks up to params.Count distinct random quotes matching a filter.
+// It fails with ErrNotFound when no quote matches.
+func (s *Service) RandomQuotes(ctx context.Context, filter repository.QuoteFilter, params repository.RandomParams) ([]*repository.QuoteWithAuthor, error) {
+	quotes, err := s.quoteRepo.Random(ctx, normalizeFilterTags(filter), params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get random quote: %w", err)
+		return nil, fmt.Errorf("failed to get random quotes: %w", err)
 	}
-	return quote, nil
+	if len(quotes) == 0 {
+		return nil, repository.NewError(repository.ErrNotFound, repository.ResourceQuote, "no quotes found", nil)
+	}
+	return quotes, nil
+}
+
+// ExportQuotes calls fn with every quote matching a filter in ID order, with its author
+// when withAuthors is set, as the quotes are read. An error returned by fn stops the export.
+func (s *Service) ExportQuotes(ctx context.Context, filter repository.QuoteFilter, withAuthors bool, fn func(*repository.Quote) error) error {
+	if err := s.quoteRepo.Export(ctx, normalizeFilterTags(filter), withAuthors, fn); err != nil {
+		return fmt.Errorf("failed to export quotes: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// openingWords is the number of words of a quote shown in its suggestion
+const openingWords = 12
+
+// suggestionOrder ranks suggestion types among equally scored suggestions
+var suggestionOrder = map[string]int{
+	repository.SuggestionAuthor: 0,
+	repository.SuggestionTag:    1,
+	repository.SuggestionQuote:  2,
+}
+
+// SuggestionTypes lists the supported suggestion types
+var SuggestionTypes = []string{repository.SuggestionAuthor, repository.SuggestionTag, repository.SuggestionQuote}
+
+// Suggest returns a ranked mix of type-ahead suggestions for a prefix, best first.
+// The sources of each type are queried concurrently; when ctx expires before a source
+// answers, its suggestions are left out and partial is reported instead of an error.
+func (s *Service) Suggest(ctx context.Context, params repository.SuggestParams) (suggestions []*repository.Suggestion, partial bool, err error) {
+	sources := map[string]func(context.Context, string, int32) ([]*repository.Suggestion, error){
+		repository.SuggestionAuthor: s.authorRepo.Suggest,
+		repository.SuggestionTag:    s.quoteRepo.SuggestTags,
+		repository.SuggestionQuote:  s.quoteRepo.SuggestOpenings,
+	}
+
+	results := make([][]*repository.Suggestion, len(params.Types))
+	errs := make([]error, len(params.Types))
+
+	var wg sync.WaitGroup
+	for i, typ := range params.Types {
+		suggest, ok := sources[typ]
+		if !ok {
+			return nil, false, fmt.Errorf("unknown suggestion type %q", typ)
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = suggest(ctx, params.Query, params.Limit)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			if ctx.Err() != nil {
+				partial = true
+				continue
+			}
+			return nil, false, fmt.Errorf("failed to suggest %ss: %w", params.Types[i], err)
+		}
+		suggestions = append(suggestions, results[i]...)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Type != b.Type {
+			return suggestionOrder[a.Type] < suggestionOrder[b.Type]
+		}
+		return len(a.Text) < len(b.Text)
+	})
+	if len(suggestions) > int(params.Limit) {
+		suggestions = suggestions[:params.Limit]
+	}
+
+	for _, suggestion := range suggestions {
+		if suggestion.Type == repository.SuggestionQuote {
+			suggestion.Text = opening(suggestion.Text)
+		}
+	}
+
+	return suggestions, partial, nil
+}
+
+// opening returns the first words of a quote
+func opening(content string) string {
+	words := strings.Fields(content)
+	if len(words) <= openingWords {
+		return strings.Join(words, " ")
+	}
+	return strings.Join(words[:openingWords], " ") + "…"
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// Slugify normalizes a tag: lowercase letters and digits, with every other run of
+// characters turned into a single hyphen. It matches the slugify SQL function.
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
+
+// normalizeTags slugifies tags, dropping duplicates. A tag without letters or digits is a validation error.
+func normalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	slugs := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		slug := Slugify(tag)
+		if slug == "" {
+			message := fmt.Sprintf("tag %q has no letters or digits", tag)
+			return nil, &repository.Error{
+				Kind:     repository.ErrValidation,
+				Resource: repository.ResourceQuote,
+				Message:  message,
+				Fields:   []repository.FieldError{{Field: "tags", Message: message}},
+			}
+		}
+		if !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	return slugs, nil
+}
+
+// normalizeFilterTags slugifies the tags of a quote filter, so filters match tags however they are spelled
+func normalizeFilterTags(filter repository.QuoteFilter) repository.QuoteFilter {
+	filter.Tags = slugifyAll(filter.Tags)
+	filter.ExcludeTags = slugifyAll(filter.ExcludeTags)
+	return filter
+}
+
+// slugifyAll slugifies tags
+func slugifyAll(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	slugs := make([]string, len(tags))
+	for i, tag := range tags {
+		slugs[i] = Slugify(tag)
+	}
+	return slugs
+}
+
+// GetTag retrieves a tag by its slug, or any spelling of it
+func (s *Service) GetTag(ctx context.Context, slug string) (*repository.Tag, error) {
+	tag, err := s.tagRepo.GetBySlug(ctx, Slugify(slug))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+	return tag, nil
+}
+
+// ListTags retrieves a paginated list of tags, most used first.
+// The total is 0 when params.SkipTotal is set.
+func (s *Service) ListTags(ctx context.Context, params repository.ListParams) ([]*repository.Tag, int64, error) {
+	var total int64
+	if !params.SkipTotal {
+		var err error
+		total, err = s.tagRepo.Count(ctx)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count tags: %w", err)
+		}
+	}
+
+	tags, err := s.tagRepo.List(ctx, params)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	return tags, total, nil
+}
+
+// RenameTag renames a tag on every quote carrying it
+func (s *Service) RenameTag(ctx context.Context, slug string, params repository.RenameTagParams) (*repository.Tag, error) {
+	params.Slug = Slugify(params.Name)
+	if params.Slug == "" {
+		message := fmt.Sprintf("tag %q has no letters or digits", params.Name)
+		return nil, &repository.Error{
+			Kind:     repository.ErrValidation,
+			Resource: repository.ResourceTag,
+			Message:  message,
+			Fields:   []repository.FieldError{{Field: "name", Message: message}},
+		}
+	}
+
+	tag, err := s.tagRepo.Rename(ctx, Slugify(slug), params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename tag: %w", err)
+	}
+	return tag, nil
+}
+
+// MergeTag moves every quote carrying a tag over to another existing tag and deletes it
+func (s *Service) MergeTag(ctx context.Context, slug string, params repository.MergeTagParams) (*repository.Tag, error) {
+	slug, into := Slugify(slug), Slugify(params.Into)
+	if slug == into {
+		return nil, repository.NewError(repository.ErrValidation, repository.ResourceTag,
+			fmt.Sprintf("tag %q cannot be merged into itself", slug), nil)
+	}
+
+	tag, err := s.tagRepo.Merge(ctx, slug, into)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge tag: %w", err)
+	}
+	return tag, nil
+}
+
+// DeleteTag removes a tag from every quote carrying it and deletes it
+func (s *Service) DeleteTag(ctx context.Context, slug string) error {
+	if err := s.tagRepo.Delete(ctx, Slugify(slug)); err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	return nil
+}
+
+// ListQuotesByTag retrieves a paginated list of the quotes carrying an existing tag and matching a filter.
+// The total is 0 when params.SkipTotal is set.
+func (s *Service) ListQuotesByTag(ctx context.Context, slug string, filter repository.QuoteFilter, params repository.ListParams) ([]*repository.QuoteWithAuthor, int64, error) {
+	// Verify tag exists
+	tag, err := s.tagRepo.GetBySlug(ctx, Slugify(slug))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	filter.Tags = append(filter.Tags, tag.Slug)
+	filter.MatchAllTags = true
+	return s.ListQuotes(ctx, filter, params)
+}
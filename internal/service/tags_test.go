@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"lowercases", "Wisdom", "wisdom"},
+		{"joins words", "self esteem", "self-esteem"},
+		{"keeps digits", "Top 10 Quotes", "top-10-quotes"},
+		{"collapses punctuation runs", "rock'n'roll -- live!!", "rock-n-roll-live"},
+		{"collapses whitespace runs", "tab\tand \n newline", "tab-and-newline"},
+		{"trims separators", "  --Hello, World!--  ", "hello-world"},
+		{"keeps hyphenated slugs", "self-esteem", "self-esteem"},
+		{"symbols are separators", "C++ & Go", "c-go"},
+		{"accented letters", "Café Crème", "café-crème"},
+		{"sharp s", "Straße", "straße"},
+		{"cyrillic", "Любовь и Жизнь", "любовь-и-жизнь"},
+		{"greek", "ΣΟΦΙΑ", "σοφια"},
+		{"cjk", "日本語 テキスト", "日本語-テキスト"},
+		{"non-ascii punctuation", "«Liberté»—égalité…", "liberté-égalité"},
+		{"emoji are separators", "party🎉time", "party-time"},
+		{"combining marks are separators", "e\u0301te\u0301", "e-te"},
+		{"invalid utf-8 is a separator", "bad\xffbytes", "bad-bytes"},
+		{"no letters or digits", "?!… 🎉", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slugify(tt.in); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugifyIsIdempotent(t *testing.T) {
+	// the tags CHECK constraint requires slug = slugify(slug)
+	for _, in := range []string{"Self Esteem", "rock'n'roll", "Café Crème", "Любовь", "日本語 テキスト", "bad\xffbytes"} {
+		slug := Slugify(in)
+		if again := Slugify(slug); again != slug {
+			t.Errorf("Slugify(%q) = %q, not a fixed point of Slugify(%q) = %q", slug, again, in, slug)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := normalizeTags([]string{"Self Esteem", "self-esteem", "LOVE", "Love!"})
+	if err != nil {
+		t.Fatalf("normalizeTags() error = %v", err)
+	}
+	if want := []string{"self-esteem", "love"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeTags() = %v, want %v", got, want)
+	}
+
+	if got, err := normalizeTags(nil); got != nil || err != nil {
+		t.Errorf("normalizeTags(nil) = %v, %v, want nil, nil", got, err)
+	}
+
+	if _, err := normalizeTags([]string{"love", "?!"}); !errors.Is(err, repository.ErrValidation) {
+		t.Errorf("normalizeTags() with a tag without letters error = %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,274 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/igferreira/quotes-api/internal/repository"
+)
+
+// Supported rules of the `validate` struct tag:
+//
+//	required  the value must not be empty; strings are checked after trimming
+//	omitempty skip the remaining rules when the value is empty
+//	min=N     minimum length for strings and slices, minimum value for numbers
+//	max=N     maximum length for strings and slices, maximum value for numbers
+//	dive      apply the remaining rules to every element of a slice
+//
+// Fields of a partial update (repository.Optional) are only validated when
+// present; an explicit null is rejected when the field is required.
+const tagName = "validate"
+
+// optional is implemented by repository.Optional
+type optional interface {
+	IsSet() bool
+	IsNull() bool
+}
+
+// Validate trims the string fields of the struct pointed to by v and checks
+// every field against its `validate` tag. All violations are reported at once
+// in a repository validation error, or nil is returned when v is valid.
+// Malformed tags are programming errors and cause a panic.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: expected pointer to struct, got %T", v))
+	}
+
+	var violations []repository.FieldError
+	validateStruct(rv.Elem(), "", &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &repository.Error{
+		Kind:    repository.ErrValidation,
+		Message: summary(violations),
+		Fields:  violations,
+	}
+}
+
+// validateStruct validates every exported field of a struct value
+func validateStruct(rv reflect.Value, prefix string, violations *[]repository.FieldError) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		value := rv.Field(i)
+		name := prefix + fieldName(field)
+
+		if opt, ok := value.Interface().(optional); ok {
+			if tag, ok := field.Tag.Lookup(tagName); ok && tag != "-" {
+				validateOptional(value, opt, name, strings.Split(tag, ","), violations)
+			}
+			continue
+		}
+
+		trim(value)
+
+		if field.Anonymous && value.Kind() == reflect.Struct {
+			validateStruct(value, prefix, violations)
+			continue
+		}
+
+		if tag, ok := field.Tag.Lookup(tagName); ok && tag != "-" {
+			validateValue(value, name, strings.Split(tag, ","), violations)
+		}
+
+		if value.Kind() == reflect.Struct {
+			validateStruct(value, name+".", violations)
+		}
+	}
+}
+
+// validateValue applies a list of rules to a single value
+func validateValue(value reflect.Value, name string, rules []string, violations *[]repository.FieldError) {
+	for i, rule := range rules {
+		key, param, _ := strings.Cut(rule, "=")
+
+		switch key {
+		case "omitempty":
+			if isEmpty(value) {
+				return
+			}
+		case "required":
+			if isEmpty(value) {
+				addViolation(violations, name, "%s is required", name)
+				return
+			}
+		case "min", "max":
+			if ok := checkBound(value, name, key, param, violations); !ok {
+				return
+			}
+		case "dive":
+			elem := indirect(value)
+			if elem.Kind() != reflect.Slice && elem.Kind() != reflect.Array {
+				panic(fmt.Sprintf("validation: dive on non-slice field %s", name))
+			}
+			for j := 0; j < elem.Len(); j++ {
+				validateValue(elem.Index(j), fmt.Sprintf("%s[%d]", name, j), rules[i+1:], violations)
+			}
+			return
+		default:
+			panic(fmt.Sprintf("validation: unknown rule %q on field %s", rule, name))
+		}
+	}
+}
+
+// validateOptional validates a partial update field. Present values are trimmed
+// and checked like regular fields; optional strings left empty are turned into null.
+func validateOptional(value reflect.Value, opt optional, name string, rules []string, violations *[]repository.FieldError) {
+	if !opt.IsSet() {
+		return
+	}
+
+	required := hasRule(rules, "required")
+	if opt.IsNull() {
+		if required {
+			addViolation(violations, name, "%s cannot be null", name)
+		}
+		return
+	}
+
+	ptr := value.FieldByName("Value")
+	trim(ptr.Elem())
+	if !required && ptr.Elem().Kind() == reflect.String && ptr.Elem().Len() == 0 {
+		ptr.Set(reflect.Zero(ptr.Type()))
+		return
+	}
+
+	validateValue(ptr.Elem(), name, rules, violations)
+}
+
+// hasRule reports whether a rule appears in a tag before any dive
+func hasRule(rules []string, name string) bool {
+	for _, rule := range rules {
+		if rule == "dive" {
+			return false
+		}
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBound applies a min or max rule, reporting whether validation of the value should continue
+func checkBound(value reflect.Value, name, key, param string, violations *[]repository.FieldError) bool {
+	bound, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: invalid %s parameter %q on field %s", key, param, name))
+	}
+
+	value = indirect(value)
+	if !value.IsValid() {
+		return true
+	}
+
+	var actual int64
+	var unit string
+	switch value.Kind() {
+	case reflect.String:
+		actual, unit = int64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		actual, unit = int64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = int64(value.Uint())
+	default:
+		panic(fmt.Sprintf("validation: %s is not supported on field %s of kind %s", key, name, value.Kind()))
+	}
+
+	switch {
+	case key == "min" && actual < bound:
+		addViolation(violations, name, "%s must be at least %d%s", name, bound, unit)
+		return false
+	case key == "max" && actual > bound:
+		addViolation(violations, name, "%s must be at most %d%s", name, bound, unit)
+		return false
+	}
+	return true
+}
+
+// trim removes surrounding whitespace from strings, string pointers and string slices
+func trim(value reflect.Value) {
+	if !value.CanSet() {
+		return
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(strings.TrimSpace(value.String()))
+	case reflect.Ptr:
+		// optional strings made only of whitespace are treated as absent
+		if !value.IsNil() && value.Elem().Kind() == reflect.String {
+			trimmed := strings.TrimSpace(value.Elem().String())
+			if trimmed == "" {
+				value.Set(reflect.Zero(value.Type()))
+				return
+			}
+			value.Elem().SetString(trimmed)
+		}
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.String {
+			for i := 0; i < value.Len(); i++ {
+				value.Index(i).SetString(strings.TrimSpace(value.Index(i).String()))
+			}
+		}
+	}
+}
+
+// isEmpty reports whether a value is nil, zero or has no elements
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len() == 0
+	default:
+		return value.IsZero()
+	}
+}
+
+// indirect dereferences pointers, returning an invalid value for nil pointers
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// fieldName returns the JSON name of a struct field, falling back to its Go name
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// addViolation records a field violation
+func addViolation(violations *[]repository.FieldError, field, format string, args ...interface{}) {
+	*violations = append(*violations, repository.FieldError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// summary builds a single message out of all violations
+func summary(violations []repository.FieldError) string {
+	if len(violations) == 1 {
+		return violations[0].Message
+	}
+	return fmt.Sprintf("%s (and %d more)", violations[0].Message, len(violations)-1)
+}
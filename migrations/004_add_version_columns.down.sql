@@ -0,0 +1,10 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS increment_quotes_version ON quotes;
+DROP TRIGGER IF EXISTS increment_authors_version ON authors;
+
+-- Drop function
+DROP FUNCTION IF EXISTS increment_version_column();
+
+-- Drop columns
+ALTER TABLE quotes DROP COLUMN IF EXISTS version;
+ALTER TABLE authors DROP COLUMN IF EXISTS version;
@@ -0,0 +1,18 @@
+-- Add row versions used for optimistic concurrency control
+ALTER TABLE authors ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE quotes ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- Create version trigger
+CREATE OR REPLACE FUNCTION increment_version_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER increment_authors_version BEFORE UPDATE
+    ON authors FOR EACH ROW EXECUTE FUNCTION increment_version_column();
+
+CREATE TRIGGER increment_quotes_version BEFORE UPDATE
+    ON quotes FOR EACH ROW EXECUTE FUNCTION increment_version_column();
@@ -0,0 +1,3 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_authors_name_id;
+DROP INDEX IF EXISTS idx_quotes_created_at_id;
@@ -0,0 +1,3 @@
+-- Create indexes backing keyset pagination
+CREATE INDEX idx_quotes_created_at_id ON quotes(created_at DESC, id DESC);
+CREATE INDEX idx_authors_name_id ON authors(name, id);
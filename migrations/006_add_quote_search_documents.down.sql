@@ -0,0 +1,11 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS refresh_authors_quote_documents ON authors;
+DROP TRIGGER IF EXISTS refresh_quotes_document ON quotes;
+
+-- Drop functions
+DROP FUNCTION IF EXISTS refresh_author_quote_documents();
+DROP FUNCTION IF EXISTS refresh_quote_document();
+DROP FUNCTION IF EXISTS quote_document(TEXT, TEXT, TEXT);
+
+-- Drop table
+DROP TABLE IF EXISTS quote_documents;
@@ -0,0 +1,54 @@
+-- Full-text search documents for quotes.
+-- The document covers the author name, which lives in another table, so it cannot be a
+-- generated column; triggers keep it in sync with quotes and author renames instead.
+CREATE TABLE IF NOT EXISTS quote_documents (
+    quote_id BIGINT PRIMARY KEY REFERENCES quotes(id) ON DELETE CASCADE,
+    document TSVECTOR NOT NULL
+);
+
+CREATE INDEX idx_quote_documents_document ON quote_documents USING GIN(document);
+
+-- Build the weighted search document of a quote
+CREATE OR REPLACE FUNCTION quote_document(content TEXT, source TEXT, author_name TEXT)
+RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('english', coalesce(content, '')), 'A')
+        || setweight(to_tsvector('english', coalesce(author_name, '')), 'B')
+        || setweight(to_tsvector('english', coalesce(source, '')), 'C');
+$$ language 'sql' IMMUTABLE;
+
+-- Refresh the document of a quote when it is created or its searchable fields change
+CREATE OR REPLACE FUNCTION refresh_quote_document()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO quote_documents (quote_id, document)
+    SELECT NEW.id, quote_document(NEW.content, NEW.source, a.name)
+    FROM authors a WHERE a.id = NEW.author_id
+    ON CONFLICT (quote_id) DO UPDATE SET document = EXCLUDED.document;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER refresh_quotes_document AFTER INSERT OR UPDATE OF content, source, author_id
+    ON quotes FOR EACH ROW EXECUTE FUNCTION refresh_quote_document();
+
+-- Refresh the documents of all quotes of an author when it is renamed
+CREATE OR REPLACE FUNCTION refresh_author_quote_documents()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE quote_documents d
+    SET document = quote_document(q.content, q.source, NEW.name)
+    FROM quotes q
+    WHERE q.id = d.quote_id AND q.author_id = NEW.id;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER refresh_authors_quote_documents AFTER UPDATE OF name
+    ON authors FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE FUNCTION refresh_author_quote_documents();
+
+-- Index existing quotes
+INSERT INTO quote_documents (quote_id, document)
+SELECT q.id, quote_document(q.content, q.source, a.name)
+FROM quotes q
+JOIN authors a ON q.author_id = a.id;
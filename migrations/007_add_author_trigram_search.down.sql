@@ -0,0 +1,10 @@
+-- Restore name index
+CREATE INDEX idx_authors_name ON authors(name);
+
+-- Drop trigram index
+DROP INDEX IF EXISTS idx_authors_name_trgm;
+
+-- Drop function
+DROP FUNCTION IF EXISTS normalize_name(TEXT);
+
+-- Extensions are left installed, other objects may depend on them
@@ -0,0 +1,17 @@
+-- Enable fuzzy, accent-insensitive author search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- Normalize names for comparison: lower case without accents.
+-- unaccent() is only STABLE because its dictionary could change, so it is wrapped with an
+-- explicit dictionary in an IMMUTABLE function to be usable in indexes.
+CREATE OR REPLACE FUNCTION normalize_name(name TEXT)
+RETURNS TEXT AS $$
+    SELECT lower(public.unaccent('public.unaccent'::regdictionary, name));
+$$ language 'sql' IMMUTABLE PARALLEL SAFE STRICT;
+
+-- Trigram index serving similarity searches; the btree index cannot serve '%term%' matches
+CREATE INDEX idx_authors_name_trgm ON authors USING GIN(normalize_name(name) gin_trgm_ops);
+
+-- Ordering by name is served by idx_authors_name_id
+DROP INDEX IF EXISTS idx_authors_name;
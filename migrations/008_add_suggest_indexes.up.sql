@@ -0,0 +1,3 @@
+-- Index the opening of quotes for type-ahead suggestions.
+-- Trigram indexes serve LIKE 'prefix%' patterns passed as query parameters.
+CREATE INDEX idx_quotes_opening_trgm ON quotes USING GIN(normalize_name(left(content, 100)) gin_trgm_ops);
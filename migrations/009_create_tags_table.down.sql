@@ -0,0 +1,15 @@
+-- Drop trigger
+DROP TRIGGER IF EXISTS refresh_quotes_tags ON quotes;
+
+-- Drop function
+DROP FUNCTION IF EXISTS refresh_quote_tags();
+
+-- Drop index
+DROP INDEX IF EXISTS idx_tags_slug_trgm;
+
+-- Drop tables
+DROP TABLE IF EXISTS quote_tags;
+DROP TABLE IF EXISTS tags;
+
+-- Drop function
+DROP FUNCTION IF EXISTS slugify(TEXT);
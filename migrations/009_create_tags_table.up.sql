@@ -0,0 +1,98 @@
+-- Normalize a tag into its slug: lowercase words joined by hyphens
+CREATE OR REPLACE FUNCTION slugify(name TEXT)
+RETURNS TEXT AS $$
+    SELECT trim(both '-' from regexp_replace(lower(name), '[^[:alnum:]]+', '-', 'g'));
+$$ language 'sql' IMMUTABLE;
+
+-- slugify() must agree with the API, which keeps the letters and digits of every script:
+-- lower() and [:alnum:] only know ASCII in a C or non-UTF-8 locale, where existing tags
+-- would be normalized differently and the slug check would reject the slugs of the API.
+DO $$
+BEGIN
+    IF slugify('Ça Été Ωμέγα') IS DISTINCT FROM 'ça-été-ωμέγα' THEN
+        RAISE EXCEPTION 'slugify() needs a UTF-8 database with a Unicode-aware locale, LC_CTYPE is %',
+            current_setting('lc_ctype');
+    END IF;
+END $$;
+
+-- Create tags table
+CREATE TABLE IF NOT EXISTS tags (
+    id BIGSERIAL PRIMARY KEY,
+    slug VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_tags_slug CHECK (slug <> '' AND slug = slugify(slug))
+);
+
+CREATE TRIGGER update_tags_updated_at BEFORE UPDATE
+    ON tags FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Tags of each quote. quotes.tags keeps the slugs for reading and filtering, and
+-- triggers keep this table in sync with it.
+CREATE TABLE IF NOT EXISTS quote_tags (
+    quote_id BIGINT NOT NULL REFERENCES quotes(id) ON DELETE CASCADE,
+    tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (quote_id, tag_id)
+);
+
+CREATE INDEX idx_quote_tags_tag_id ON quote_tags(tag_id);
+
+-- Trigram index serving tag suggestions, which match the start of slugs. The usage
+-- counts of the matching tags are read from quote_tags through idx_quote_tags_tag_id.
+CREATE INDEX idx_tags_slug_trgm ON tags USING GIN(normalize_name(slug) gin_trgm_ops);
+
+-- Normalize existing tags, keeping the first occurrence of duplicates
+UPDATE quotes q
+SET tags = n.tags
+FROM (
+    SELECT q.id, (
+        SELECT array_agg(slug ORDER BY position)
+        FROM (
+            SELECT slugify(tag) AS slug, min(position) AS position
+            FROM unnest(q.tags) WITH ORDINALITY AS t(tag, position)
+            WHERE slugify(tag) <> ''
+            GROUP BY 1
+        ) s
+    ) AS tags
+    FROM quotes q
+    WHERE q.tags IS NOT NULL
+) n
+WHERE q.id = n.id AND q.tags IS DISTINCT FROM n.tags;
+
+-- Sync the tags of a quote into tags and quote_tags
+CREATE OR REPLACE FUNCTION refresh_quote_tags()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO tags (slug, name)
+    SELECT DISTINCT tag, tag FROM unnest(NEW.tags) AS tag
+    ON CONFLICT (slug) DO NOTHING;
+
+    DELETE FROM quote_tags qt
+    USING tags t
+    WHERE qt.quote_id = NEW.id AND t.id = qt.tag_id
+      AND NOT (t.slug = ANY(coalesce(NEW.tags, '{}')));
+
+    INSERT INTO quote_tags (quote_id, tag_id)
+    SELECT NEW.id, t.id FROM tags t
+    WHERE t.slug = ANY(NEW.tags)
+    ON CONFLICT DO NOTHING;
+
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER refresh_quotes_tags AFTER INSERT OR UPDATE OF tags
+    ON quotes FOR EACH ROW EXECUTE FUNCTION refresh_quote_tags();
+
+-- Back-fill tags and quote_tags
+INSERT INTO tags (slug, name)
+SELECT DISTINCT tag, tag FROM quotes, unnest(tags) AS tag
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO quote_tags (quote_id, tag_id)
+SELECT q.id, t.id
+FROM quotes q
+JOIN tags t ON t.slug = ANY(q.tags)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+-- Drop index
+DROP INDEX IF EXISTS idx_daily_quotes_quote_id;
+
+-- Drop table
+DROP TABLE IF EXISTS daily_quotes;
@@ -0,0 +1,14 @@
+-- Quote of the day of each date, per tag ('' for all quotes).
+-- Rows are written the first time a date around the current one is requested, or ahead of time
+-- when a quote is scheduled. Deleting a quote deletes the days it was or is the quote of.
+CREATE TABLE IF NOT EXISTS daily_quotes (
+    day DATE NOT NULL,
+    tag VARCHAR(50) NOT NULL DEFAULT '',
+    quote_id BIGINT NOT NULL REFERENCES quotes(id) ON DELETE CASCADE,
+    scheduled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (tag, day)
+);
+
+CREATE INDEX idx_daily_quotes_quote_id ON daily_quotes(quote_id);
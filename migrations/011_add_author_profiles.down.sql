@@ -0,0 +1,27 @@
+-- Restore the name index, then drop the search index and function
+CREATE INDEX IF NOT EXISTS idx_authors_name_trgm ON authors USING GIN(normalize_name(name) gin_trgm_ops);
+DROP INDEX IF EXISTS idx_authors_search_trgm;
+DROP FUNCTION IF EXISTS author_search_text(TEXT, TEXT[]);
+
+-- Drop filter indexes
+DROP INDEX IF EXISTS idx_authors_lifespan;
+DROP INDEX IF EXISTS idx_authors_occupations;
+DROP INDEX IF EXISTS idx_authors_nationality;
+DROP INDEX IF EXISTS idx_authors_wikidata_id;
+
+-- Drop profile columns, along with their constraints
+ALTER TABLE authors
+    DROP COLUMN IF EXISTS portrait_url,
+    DROP COLUMN IF EXISTS aliases,
+    DROP COLUMN IF EXISTS external_ids,
+    DROP COLUMN IF EXISTS wikidata_id,
+    DROP COLUMN IF EXISTS occupations,
+    DROP COLUMN IF EXISTS nationality,
+    DROP COLUMN IF EXISTS death_circa,
+    DROP COLUMN IF EXISTS death_day,
+    DROP COLUMN IF EXISTS death_month,
+    DROP COLUMN IF EXISTS death_year,
+    DROP COLUMN IF EXISTS birth_circa,
+    DROP COLUMN IF EXISTS birth_day,
+    DROP COLUMN IF EXISTS birth_month,
+    DROP COLUMN IF EXISTS birth_year;
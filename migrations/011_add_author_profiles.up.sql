@@ -0,0 +1,52 @@
+-- Biographical metadata of authors. Birth and death dates are partial: the month and day
+-- may be unknown and the date approximate. Years before the common era are negative.
+ALTER TABLE authors
+    ADD COLUMN birth_year INTEGER,
+    ADD COLUMN birth_month INTEGER,
+    ADD COLUMN birth_day INTEGER,
+    ADD COLUMN birth_circa BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN death_year INTEGER,
+    ADD COLUMN death_month INTEGER,
+    ADD COLUMN death_day INTEGER,
+    ADD COLUMN death_circa BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN nationality VARCHAR(100),
+    ADD COLUMN occupations TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN wikidata_id VARCHAR(20),
+    ADD COLUMN external_ids JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN aliases TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN portrait_url TEXT;
+
+ALTER TABLE authors
+    ADD CONSTRAINT chk_authors_birth_date CHECK (
+        (birth_year IS NULL OR birth_year <> 0)
+        AND (birth_month IS NULL OR (birth_year IS NOT NULL AND birth_month BETWEEN 1 AND 12))
+        AND (birth_day IS NULL OR (birth_month IS NOT NULL AND birth_day BETWEEN 1 AND 31))
+    ),
+    ADD CONSTRAINT chk_authors_death_date CHECK (
+        (death_year IS NULL OR death_year <> 0)
+        AND (death_month IS NULL OR (death_year IS NOT NULL AND death_month BETWEEN 1 AND 12))
+        AND (death_day IS NULL OR (death_month IS NOT NULL AND death_day BETWEEN 1 AND 31))
+    ),
+    ADD CONSTRAINT chk_authors_lifespan CHECK (birth_year IS NULL OR death_year IS NULL OR birth_year <= death_year),
+    ADD CONSTRAINT chk_authors_wikidata_id CHECK (wikidata_id ~ '^Q[1-9][0-9]*$'),
+    ADD CONSTRAINT chk_authors_external_ids CHECK (jsonb_typeof(external_ids) = 'object');
+
+-- An author has a single Wikidata item
+CREATE UNIQUE INDEX idx_authors_wikidata_id ON authors(wikidata_id);
+
+-- Indexes serving the author filters
+CREATE INDEX idx_authors_nationality ON authors(normalize_name(nationality));
+CREATE INDEX idx_authors_occupations ON authors USING GIN(occupations);
+CREATE INDEX idx_authors_lifespan ON authors(birth_year, death_year);
+
+-- Text matched by author searches: the name followed by the aliases.
+-- array_to_string() is only STABLE in general but IMMUTABLE for text arrays.
+CREATE OR REPLACE FUNCTION author_search_text(name TEXT, aliases TEXT[])
+RETURNS TEXT AS $$
+    SELECT normalize_name(array_to_string(array_prepend(name, aliases), ' '));
+$$ language 'sql' IMMUTABLE PARALLEL SAFE;
+
+CREATE INDEX idx_authors_search_trgm ON authors USING GIN(author_search_text(name, aliases) gin_trgm_ops);
+
+-- Author searches and suggestions match the search text, which supersedes the name index
+DROP INDEX IF EXISTS idx_authors_name_trgm;
@@ -0,0 +1,10 @@
+-- Drop sync trigger and function
+DROP TRIGGER IF EXISTS refresh_authors_aliases ON authors;
+DROP FUNCTION IF EXISTS refresh_author_aliases();
+
+-- Drop author_aliases table
+DROP TABLE IF EXISTS author_aliases;
+
+-- Drop the record of name conflicts. Merged authors and dropped aliases are not restored.
+DROP TABLE IF EXISTS author_name_conflicts;
+DROP FUNCTION IF EXISTS author_name_key(TEXT);
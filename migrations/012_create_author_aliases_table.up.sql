@@ -0,0 +1,137 @@
+-- Key identifying an author name: normalized, with its whitespace collapsed
+CREATE OR REPLACE FUNCTION author_name_key(name TEXT)
+RETURNS TEXT AS $$
+    SELECT btrim(regexp_replace(normalize_name(name), '\s+', ' ', 'g'));
+$$ language 'sql' IMMUTABLE PARALLEL SAFE STRICT;
+
+-- Names each author is known by, its name and its aliases, one row per key so that no
+-- two authors go by the same name whatever its case, accents or spacing.
+-- authors.name and authors.aliases keep the names for reading and searching, and a
+-- trigger keeps this table in sync with them.
+CREATE TABLE IF NOT EXISTS author_aliases (
+    author_id BIGINT NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    normalized_name TEXT GENERATED ALWAYS AS (author_name_key(name)) STORED,
+
+    CONSTRAINT uq_author_aliases_normalized_name UNIQUE (normalized_name)
+);
+
+CREATE INDEX idx_author_aliases_author_id ON author_aliases(author_id);
+
+-- Names that two authors went by before author_aliases, and how each conflict was settled:
+-- an author sharing its name with an older one is merged into it, a snapshot of it being
+-- kept in author, and an alias taken by another author is dropped.
+CREATE TABLE IF NOT EXISTS author_name_conflicts (
+    author_id BIGINT NOT NULL,
+    kept_by BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    author JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Merge the authors sharing a name into the oldest of them: their quotes move to it and
+-- their names and aliases become its aliases
+CREATE TEMPORARY TABLE author_merges AS
+SELECT id, kept_by
+FROM (SELECT id, min(id) OVER (PARTITION BY author_name_key(name)) AS kept_by FROM authors) a
+WHERE id <> kept_by;
+
+INSERT INTO author_name_conflicts (author_id, kept_by, name, author)
+SELECT a.id, m.kept_by, a.name, to_jsonb(a)
+FROM author_merges m
+JOIN authors a ON a.id = m.id;
+
+UPDATE authors k
+SET aliases = k.aliases || merged.names
+FROM (
+    SELECT kept_by, array_agg(name ORDER BY id, position) AS names
+    FROM (
+        SELECT DISTINCT ON (m.kept_by, author_name_key(n.name)) m.kept_by, m.id, n.position, n.name
+        FROM author_merges m
+        JOIN authors a ON a.id = m.id,
+        unnest(array_prepend(a.name, a.aliases)) WITH ORDINALITY AS n(name, position)
+        ORDER BY m.kept_by, author_name_key(n.name), m.id, n.position
+    ) names
+    WHERE NOT EXISTS (
+        SELECT 1
+        FROM authors kept, unnest(array_prepend(kept.name, kept.aliases)) AS known(name)
+        WHERE kept.id = names.kept_by AND author_name_key(known.name) = author_name_key(names.name)
+    )
+    GROUP BY kept_by
+) merged
+WHERE k.id = merged.kept_by;
+
+UPDATE authors k
+SET bio = (
+    SELECT a.bio FROM author_merges m JOIN authors a ON a.id = m.id
+    WHERE m.kept_by = k.id AND a.bio IS NOT NULL
+    ORDER BY a.id
+    LIMIT 1
+)
+WHERE k.bio IS NULL AND k.id IN (SELECT kept_by FROM author_merges);
+
+UPDATE quotes q
+SET author_id = m.kept_by
+FROM author_merges m
+WHERE q.author_id = m.id;
+
+DELETE FROM authors a
+USING author_merges m
+WHERE a.id = m.id;
+
+DROP TABLE author_merges;
+
+-- Drop the aliases other authors go by: a name wins over an alias, and an alias of an
+-- older author over one of a newer author
+CREATE TEMPORARY TABLE author_dropped_aliases AS
+WITH names AS (
+    SELECT a.id, n.name, n.position, author_name_key(n.name) AS key
+    FROM authors a, unnest(array_prepend(a.name, a.aliases)) WITH ORDINALITY AS n(name, position)
+), owners AS (
+    SELECT DISTINCT ON (key) key, id
+    FROM names
+    ORDER BY key, position > 1, id
+)
+SELECT n.id, o.id AS kept_by, n.name, n.key
+FROM names n
+JOIN owners o ON o.key = n.key
+WHERE n.id <> o.id;
+
+INSERT INTO author_name_conflicts (author_id, kept_by, name)
+SELECT id, kept_by, name FROM author_dropped_aliases;
+
+UPDATE authors a
+SET aliases = ARRAY(
+    SELECT n.alias
+    FROM unnest(a.aliases) WITH ORDINALITY AS n(alias, position)
+    WHERE author_name_key(n.alias) NOT IN (SELECT key FROM author_dropped_aliases d WHERE d.id = a.id)
+    ORDER BY n.position
+)
+WHERE a.id IN (SELECT id FROM author_dropped_aliases);
+
+DROP TABLE author_dropped_aliases;
+
+-- Sync the names of an author into author_aliases. The name wins over an alias
+-- spelling it the same way, and an alias over a later one.
+CREATE OR REPLACE FUNCTION refresh_author_aliases()
+RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM author_aliases WHERE author_id = NEW.id;
+
+    INSERT INTO author_aliases (author_id, name)
+    SELECT DISTINCT ON (author_name_key(n.name)) NEW.id, n.name
+    FROM unnest(array_prepend(NEW.name, NEW.aliases)) WITH ORDINALITY AS n(name, position)
+    ORDER BY author_name_key(n.name), n.position;
+
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER refresh_authors_aliases AFTER INSERT OR UPDATE OF name, aliases
+    ON authors FOR EACH ROW EXECUTE FUNCTION refresh_author_aliases();
+
+-- Back-fill author_aliases, every name now belonging to a single author
+INSERT INTO author_aliases (author_id, name)
+SELECT DISTINCT ON (a.id, author_name_key(n.name)) a.id, n.name
+FROM authors a, unnest(array_prepend(a.name, a.aliases)) WITH ORDINALITY AS n(name, position)
+ORDER BY a.id, author_name_key(n.name), n.position;